@@ -2,10 +2,12 @@ package server
 
 import (
 	"bytes"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io/ioutil"
 	"net"
+	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -15,7 +17,12 @@ import (
 
 	"testing"
 
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
 	"github.com/pkg/errors"
+
+	"github.com/metaverse/truss/truss/manifest"
+	"github.com/metaverse/truss/truss/parseproto"
 )
 
 const definitionDirectory = "test-service-definitions"
@@ -109,6 +116,444 @@ func TestBasicTypes(t *testing.T) {
 	testEndToEnd("1-basic", "getbasic", t)
 }
 
+func TestMultipleDirectories(t *testing.T) {
+	// The service is defined in svc/ and its messages in types/, each of
+	// which is a Go package of its own
+	testEndToEnd("1-multidir", "getbasic", t,
+		"--svcout", "./", "-I", ".", filepath.Join("svc", "basic.proto"), filepath.Join("types", "types.proto"))
+}
+
+func TestMultipleDirectoriesWithProtoc(t *testing.T) {
+	// The .pb.go files of each directory are generated by protoc and
+	// protoc-gen-gogofaster rather than in process
+	testEndToEnd("1-multidir", "getbasic", t,
+		"--protoc", "--svcout", "./", "-I", ".", filepath.Join("svc", "basic.proto"), filepath.Join("types", "types.proto"))
+}
+
+func TestConfigFile(t *testing.T) {
+	// truss.yaml names the definition and where the service and .pb.go
+	// files are generated
+	testEndToEnd("1-configfile", "getbasic", t)
+	pbPath := filepath.Join(basePath, "1-configfile", "test-service", "pb", "basic.pb.go")
+	if !fileExists(pbPath) {
+		t.Fatal("basic.pb.go was not generated within pbout")
+	}
+}
+
+// copyBasicDefinition copies the definition of 1-basic into dirName, without
+// any of the files 1-basic may have been generated.
+func copyBasicDefinition(t *testing.T, dirName string) string {
+	path := filepath.Join(basePath, dirName)
+	if err := os.MkdirAll(path, 0777); err != nil {
+		t.Fatal(err)
+	}
+	def, err := ioutil.ReadFile(filepath.Join(basePath, "1-basic", "basic.proto"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(path, "basic.proto"), def, 0666); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDryRunAndDiff(t *testing.T) {
+	path := copyBasicDefinition(t, "0-dryrun")
+	handlers := filepath.Join("test-service", "handlers", "handlers.go")
+
+	out, err := truss(path, "--dry-run")
+	if err != nil {
+		t.Fatalf("truss --dry-run failed: %v\n%s", err, out)
+	}
+	for _, want := range []string{"create basic.pb.go", "create " + handlers} {
+		if !strings.Contains(out, want) {
+			t.Errorf("truss --dry-run output does not contain %q:\n%s", want, out)
+		}
+	}
+	if fileExists(filepath.Join(path, "test-service")) || fileExists(filepath.Join(path, "basic.pb.go")) {
+		t.Fatal("truss --dry-run wrote files")
+	}
+
+	if err := createTrussService(path); err != nil {
+		t.Fatal(err)
+	}
+	out, err = truss(path, "--diff")
+	if err != nil {
+		t.Fatalf("truss --diff failed: %v\n%s", err, out)
+	}
+	if strings.Contains(out, "+++ ") {
+		t.Errorf("truss --diff reported changes to an unchanged service:\n%s", out)
+	}
+
+	if err := os.Remove(filepath.Join(path, handlers)); err != nil {
+		t.Fatal(err)
+	}
+	out, err = truss(path, "--diff")
+	if err != nil {
+		t.Fatalf("truss --diff failed: %v\n%s", err, out)
+	}
+	if want := "+++ b/" + filepath.ToSlash(handlers); !strings.Contains(out, want) {
+		t.Errorf("truss --diff output does not contain %q:\n%s", want, out)
+	}
+	if fileExists(filepath.Join(path, handlers)) {
+		t.Fatal("truss --diff wrote files")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	path := copyBasicDefinition(t, "0-verify")
+	if err := createTrussService(path); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := truss(path, "--verify"); err != nil {
+		t.Fatalf("truss --verify failed for an up to date service: %v\n%s", err, out)
+	}
+
+	handlers := filepath.Join("test-service", "handlers", "handlers.go")
+	if err := os.Remove(filepath.Join(path, handlers)); err != nil {
+		t.Fatal(err)
+	}
+	out, err := truss(path, "--verify")
+	if err == nil {
+		t.Fatalf("truss --verify succeeded for a stale service:\n%s", out)
+	}
+	if !strings.Contains(out, handlers) {
+		t.Errorf("truss --verify output does not contain %q:\n%s", handlers, out)
+	}
+	if fileExists(filepath.Join(path, handlers)) {
+		t.Fatal("truss --verify wrote files")
+	}
+}
+
+func TestDump(t *testing.T) {
+	path := copyBasicDefinition(t, "0-dump")
+	dump := exec.Command("truss", "dump", "basic.proto")
+	dump.Dir = path
+	out, err := dump.Output()
+	if err != nil {
+		t.Fatalf("truss dump failed: %v", err)
+	}
+
+	var def struct {
+		Services []struct {
+			Name string `json:"name"`
+		} `json:"services"`
+		Messages []struct {
+			ID string `json:"id"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(out, &def); err != nil {
+		t.Fatalf("truss dump printed invalid JSON: %v\n%s", err, out)
+	}
+	if len(def.Services) != 1 || def.Services[0].Name != "TEST" {
+		t.Errorf("truss dump printed services %+v, want TEST", def.Services)
+	}
+	if len(def.Messages) == 0 {
+		t.Error("truss dump printed no messages")
+	}
+
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("truss dump wrote files: %v", files)
+	}
+}
+
+func TestDoc(t *testing.T) {
+	path := copyBasicDefinition(t, "0-doc")
+	if out, err := truss(path, "doc"); err != nil {
+		t.Fatalf("truss doc failed: %v\n%s", err, out)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(path, "test-service", "docs", "docs.md"))
+	if err != nil {
+		t.Fatalf("docs were not generated: %v", err)
+	}
+	if !strings.Contains(string(b), "#### TEST") {
+		t.Errorf("docs/docs.md does not document the service:\n%s", b)
+	}
+	// Only the documentation is generated
+	if fileExists(filepath.Join(path, "basic.pb.go")) {
+		t.Error("truss doc generated .pb.go files")
+	}
+	if fileExists(filepath.Join(path, "test-service", "svc")) {
+		t.Error("truss doc generated the service")
+	}
+}
+
+func TestOrphanedFiles(t *testing.T) {
+	path := copyBasicDefinition(t, "0-orphans")
+	if err := createTrussService(path); err != nil {
+		t.Fatal(err)
+	}
+	svcPath := filepath.Join(path, "test-service")
+	m, err := manifest.Read(svcPath)
+	if err != nil || m == nil {
+		t.Fatalf("cannot read manifest of generated service: %v", err)
+	}
+
+	write := func(name, content string) {
+		p := filepath.Join(path, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A file generated by a previous version of the templates, and a file
+	// truss never generated
+	m.Add("svc/server/cli/cli.go", []byte("package cli\n"))
+	write("test-service/svc/server/cli/cli.go", "package cli\n")
+	write("test-service/svc/notes.txt", "mine\n")
+	write("test-service/"+manifest.FileName, string(m.Marshal()))
+
+	// The tree of a service which is no longer defined, one file of which
+	// has been modified since it was generated
+	old := manifest.New(m.PBPackage, "Old", m.Inputs)
+	old.Add("svc/endpoints.go", []byte("package svc\n"))
+	old.Add("handlers/handlers.go", []byte("package handlers\n"))
+	write("old-service/svc/endpoints.go", "package svc\n")
+	write("old-service/handlers/handlers.go", "package handlers\n\n// mine\n")
+	write("old-service/"+manifest.FileName, string(old.Marshal()))
+
+	// The tree of a service of the same Go package generated from other
+	// definition files, which may still define it
+	other := manifest.New(m.PBPackage, "Other", []string{"other.proto"})
+	other.Add("svc/endpoints.go", []byte("package svc\n"))
+	write("other-service/svc/endpoints.go", "package svc\n")
+	write("other-service/"+manifest.FileName, string(other.Marshal()))
+
+	if err := createTrussService(path); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"test-service/svc/server/cli", "old-service/svc"} {
+		if fileExists(filepath.Join(path, filepath.FromSlash(name))) {
+			t.Errorf("%s is no longer generated but was not removed", name)
+		}
+	}
+	for _, name := range []string{"test-service/svc/notes.txt", "old-service/handlers/handlers.go", "old-service/" + manifest.FileName, "other-service/svc/endpoints.go"} {
+		if !fileExists(filepath.Join(path, filepath.FromSlash(name))) {
+			t.Errorf("%s was removed", name)
+		}
+	}
+}
+
+func TestOldFilesWithoutManifest(t *testing.T) {
+	path := copyBasicDefinition(t, "0-oldfiles")
+	cli := filepath.Join(path, "test-service", "svc", "client", "cli", "cli.go")
+	if err := os.MkdirAll(filepath.Dir(cli), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(cli, []byte("package cli\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := createTrussService(path); err != nil {
+		t.Fatal(err)
+	}
+	if fileExists(filepath.Dir(cli)) {
+		t.Error("svc/client/cli of a tree without a manifest was not removed")
+	}
+}
+
+func TestTemplateOverlays(t *testing.T) {
+	path := copyBasicDefinition(t, "0-templates")
+	overlay := filepath.Join(path, "templates", "svc", "name.gotemplate")
+	if err := os.MkdirAll(filepath.Dir(overlay), 0777); err != nil {
+		t.Fatal(err)
+	}
+	templ := "package svc\n\n// ServiceName is the name of the service\nconst ServiceName = \"{{.Service.Name}}\"\n"
+	if err := ioutil.WriteFile(overlay, []byte(templ), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := createTrussService(path, "--templates", "templates"); err != nil {
+		t.Fatal(err)
+	}
+	svcPath := filepath.Join(path, "test-service")
+	b, err := ioutil.ReadFile(filepath.Join(svcPath, "svc", "name.go"))
+	if err != nil {
+		t.Fatalf("file added by the overlay was not generated: %v", err)
+	}
+	if !strings.Contains(string(b), `const ServiceName = "TEST"`) {
+		t.Errorf("svc/name.go was not rendered from the overlay:\n%s", b)
+	}
+	if err := buildTestService(svcPath); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerators(t *testing.T) {
+	path := copyBasicDefinition(t, "0-generators")
+	if err := createTrussService(path, "--generators", "service", "--generators", "docs", "--generators", "openapi"); err != nil {
+		t.Fatal(err)
+	}
+	svcPath := filepath.Join(path, "test-service")
+	b, err := ioutil.ReadFile(filepath.Join(svcPath, "docs", "docs.md"))
+	if err != nil {
+		t.Fatalf("docs were not generated: %v", err)
+	}
+	if !strings.Contains(string(b), "TEST") {
+		t.Errorf("docs/docs.md does not document the service:\n%s", b)
+	}
+	b, err = ioutil.ReadFile(filepath.Join(svcPath, "docs", "openapi.json"))
+	if err != nil {
+		t.Fatalf("the OpenAPI document was not generated: %v", err)
+	}
+	var api struct {
+		OpenAPI string                 `json:"openapi"`
+		Paths   map[string]interface{} `json:"paths"`
+	}
+	if err := json.Unmarshal(b, &api); err != nil {
+		t.Fatalf("cannot decode docs/openapi.json: %v", err)
+	}
+	if api.OpenAPI == "" || len(api.Paths) == 0 {
+		t.Errorf("docs/openapi.json does not describe the service:\n%s", b)
+	}
+	if err := buildTestService(svcPath); err != nil {
+		t.Fatal(err)
+	}
+
+	// The HTML documentation is served by the debug listener
+	debugPort := strconv.Itoa(FindFreePort())
+	server, srvrOut, errc := runServer(svcPath,
+		"-grpc.addr", ":"+strconv.Itoa(FindFreePort()),
+		"-http.addr", ":"+strconv.Itoa(FindFreePort()),
+		"-debug.addr", ":"+debugPort)
+	page, err := getWithRetry("http://localhost:" + debugPort + "/debug/docs")
+	if err := reapServer(server, errc); err != nil {
+		t.Logf("Server Output\n%v", srvrOut.String())
+		t.Fatalf("cannot reap server: %v", err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(page, `id="service-TEST"`) {
+		t.Errorf("/debug/docs does not document the service:\n%s", page)
+	}
+
+	// With only docs, the files of the service are no longer generated
+	if err := createTrussService(path, "--generators", "docs"); err != nil {
+		t.Fatal(err)
+	}
+	if fileExists(filepath.Join(svcPath, "svc", "endpoints.go")) {
+		t.Error("svc/endpoints.go was not removed")
+	}
+	if !fileExists(filepath.Join(svcPath, "docs", "docs.md")) {
+		t.Error("docs/docs.md was removed")
+	}
+}
+
+func TestAPIExplorer(t *testing.T) {
+	path := filepath.Join(basePath, "0-basic", "test-service")
+	debugURL := "http://localhost:" + strconv.Itoa(FindFreePort())
+	httpAddr := ":" + strconv.Itoa(FindFreePort())
+	server, srvrOut, errc := runServer(path,
+		"-grpc.addr", ":"+strconv.Itoa(FindFreePort()),
+		"-http.addr", httpAddr,
+		"-debug.addr", strings.TrimPrefix(debugURL, "http://localhost"))
+	defer func() {
+		if err := reapServer(server, errc); err != nil {
+			t.Logf("Server Output\n%v", srvrOut.String())
+			t.Fatalf("cannot reap server: %v", err)
+		}
+	}()
+
+	doc, err := getWithRetry(debugURL + "/debug/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var api struct {
+		Paths map[string]interface{} `json:"paths"`
+	}
+	if err := json.Unmarshal([]byte(doc), &api); err != nil {
+		t.Fatalf("cannot decode /debug/openapi.json: %v", err)
+	}
+	if api.Paths["/1"] == nil || api.Paths["/2"] == nil {
+		t.Errorf("/debug/openapi.json does not describe the service:\n%s", doc)
+	}
+
+	page, err := getWithRetry(debugURL + "/debug/explorer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(page, "TEST API Explorer") {
+		t.Errorf("/debug/explorer is not the explorer of the service:\n%s", page)
+	}
+
+	if !strings.Contains(page, `data-addr="`+httpAddr+`"`) {
+		t.Errorf("/debug/explorer does not send requests to %v:\n%s", httpAddr, page)
+	}
+
+	// The explorer sends its requests from its own origin to the HTTP
+	// listener, which a browser first asks whether it allows them
+	httpURL := "http://localhost" + httpAddr
+	resp, err := originRequest(http.MethodOptions, httpURL+"/2", debugURL, "", http.Header{
+		"Access-Control-Request-Method":  {"POST"},
+		"Access-Control-Request-Headers": {"content-type"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode/100 != 2 ||
+		resp.Header.Get("Access-Control-Allow-Origin") != debugURL ||
+		!strings.Contains(resp.Header.Get("Access-Control-Allow-Methods"), "POST") ||
+		!strings.Contains(strings.ToLower(resp.Header.Get("Access-Control-Allow-Headers")), "content-type") {
+		t.Errorf("preflight request from the explorer was answered %v, %v", resp.Status, resp.Header)
+	}
+
+	resp, err = originRequest(http.MethodPost, httpURL+"/2", debugURL, `{"C":3,"M":"m"}`, http.Header{
+		"Content-Type": {"application/json"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Access-Control-Allow-Origin") != debugURL {
+		t.Errorf("request from the explorer was answered %v, %v", resp.Status, resp.Header)
+	}
+
+	// Pages of other origins are not allowed to read responses
+	resp, err = originRequest(http.MethodGet, httpURL+"/1?C=3", "http://localhost:1", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("request from another origin was allowed for %q", got)
+	}
+
+	// The debug listener forwards nothing to the HTTP listener
+	if _, err := getWithRetry(debugURL + "/debug/api/1?C=3"); err == nil {
+		t.Error("/debug/api/ is served by the debug listener")
+	}
+}
+
+// originRequest sends a request of method to url with body, as a page of
+// origin would along with header, returning the response with its body read.
+func originRequest(method, url, origin, body string, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Origin", origin)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%v %v", method, url)
+	}
+	defer resp.Body.Close()
+	if _, err := ioutil.ReadAll(resp.Body); err != nil {
+		return nil, errors.Wrapf(err, "%v %v", method, url)
+	}
+	return resp, nil
+}
+
 func TestBasicTypesWithRelSVCOutFlag(t *testing.T) {
 	svcOut := "./metaverse"
 	path := filepath.Join(basePath, "1-basic")
@@ -181,6 +626,116 @@ func TestMessageOnly(t *testing.T) {
 	}
 }
 
+func TestMultipleServices(t *testing.T) {
+	path := filepath.Join(basePath, "9-multiple_services")
+	err := createTrussService(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = buildTestService(filepath.Join(path, "test-service"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fileExists(filepath.Join(path, "admin-service", "cmd", "admin", "main.go")) {
+		t.Fatal("admin-service was not generated")
+	}
+}
+
+func TestMultipleServicesCombined(t *testing.T) {
+	svcOut := "./combined"
+	path := filepath.Join(basePath, "9-multiple_services")
+	err := createTrussService(path, "--combined", "--svcout", svcOut)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path = filepath.Join(path, svcOut)
+	for _, tree := range []string{"test-service", "admin-service"} {
+		if !fileExists(filepath.Join(path, tree, "handlers", "handlers.go")) {
+			t.Fatalf("%s was not generated within the combined tree", tree)
+		}
+	}
+	err = buildTestService(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	grpcPort := strconv.Itoa(FindFreePort())
+	httpPort := strconv.Itoa(FindFreePort())
+	debugPort := strconv.Itoa(FindFreePort())
+
+	server, srvrOut, errc := runServer(path,
+		"-grpc.addr", ":"+grpcPort,
+		"-http.addr", ":"+httpPort,
+		"-debug.addr", ":"+debugPort)
+
+	// The HTTP listener allows requests of the API explorers of the debug
+	// listener
+	debugURL := "http://localhost:" + debugPort
+	if _, err := getWithRetry(debugURL + "/debug/pprof/"); err != nil {
+		t.Error(err)
+	} else if resp, err := originRequest(http.MethodOptions, "http://localhost:"+httpPort+"/1", debugURL, "", http.Header{
+		"Access-Control-Request-Method": {"GET"},
+	}); err != nil {
+		t.Error(err)
+	} else if resp.Header.Get("Access-Control-Allow-Origin") != debugURL {
+		t.Errorf("preflight request from the explorer was answered %v, %v", resp.Status, resp.Header)
+	}
+
+	err = reapServer(server, errc)
+	if err != nil {
+		t.Logf("Server Output\n%v", srvrOut.String())
+		t.Fatalf("cannot reap server: %v", err)
+	}
+}
+
+func TestDescriptorSet(t *testing.T) {
+	// The descriptor set is generated into a directory without any .proto
+	// files, so truss may only generate from the set
+	path := filepath.Join(basePath, "0-descriptor_set")
+	if err := os.MkdirAll(path, 0777); err != nil {
+		t.Fatal(err)
+	}
+	defDir := filepath.Join(basePath, "1-multifile")
+	files, err := parseproto.Files(
+		[]string{filepath.Join(defDir, "basic.proto"), filepath.Join(defDir, "imported.proto")},
+		append([]string{defDir}, parseproto.GoPathImports(filepath.SplitList(os.Getenv("GOPATH")))...),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	set, err := proto.Marshal(&descriptor.FileDescriptorSet{File: files})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(path, "definition.pb"), set, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	err = createTrussService(path, "--descriptor_set_in", "definition.pb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fileExists(filepath.Join(path, "basic.pb.go")) {
+		t.Fatal("basic.pb.go was not generated from the descriptor set")
+	}
+	path = filepath.Join(path, "test-service")
+	err = buildTestService(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server, srvrOut, errc := runServer(path,
+		"-grpc.addr", ":"+strconv.Itoa(FindFreePort()),
+		"-http.addr", ":"+strconv.Itoa(FindFreePort()),
+		"-debug.addr", ":"+strconv.Itoa(FindFreePort()))
+
+	err = reapServer(server, errc)
+	if err != nil {
+		t.Logf("Server Output\n%v", srvrOut.String())
+		t.Fatalf("cannot reap server: %v", err)
+	}
+}
+
 func testEndToEnd(defDir string, subcmd string, t *testing.T, trussOptions ...string) {
 	path := filepath.Join(basePath, defDir)
 	err := createTrussService(path, trussOptions...)
@@ -362,6 +917,25 @@ func reapServer(server *exec.Cmd, errc chan error) error {
 	return nil
 }
 
+// getWithRetry returns the body of a GET of url, retrying while the server
+// starts up.
+func getWithRetry(url string) (string, error) {
+	var err error
+	for i := 0; i < 20; i++ {
+		var resp *http.Response
+		if resp, err = http.Get(url); err == nil {
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				return "", errors.Errorf("GET %v: %v", url, resp.Status)
+			}
+			b, err := ioutil.ReadAll(resp.Body)
+			return string(b), err
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return "", errors.Wrapf(err, "GET %v", url)
+}
+
 // fileExists checks if a file at the given path exists. Returns true if the
 // file exists, and false if the file does not exist.
 func fileExists(path string) bool {
@@ -375,6 +949,21 @@ func fileExists(path string) bool {
 func cleanTests(servicesDir string) {
 	// Remove the 0-basic used for non building tests
 	os.RemoveAll(filepath.Join(servicesDir, "0-basic"))
+	// Remove the directory the descriptor set is generated into
+	os.RemoveAll(filepath.Join(servicesDir, "0-descriptor_set"))
+	// Remove the copies of the "1-basic" definition regenerated with
+	// --dry-run, --diff and --verify
+	os.RemoveAll(filepath.Join(servicesDir, "0-dryrun"))
+	os.RemoveAll(filepath.Join(servicesDir, "0-verify"))
+	// Remove the copy of the "1-basic" definition orphaned files are
+	// removed from
+	os.RemoveAll(filepath.Join(servicesDir, "0-orphans"))
+	// Remove the copy of the "1-basic" definition generated with template
+	// overlays
+	os.RemoveAll(filepath.Join(servicesDir, "0-templates"))
+	os.RemoveAll(filepath.Join(servicesDir, "0-generators"))
+	os.RemoveAll(filepath.Join(servicesDir, "0-dump"))
+	os.RemoveAll(filepath.Join(servicesDir, "0-doc"))
 	// Clean up the service directories in each test
 	dirs, _ := ioutil.ReadDir(servicesDir)
 	for _, d := range dirs {
@@ -393,11 +982,19 @@ func removeTestFiles(defDir string) {
 	os.RemoveAll(filepath.Join(defDir, "metaverse"))
 	// service dir
 	os.RemoveAll(filepath.Join(defDir, "test-service"))
+	os.RemoveAll(filepath.Join(defDir, "admin-service"))
+	// combined svcout dir
+	os.RemoveAll(filepath.Join(defDir, "combined"))
 	// where the binaries are compiled to
 	os.RemoveAll(filepath.Join(defDir, "bin"))
-	// Remove all the .pb.go files which may remain
+	// Remove all the .pb.go files which may remain, including those of
+	// definitions spanning several directories
 	dirs, _ := ioutil.ReadDir(defDir)
 	for _, d := range dirs {
+		if d.IsDir() {
+			removeTestFiles(filepath.Join(defDir, d.Name()))
+			continue
+		}
 		if strings.HasSuffix(d.Name(), ".pb.go") {
 			os.RemoveAll(filepath.Join(defDir, d.Name()))
 		}
@@ -1,6 +1,8 @@
 package test
 
 import (
+	"io"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -67,3 +69,125 @@ func TestHTTPErrorStatusCodeAndHeadersWithGRPC(t *testing.T) {
 		t.Fatalf("Expected error")
 	}
 }
+
+func TestServerStreamWithGRPC(t *testing.T) {
+	conn, err := grpc.Dial(grpcAddr, grpc.WithInsecure(), grpc.WithTimeout(time.Second))
+	if err != nil {
+		t.Fatalf("failed to dial: %q", err)
+	}
+	client := pb.NewTransportPermutationsClient(conn)
+
+	stream, err := client.StreamCount(context.Background(), &pb.GetWithQueryRequest{A: 2, B: 5})
+	if err != nil {
+		t.Fatalf("StreamCount returned error: %q", err)
+	}
+	var got []int64
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("stream returned error: %q", err)
+		}
+		got = append(got, resp.V)
+	}
+
+	if want := []int64{2, 3, 4}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Expect: %v, got %v", want, got)
+	}
+}
+
+func TestClientStreamWithGRPC(t *testing.T) {
+	conn, err := grpc.Dial(grpcAddr, grpc.WithInsecure(), grpc.WithTimeout(time.Second))
+	if err != nil {
+		t.Fatalf("failed to dial: %q", err)
+	}
+	client := pb.NewTransportPermutationsClient(conn)
+
+	stream, err := client.StreamSum(context.Background())
+	if err != nil {
+		t.Fatalf("StreamSum returned error: %q", err)
+	}
+	for i := int64(0); i < 3; i++ {
+		if err := stream.Send(&pb.GetWithQueryRequest{A: i, B: 10}); err != nil {
+			t.Fatalf("stream returned error: %q", err)
+		}
+	}
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		t.Fatalf("stream returned error: %q", err)
+	}
+
+	if want := int64(33); resp.V != want {
+		t.Fatalf("Expect: %d, got %d", want, resp.V)
+	}
+}
+
+func TestBidiStreamWithGRPC(t *testing.T) {
+	conn, err := grpc.Dial(grpcAddr, grpc.WithInsecure(), grpc.WithTimeout(time.Second))
+	if err != nil {
+		t.Fatalf("failed to dial: %q", err)
+	}
+	client := pb.NewTransportPermutationsClient(conn)
+
+	stream, err := client.StreamEcho(context.Background())
+	if err != nil {
+		t.Fatalf("StreamEcho returned error: %q", err)
+	}
+	for i := int64(0); i < 3; i++ {
+		if err := stream.Send(&pb.GetWithQueryRequest{A: i, B: i}); err != nil {
+			t.Fatalf("stream returned error: %q", err)
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("stream returned error: %q", err)
+		}
+		if want := 2 * i; resp.V != want {
+			t.Fatalf("Expect: %d, got %d", want, resp.V)
+		}
+	}
+	if err := stream.CloseSend(); err != nil {
+		t.Fatalf("stream returned error: %q", err)
+	}
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Fatalf("Expect: io.EOF, got %v", err)
+	}
+}
+
+// countCollector implements pb.TransportPermutations_StreamCountServer,
+// collecting each sent response.
+type countCollector struct {
+	grpc.ServerStream
+	got []int64
+}
+
+func (c *countCollector) Context() context.Context {
+	return context.Background()
+}
+
+func (c *countCollector) Send(resp *pb.GetWithQueryResponse) error {
+	c.got = append(c.got, resp.V)
+	return nil
+}
+
+func TestServerStreamWithGRPCClient(t *testing.T) {
+	conn, err := grpc.Dial(grpcAddr, grpc.WithInsecure(), grpc.WithTimeout(time.Second))
+	if err != nil {
+		t.Fatalf("failed to dial: %q", err)
+	}
+	svcgrpc, err := grpcclient.New(conn)
+	if err != nil {
+		t.Fatalf("failed to create grpcclient: %q", err)
+	}
+
+	var c countCollector
+	err = svcgrpc.StreamCount(&pb.GetWithQueryRequest{A: 0, B: 3}, &c)
+	if err != nil {
+		t.Fatalf("grpcclient returned error: %q", err)
+	}
+
+	if want := []int64{0, 1, 2}; !reflect.DeepEqual(c.got, want) {
+		t.Fatalf("Expect: %v, got %v", want, c.got)
+	}
+}
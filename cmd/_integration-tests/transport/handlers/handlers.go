@@ -6,6 +6,7 @@ package handlers
 import (
 	"context"
 	"github.com/pkg/errors"
+	"io"
 	"net/http"
 
 	pb "github.com/metaverse/truss/cmd/_integration-tests/transport/proto"
@@ -190,3 +191,79 @@ func (s transportpermutationsService) CustomVerb(ctx context.Context, in *pb.Get
 	}
 	return &response, nil
 }
+
+// GetWithPathTemplate implements Service.
+func (s transportpermutationsService) GetWithPathTemplate(ctx context.Context, in *pb.PathTemplateRequest) (*pb.PathTemplateRequest, error) {
+	return in, nil
+}
+
+// DescribeWithVerb implements Service.
+func (s transportpermutationsService) DescribeWithVerb(ctx context.Context, in *pb.PathTemplateRequest) (*pb.PathTemplateRequest, error) {
+	return &pb.PathTemplateRequest{Name: "described", Id: in.Id}, nil
+}
+
+// GetWithResponseBody implements Service.
+func (s transportpermutationsService) GetWithResponseBody(ctx context.Context, in *pb.GetWithQueryRequest) (*pb.ResponseBodyResponse, error) {
+	var resp pb.ResponseBodyResponse
+	for i := in.A; i < in.B; i++ {
+		resp.Items = append(resp.Items, &pb.GetWithQueryResponse{V: i})
+	}
+	resp.Total = int64(len(resp.Items))
+	return &resp, nil
+}
+
+// GetWithNestedQuery implements Service.
+func (s transportpermutationsService) GetWithNestedQuery(ctx context.Context, in *pb.NestedQueryRequest) (*pb.NestedQueryRequest, error) {
+	return in, nil
+}
+
+// GetWithWellKnownTypes implements Service.
+func (s transportpermutationsService) GetWithWellKnownTypes(ctx context.Context, in *pb.WellKnownTypesRequest) (*pb.WellKnownTypesRequest, error) {
+	return in, nil
+}
+
+// PostWithBodyField implements Service.
+func (s transportpermutationsService) PostWithBodyField(ctx context.Context, in *pb.BodyFieldRequest) (*pb.BodyFieldRequest, error) {
+	return in, nil
+}
+
+// StreamCount implements Service.
+func (s transportpermutationsService) StreamCount(in *pb.GetWithQueryRequest, stream pb.TransportPermutations_StreamCountServer) error {
+	for i := in.A; i < in.B; i++ {
+		if err := stream.Send(&pb.GetWithQueryResponse{V: i}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StreamSum implements Service.
+func (s transportpermutationsService) StreamSum(stream pb.TransportPermutations_StreamSumServer) error {
+	var resp pb.GetWithQueryResponse
+	for {
+		in, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(&resp)
+		}
+		if err != nil {
+			return err
+		}
+		resp.V += in.A + in.B
+	}
+}
+
+// StreamEcho implements Service.
+func (s transportpermutationsService) StreamEcho(stream pb.TransportPermutations_StreamEchoServer) error {
+	for {
+		in, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(&pb.GetWithQueryResponse{V: in.A + in.B}); err != nil {
+			return err
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strings"
 
 	// 3d Party
@@ -19,6 +20,7 @@ import (
 	httpclient "github.com/metaverse/truss/cmd/_integration-tests/transport/transportpermutations-service/svc/client/http"
 
 	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/types"
 	"github.com/moul/http2curl"
 	"github.com/pkg/errors"
 
@@ -778,6 +780,236 @@ func TestCustomVerbClient(t *testing.T) {
 	}
 }
 
+// Test that variables bound to several segments, and to a single segment
+// before a custom verb, are matched and unescaped as google.api.HttpRule
+// prescribes
+func TestPathTemplateRequest(t *testing.T) {
+	tests := []struct {
+		route   string
+		expects pb.PathTemplateRequest
+	}{
+		// Escaped slashes are kept escaped in a variable of several segments
+		{"v1/shelves/s1/books/a%2Fb/c", pb.PathTemplateRequest{Name: "shelves/s1/books/a%2Fb/c"}},
+		{"v1/shelves/s1/books", pb.PathTemplateRequest{Name: "shelves/s1/books"}},
+		// while they are unescaped in a variable of a single segment
+		{"v1/shelves/a%2Fb%20c", pb.PathTemplateRequest{Id: "a/b c"}},
+		// The custom verb is not taken for part of the id by the other route
+		{"v1/shelves/a%2Fb:describe", pb.PathTemplateRequest{Name: "described", Id: "a/b"}},
+	}
+	for _, tt := range tests {
+		var resp pb.PathTemplateRequest
+		if err := testHTTP(t, &resp, &tt.expects, nil, "GET", "%s", tt.route); err != nil {
+			t.Fatal(errors.Wrap(err, "cannot make http request"))
+		}
+	}
+}
+
+// Test that the generated client escapes the values of path variables the
+// way the server unescapes them
+func TestPathTemplateClient(t *testing.T) {
+	svchttp, err := httpclient.New(httpAddr)
+	if err != nil {
+		t.Fatalf("failed to create httpclient: %q", err)
+	}
+
+	req := pb.PathTemplateRequest{Name: "shelves/s 1/books/a:b/c"}
+	resp, err := svchttp.GetWithPathTemplate(context.Background(), &req)
+	if err != nil {
+		t.Fatalf("httpclient returned error: %q", err)
+	}
+	if resp.Name != req.Name {
+		t.Fatalf("Expect: %q, got %q", req.Name, resp.Name)
+	}
+
+	req = pb.PathTemplateRequest{Id: "a/b c:d"}
+	resp, err = svchttp.DescribeWithVerb(context.Background(), &req)
+	if err != nil {
+		t.Fatalf("httpclient returned error: %q", err)
+	}
+	if resp.Name != "described" || resp.Id != req.Id {
+		t.Fatalf("Expect: described %q, got %q %q", req.Id, resp.Name, resp.Id)
+	}
+}
+
+// Test that only the response_body field of the response is encoded in the
+// body
+func TestResponseBodyRequest(t *testing.T) {
+	respBytes, err := httpRequestBuilder{
+		method: "GET",
+		route:  "responsebody?A=1&B=3",
+	}.Test(t)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "cannot make http request"))
+	}
+
+	if got, want := string(respBytes), `[{"V":"1"},{"V":"2"}]`; got != want {
+		t.Fatalf("Expect: %s, got %s", want, got)
+	}
+}
+
+// Test that the generated client decodes the response_body field back into
+// the response
+func TestResponseBodyClient(t *testing.T) {
+	svchttp, err := httpclient.New(httpAddr)
+	if err != nil {
+		t.Fatalf("failed to create httpclient: %q", err)
+	}
+
+	resp, err := svchttp.GetWithResponseBody(context.Background(), &pb.GetWithQueryRequest{A: 1, B: 3})
+	if err != nil {
+		t.Fatalf("httpclient returned error: %q", err)
+	}
+	want := &pb.ResponseBodyResponse{
+		Items: []*pb.GetWithQueryResponse{{V: 1}, {V: 2}},
+	}
+	if !reflect.DeepEqual(resp, want) {
+		t.Fatalf("Expect: %+v, got %+v", want, resp)
+	}
+}
+
+// Test that a body bound to a field is decoded into that field, while the
+// other fields are taken from the path and query
+func TestBodyFieldRequest(t *testing.T) {
+	expects := pb.BodyFieldRequest{
+		Id:    "x",
+		Book:  &pb.PathTemplateRequest{Name: "a book"},
+		Count: 3,
+	}
+	body := []byte(`{"name": "a book"}`)
+
+	var resp pb.BodyFieldRequest
+	if err := testHTTP(t, &resp, &expects, body, "POST", "bodyfield/%s?count=%d", expects.Id, expects.Count); err != nil {
+		t.Fatal(errors.Wrap(err, "cannot make http request"))
+	}
+}
+
+// Test that the generated client sends only the field bound to the body in
+// the body
+func TestBodyFieldClient(t *testing.T) {
+	svchttp, err := httpclient.New(httpAddr)
+	if err != nil {
+		t.Fatalf("failed to create httpclient: %q", err)
+	}
+
+	req := pb.BodyFieldRequest{
+		Id:    "x",
+		Book:  &pb.PathTemplateRequest{Name: "a book", Id: "b"},
+		Count: 3,
+	}
+	resp, err := svchttp.PostWithBodyField(context.Background(), &req)
+	if err != nil {
+		t.Fatalf("httpclient returned error: %q", err)
+	}
+	if !reflect.DeepEqual(resp, &req) {
+		t.Fatalf("Expect: %+v, got %+v", &req, resp)
+	}
+}
+
+// Test that dotted query parameters set the fields of a message in the
+// query, including repeated and enum fields
+func TestNestedQueryRequest(t *testing.T) {
+	expects := pb.NestedQueryRequest{
+		Filter: &pb.NestedQueryFilter{
+			Author:   &pb.PathTemplateRequest{Name: "x"},
+			Year:     2020,
+			Tags:     []string{"a", "b"},
+			Status:   pb.TestStatus_test_passed,
+			Statuses: []pb.TestStatus{pb.TestStatus_test_passed, pb.TestStatus_test_failed},
+		},
+	}
+
+	var resp pb.NestedQueryRequest
+	err := testHTTP(t, &resp, &expects, nil, "GET",
+		"nestedquery?filter.author.name=x&filter.year=2020&filter.tags=a&filter.tags=b&filter.status=test_passed&filter.statuses=1&filter.statuses=test_failed")
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "cannot make http request"))
+	}
+
+	// A message may still be given as JSON
+	resp = pb.NestedQueryRequest{}
+	expects = pb.NestedQueryRequest{Filter: &pb.NestedQueryFilter{Year: 2020}}
+	if err := testHTTP(t, &resp, &expects, nil, "GET", "nestedquery?filter=%s", url.QueryEscape(`{"year":2020}`)); err != nil {
+		t.Fatal(errors.Wrap(err, "cannot make http request"))
+	}
+}
+
+// Test that the generated client sends a message in the query as dotted query
+// parameters
+func TestNestedQueryClient(t *testing.T) {
+	svchttp, err := httpclient.New(httpAddr)
+	if err != nil {
+		t.Fatalf("failed to create httpclient: %q", err)
+	}
+
+	req := pb.NestedQueryRequest{
+		Filter: &pb.NestedQueryFilter{
+			Author:   &pb.PathTemplateRequest{Name: "x y", Id: "1"},
+			Year:     2020,
+			Tags:     []string{"a", "b,c"},
+			Status:   pb.TestStatus_test_passed,
+			Statuses: []pb.TestStatus{pb.TestStatus_test_passed, pb.TestStatus_test_failed},
+		},
+	}
+	resp, err := svchttp.GetWithNestedQuery(context.Background(), &req)
+	if err != nil {
+		t.Fatalf("httpclient returned error: %q", err)
+	}
+	if !reflect.DeepEqual(resp, &req) {
+		t.Fatalf("Expect: %+v, got %+v", &req, resp)
+	}
+}
+
+func TestWellKnownTypesRequest(t *testing.T) {
+	expects := pb.WellKnownTypesRequest{
+		Since:   &types.Timestamp{Seconds: 1577934245},
+		Timeout: &types.Duration{Seconds: 1, Nanos: 500000000},
+		Mask:    &types.FieldMask{Paths: []string{"name", "parent.since"}},
+		Limit:   &types.Int64Value{Value: 10},
+		Name:    &types.StringValue{Value: "a,b"},
+		Active:  &types.BoolValue{Value: true},
+		Until:   &types.Timestamp{Seconds: 1577934245, Nanos: 500000000},
+		Parent: &pb.WellKnownTypesRequest{
+			Since: &types.Timestamp{Seconds: 1577934245},
+			Limit: &types.Int64Value{Value: 5},
+		},
+	}
+
+	var resp pb.WellKnownTypesRequest
+	err := testHTTP(t, &resp, &expects, nil, "GET",
+		"wellknowntypes/2020-01-02T03:04:05Z/1.5s?mask=name,parent.since&limit=10&name=a,b&active=true&until=%s&parent.since=2020-01-02T03:04:05Z&parent.limit=5",
+		url.QueryEscape("2020-01-02T04:04:05.5+01:00"))
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "cannot make http request"))
+	}
+}
+
+// Test that the generated client sends well-known types by their string forms
+func TestWellKnownTypesClient(t *testing.T) {
+	svchttp, err := httpclient.New(httpAddr)
+	if err != nil {
+		t.Fatalf("failed to create httpclient: %q", err)
+	}
+
+	req := pb.WellKnownTypesRequest{
+		Since:   &types.Timestamp{Seconds: 1577934245, Nanos: 1000},
+		Timeout: &types.Duration{Seconds: -90},
+		Mask:    &types.FieldMask{Paths: []string{"name", "parent.since"}},
+		Limit:   &types.Int64Value{Value: -9007199254740993},
+		Name:    &types.StringValue{Value: "a b/c"},
+		Active:  &types.BoolValue{},
+		Parent: &pb.WellKnownTypesRequest{
+			Until: &types.Timestamp{Seconds: 1577934245},
+		},
+	}
+	resp, err := svchttp.GetWithWellKnownTypes(context.Background(), &req)
+	if err != nil {
+		t.Fatalf("httpclient returned error: %q", err)
+	}
+	if !reflect.DeepEqual(resp, &req) {
+		t.Fatalf("Expect: %+v, got %+v", &req, resp)
+	}
+}
+
 // Helpers
 
 // Generic way to test that making an HTTP request returns the expected data,
@@ -820,6 +1052,14 @@ func testHTTP(
 		err = jsonpb.UnmarshalString(string(respBytes), v)
 	case *pb.GetWithOneofResponse:
 		err = jsonpb.UnmarshalString(string(respBytes), v)
+	case *pb.PathTemplateRequest:
+		err = jsonpb.UnmarshalString(string(respBytes), v)
+	case *pb.BodyFieldRequest:
+		err = jsonpb.UnmarshalString(string(respBytes), v)
+	case *pb.NestedQueryRequest:
+		err = jsonpb.UnmarshalString(string(respBytes), v)
+	case *pb.WellKnownTypesRequest:
+		err = jsonpb.UnmarshalString(string(respBytes), v)
 	default:
 		t.Fatalf("Unknown response type: %T", v)
 	}
@@ -42,6 +42,12 @@ func TestMain(m *testing.M) {
 	StatusCodeAndNilHeadersE := svc.MakeStatusCodeAndNilHeadersEndpoint(service)
 	StatusCodeAndHeadersE := svc.MakeStatusCodeAndHeadersEndpoint(service)
 	CustomVerbE := svc.MakeCustomVerbEndpoint(service)
+	getWithPathTemplateE := svc.MakeGetWithPathTemplateEndpoint(service)
+	describeWithVerbE := svc.MakeDescribeWithVerbEndpoint(service)
+	getWithResponseBodyE := svc.MakeGetWithResponseBodyEndpoint(service)
+	postWithBodyFieldE := svc.MakePostWithBodyFieldEndpoint(service)
+	getWithNestedQueryE := svc.MakeGetWithNestedQueryEndpoint(service)
+	getWithWellKnownTypesE := svc.MakeGetWithWellKnownTypesEndpoint(service)
 
 	endpoints := svc.Endpoints{
 		GetWithQueryEndpoint:               getWithQueryE,
@@ -63,6 +69,15 @@ func TestMain(m *testing.M) {
 		StatusCodeAndNilHeadersEndpoint:    StatusCodeAndNilHeadersE,
 		StatusCodeAndHeadersEndpoint:       StatusCodeAndHeadersE,
 		CustomVerbEndpoint:                 CustomVerbE,
+		GetWithPathTemplateEndpoint:        getWithPathTemplateE,
+		DescribeWithVerbEndpoint:           describeWithVerbE,
+		GetWithResponseBodyEndpoint:        getWithResponseBodyE,
+		PostWithBodyFieldEndpoint:          postWithBodyFieldE,
+		GetWithNestedQueryEndpoint:         getWithNestedQueryE,
+		GetWithWellKnownTypesEndpoint:      getWithWellKnownTypesE,
+		StreamCountStream:                  service.StreamCount,
+		StreamSumStream:                    service.StreamSum,
+		StreamEchoStream:                   service.StreamEcho,
 	}
 
 	// http test server
@@ -0,0 +1,341 @@
+// protoc-gen-truss is a protoc plugin generating a go-kit service for each
+// service of the .proto files it is run against, as the truss command does.
+//
+// It is run by protoc, buf or any other tool driving protoc plugins:
+//
+//	protoc --truss_out=svcout=github.com/me/svcs,out_dir=.:. svc.proto
+//
+// The parameters, separated by commas, are:
+//
+//	pb_import_path  Go import path of the package the .pb.go files are
+//	                generated in. Defaults to the import path of the
+//	                go_package option.
+//	svcout          Go import path of the directory each NAME-service is
+//	                generated within. Defaults to pb_import_path.
+//	module          Prefix stripped from the Go import path of each output
+//	                file, as with protoc-gen-go. Without it files are
+//	                written at their full import path.
+//	out_dir         Directory the output is written to, i.e. that of
+//	                --truss_out. The handlers of services previously
+//	                generated there are updated instead of replaced.
+//	                Defaults to the current directory.
+//	service         Name of the only service to generate.
+//	combined        If true, also generate one binary serving every service.
+//	M<file>=<path>  Go import path of the package of the .proto file, as with
+//	                protoc-gen-gogo; overrides its go_package option.
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gogo/protobuf/proto"
+	plugin "github.com/gogo/protobuf/protoc-gen-gogo/plugin"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/metaverse/truss/truss"
+
+	ggkconf "github.com/metaverse/truss/gengokit"
+	"github.com/metaverse/truss/gengokit/combined"
+	gengokit "github.com/metaverse/truss/gengokit/generator"
+	"github.com/metaverse/truss/svcdef"
+)
+
+var (
+	// version and date are read from the build info of protoc-gen-truss,
+	// unless overridden with the flag
+	// go install -ldflags "-X main.version=$SHA -X main.date=$VERSION_DATE"
+	version string
+	date    string
+)
+
+func init() {
+	if version == "" && date == "" {
+		version, date = truss.Version()
+	}
+}
+
+func main() {
+	in, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "cannot read CodeGeneratorRequest"))
+	}
+	req := new(plugin.CodeGeneratorRequest)
+	if err := proto.Unmarshal(in, req); err != nil {
+		log.Fatal(errors.Wrap(err, "cannot unmarshal CodeGeneratorRequest"))
+	}
+
+	resp := generate(req)
+
+	out, err := proto.Marshal(resp)
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "cannot marshal CodeGeneratorResponse"))
+	}
+	if _, err := os.Stdout.Write(out); err != nil {
+		log.Fatal(errors.Wrap(err, "cannot write CodeGeneratorResponse"))
+	}
+}
+
+// params holds the parameters passed to the plugin by protoc.
+type params struct {
+	PBImportPath string
+	SvcOut       string
+	Module       string
+	OutDir       string
+	Service      string
+	Combined     bool
+}
+
+// parseParams parses the comma separated key=value parameter of a
+// CodeGeneratorRequest.
+func parseParams(parameter string) (*params, error) {
+	p := &params{
+		OutDir: ".",
+	}
+	if parameter == "" {
+		return p, nil
+	}
+	for _, kv := range strings.Split(parameter, ",") {
+		var key, value string
+		if i := strings.Index(kv, "="); i >= 0 {
+			key, value = kv[:i], kv[i+1:]
+		} else {
+			key = kv
+		}
+		// M options are those of protoc-gen-gogo, which svcdef reads from
+		// the request itself
+		if strings.HasPrefix(key, "M") {
+			continue
+		}
+		switch key {
+		case "pb_import_path":
+			p.PBImportPath = value
+		case "svcout":
+			p.SvcOut = value
+		case "module":
+			p.Module = value
+		case "out_dir":
+			p.OutDir = value
+		case "service":
+			p.Service = value
+		case "combined":
+			if value == "" {
+				value = "true"
+			}
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid value of parameter %q", key)
+			}
+			p.Combined = b
+		default:
+			return nil, errors.Errorf("unknown parameter %q", key)
+		}
+	}
+	return p, nil
+}
+
+// generate returns the CodeGeneratorResponse for req, reporting any error
+// within the response as protoc expects.
+func generate(req *plugin.CodeGeneratorRequest) *plugin.CodeGeneratorResponse {
+	files, err := generateFiles(req)
+	if err != nil {
+		return &plugin.CodeGeneratorResponse{
+			Error:            proto.String(err.Error()),
+			XXX_unrecognized: supportedFeatures(),
+		}
+	}
+	return &plugin.CodeGeneratorResponse{
+		File:             files,
+		XXX_unrecognized: supportedFeatures(),
+	}
+}
+
+// supportedFeatures returns the encoded supported_features field (2) of a
+// CodeGeneratorResponse, advertising FEATURE_PROTO3_OPTIONAL (1) so that protoc
+// passes definitions with proto3 optional fields to the plugin. The gogo
+// plugin descriptors predate the field.
+func supportedFeatures() []byte {
+	return append(proto.EncodeVarint(2<<3|proto.WireVarint), proto.EncodeVarint(1)...)
+}
+
+func generateFiles(req *plugin.CodeGeneratorRequest) ([]*plugin.CodeGeneratorResponse_File, error) {
+	p, err := parseParams(req.GetParameter())
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot parse parameters")
+	}
+
+	sd, err := svcdef.NewFromRequest(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create service definition")
+	}
+	if len(sd.Services) == 0 {
+		log.Warn("No valid service is defined; nothing to generate")
+		return nil, nil
+	}
+
+	if p.PBImportPath == "" {
+		p.PBImportPath, err = goImportPath(req)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if p.SvcOut == "" {
+		p.SvcOut = p.PBImportPath
+	}
+
+	g := &generator{params: p}
+
+	svcs := sd.Services
+	if p.Service != "" {
+		svcs = nil
+		for _, svc := range sd.Services {
+			if svc.Name == p.Service {
+				svcs = append(svcs, svc)
+			}
+		}
+		if len(svcs) == 0 {
+			return nil, errors.Errorf("cannot find service named %q", p.Service)
+		}
+	}
+
+	if p.Combined {
+		err = g.combined(sd, svcs)
+	} else {
+		for _, svc := range svcs {
+			pkg := path.Join(p.SvcOut, strings.ToLower(svc.Name)+"-service")
+			if err = g.service(sd, svc.Name, pkg); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return g.files, nil
+}
+
+// generator accumulates the files generated for the services of a request.
+type generator struct {
+	params *params
+	files  []*plugin.CodeGeneratorResponse_File
+}
+
+// service generates the service svcName as the Go package pkg.
+func (g *generator) service(sd *svcdef.Svcdef, svcName, pkg string) error {
+	outPath, err := g.outputPath(pkg)
+	if err != nil {
+		return err
+	}
+	prevGen, err := truss.ReadPreviousGeneration(filepath.Join(g.params.OutDir, filepath.FromSlash(outPath)))
+	if err != nil {
+		return errors.Wrap(err, "cannot read previously generated files")
+	}
+
+	genFiles, err := gengokit.GenerateGokit(sd, ggkconf.Config{
+		PBPackage:     g.params.PBImportPath,
+		GoPackage:     pkg,
+		Service:       svcName,
+		PreviousFiles: prevGen,
+		Version:       version,
+		VersionDate:   date,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "cannot generate service %q", svcName)
+	}
+
+	return g.add(outPath, genFiles)
+}
+
+// combined generates each of svcs within a package named after the
+// definition's package, along with a binary serving all of them.
+func (g *generator) combined(sd *svcdef.Svcdef, svcs []*svcdef.Service) error {
+	rootPkg := path.Join(g.params.SvcOut, strings.ToLower(sd.PkgName)+"-service")
+	outPath, err := g.outputPath(rootPkg)
+	if err != nil {
+		return err
+	}
+
+	svcPackages := make(map[string]string)
+	for _, svc := range svcs {
+		pkg := path.Join(rootPkg, strings.ToLower(svc.Name)+"-service")
+		if err := g.service(sd, svc.Name, pkg); err != nil {
+			return err
+		}
+		svcPackages[svc.Name] = pkg
+	}
+
+	csd := *sd
+	csd.Services = svcs
+	genFiles, err := combined.Generate(&csd, combined.Config{
+		GoPackage:       rootPkg,
+		PBPackage:       g.params.PBImportPath,
+		ServicePackages: svcPackages,
+		Version:         version,
+		VersionDate:     date,
+	})
+	if err != nil {
+		return errors.Wrap(err, "cannot generate combined binary")
+	}
+
+	return g.add(outPath, genFiles)
+}
+
+// add appends genFiles to the response, named relative to dir.
+func (g *generator) add(dir string, genFiles map[string]io.Reader) error {
+	for name, file := range genFiles {
+		var buf bytes.Buffer
+		if _, err := io.Copy(&buf, file); err != nil {
+			return errors.Wrapf(err, "cannot read generated file %q", name)
+		}
+		g.files = append(g.files, &plugin.CodeGeneratorResponse_File{
+			Name:    proto.String(path.Join(dir, name)),
+			Content: proto.String(buf.String()),
+		})
+	}
+	return nil
+}
+
+// outputPath returns the path, relative to the output directory, the Go
+// package pkg is written to.
+func (g *generator) outputPath(pkg string) (string, error) {
+	if g.params.Module == "" {
+		return pkg, nil
+	}
+	if pkg == g.params.Module {
+		return ".", nil
+	}
+	prefix := strings.TrimSuffix(g.params.Module, "/") + "/"
+	if !strings.HasPrefix(pkg, prefix) {
+		return "", errors.Errorf("package %q is not within module %q", pkg, g.params.Module)
+	}
+	return strings.TrimPrefix(pkg, prefix), nil
+}
+
+// goImportPath returns the import path of the go_package option of the files
+// to generate.
+func goImportPath(req *plugin.CodeGeneratorRequest) (string, error) {
+	for _, name := range req.GetFileToGenerate() {
+		for _, f := range req.GetProtoFile() {
+			if f.GetName() != name {
+				continue
+			}
+			opt := f.GetOptions().GetGoPackage()
+			if i := strings.Index(opt, ";"); i >= 0 {
+				opt = opt[:i]
+			}
+			if strings.Contains(opt, "/") {
+				return opt, nil
+			}
+		}
+	}
+	return "", errors.New("cannot determine the import path of the .pb.go files; " +
+		"set the go_package option or the pb_import_path parameter")
+}
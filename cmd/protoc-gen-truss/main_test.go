@@ -0,0 +1,169 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	plugin "github.com/gogo/protobuf/protoc-gen-gogo/plugin"
+
+	"github.com/metaverse/truss/truss/parseproto"
+)
+
+var gopath []string
+
+func init() {
+	gopath = filepath.SplitList(os.Getenv("GOPATH"))
+}
+
+const def = `
+	syntax = "proto3";
+
+	package echo;
+
+	option go_package = "example.com/echo/pb";
+
+	import "github.com/metaverse/truss/deftree/googlethirdparty/annotations.proto";
+
+	message Msg {
+		string in = 1;
+	}
+
+	service Echo {
+		rpc Say(Msg) returns (Msg) {
+			option (google.api.http) = {
+				get: "/say"
+			};
+		}
+	}
+
+	service Admin {
+		rpc Reset(Msg) returns (Msg) {}
+	}
+`
+
+func request(t *testing.T, parameter string) *plugin.CodeGeneratorRequest {
+	dir, err := ioutil.TempDir("", "protoc-gen-truss-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	defPath := filepath.Join(dir, "echo.proto")
+	if err := ioutil.WriteFile(defPath, []byte(def), 0666); err != nil {
+		t.Fatal(err)
+	}
+	req, err := parseproto.CodeGeneratorRequest([]string{defPath}, parseproto.GoPathImports(gopath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Parameter = proto.String(parameter)
+	return req
+}
+
+func fileNames(resp *plugin.CodeGeneratorResponse) map[string]string {
+	files := make(map[string]string)
+	for _, f := range resp.File {
+		files[f.GetName()] = f.GetContent()
+	}
+	return files
+}
+
+func TestGenerate(t *testing.T) {
+	resp := generate(request(t, "svcout=example.com/echo/svcs,module=example.com/echo"))
+	if resp.Error != nil {
+		t.Fatal(resp.GetError())
+	}
+	files := fileNames(resp)
+
+	for _, name := range []string{
+		"svcs/echo-service/handlers/handlers.go",
+		"svcs/echo-service/svc/server/run.go",
+		"svcs/echo-service/cmd/echo/main.go",
+		"svcs/admin-service/handlers/handlers.go",
+	} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("file %q was not generated", name)
+		}
+	}
+
+	run := files["svcs/echo-service/svc/server/run.go"]
+	if !strings.Contains(run, `pb "example.com/echo/pb"`) {
+		t.Errorf("run.go does not import the go_package of the definition:\n%s", run)
+	}
+	if !strings.Contains(run, `"example.com/echo/svcs/echo-service/svc"`) {
+		t.Errorf("run.go does not import the svcout service package:\n%s", run)
+	}
+}
+
+func TestGenerateSupportedFeatures(t *testing.T) {
+	for _, parameter := range []string{"", "unknown=true"} {
+		resp := generate(request(t, parameter))
+
+		b := proto.NewBuffer(resp.XXX_unrecognized)
+		key, err := b.DecodeVarint()
+		if err != nil {
+			t.Fatal(err)
+		}
+		features, err := b.DecodeVarint()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key != 2<<3|proto.WireVarint || features != 1 {
+			t.Errorf("response with parameter %q does not advertise FEATURE_PROTO3_OPTIONAL: field %d = %d", parameter, key>>3, features)
+		}
+	}
+}
+
+func TestGenerateSelectedService(t *testing.T) {
+	resp := generate(request(t, "service=Admin,pb_import_path=example.com/other/pb"))
+	if resp.Error != nil {
+		t.Fatal(resp.GetError())
+	}
+	files := fileNames(resp)
+
+	if _, ok := files["example.com/other/pb/admin-service/handlers/handlers.go"]; !ok {
+		t.Errorf("admin service was not generated within pb_import_path: %v", files)
+	}
+	for name := range files {
+		if strings.Contains(name, "echo-service") {
+			t.Errorf("unselected service generated file %q", name)
+		}
+	}
+}
+
+func TestGenerateCombined(t *testing.T) {
+	resp := generate(request(t, "combined,module=example.com/echo/pb"))
+	if resp.Error != nil {
+		t.Fatal(resp.GetError())
+	}
+	files := fileNames(resp)
+
+	for _, name := range []string{
+		"pb-service/cmd/pb/main.go",
+		"pb-service/svc/server/run.go",
+		"pb-service/echo-service/handlers/handlers.go",
+		"pb-service/admin-service/handlers/handlers.go",
+	} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("file %q was not generated", name)
+		}
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	for _, parameter := range []string{
+		"unknown=1",
+		"combined=maybe",
+		"service=Missing",
+		"module=example.com/elsewhere",
+	} {
+		resp := generate(request(t, parameter))
+		if resp.Error == nil {
+			t.Errorf("expected an error with parameter %q", parameter)
+		}
+	}
+}
@@ -1,72 +1,116 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"go/build"
 	"io"
+	"io/ioutil"
 	"os"
-	"os/exec"
+	"path"
 	"path/filepath"
 	"strings"
 
-	"golang.org/x/tools/go/packages"
-
+	"github.com/gogo/protobuf/proto"
+	plugin "github.com/gogo/protobuf/protoc-gen-gogo/plugin"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	flag "github.com/spf13/pflag"
 
 	"github.com/metaverse/truss/truss"
+	"github.com/metaverse/truss/truss/configfile"
 	"github.com/metaverse/truss/truss/execprotoc"
+	"github.com/metaverse/truss/truss/generators"
 	"github.com/metaverse/truss/truss/getstarted"
-	"github.com/metaverse/truss/truss/parsesvcname"
+	"github.com/metaverse/truss/truss/gomod"
+	"github.com/metaverse/truss/truss/manifest"
+	"github.com/metaverse/truss/truss/output"
+	"github.com/metaverse/truss/truss/parseproto"
+	"github.com/metaverse/truss/truss/pbgo"
 
 	ggkconf "github.com/metaverse/truss/gengokit"
-	gengokit "github.com/metaverse/truss/gengokit/generator"
+	"github.com/metaverse/truss/gengokit/combined"
 	"github.com/metaverse/truss/svcdef"
 )
 
 var (
 	svcPackageFlag = flag.String("svcout", "", "Go package path where the generated Go service will be written. Trailing slash will create a NAME-service directory")
+	pbOutFlag      = flag.String("pbout", "", "Go package path where the .pb.go files of the definition will be written; by default they are written next to the .proto files")
+	templatesFlag  = flag.StringP("templates", "", "", "Directory of templates overriding or adding to the built-in templates of each service by path, e.g. svc/server/run.gotemplate")
+	dryRunFlag     = flag.BoolP("dry-run", "", false, "List the files which would be created, updated or deleted, without touching any of them")
+	diffFlag       = flag.BoolP("diff", "", false, "Print a unified diff of each file which would be created, updated or deleted, without touching any of them")
+	verifyFlag     = flag.BoolP("verify", "", false, "Print the path of each generated file which is stale, i.e. differs from what would be generated, exiting with an error if there are any; no file is touched")
+	configFlag     = flag.StringP("config", "", "", "Path of a "+configfile.Name+" file; by default "+configfile.Name+" is read from the directory of the definition files if it exists")
 	verboseFlag    = flag.BoolP("verbose", "v", false, "Verbose output")
 	helpFlag       = flag.BoolP("help", "h", false, "Print usage")
 	getStartedFlag = flag.BoolP("getstarted", "", false, "Output a 'getstarted.proto' protobuf file in ./")
+	combinedFlag   = flag.BoolP("combined", "", false, "Generate one binary serving every service of the definition, rather than a binary per service")
+	generatorsFlag = flag.StringArrayP("generators", "", nil, "Generator to run for each service, which may be repeated: one of "+strings.Join(generators.Names(), ", ")+", or NAME[:PARAMETER] to run protoc-gen-NAME from PATH as a protoc plugin; "+generators.Service+" by default")
+	descSetFlag    = flag.StringP("descriptor_set_in", "", "", "Serialized FileDescriptorSet to generate from instead of .proto files; arguments name the files of the set to generate")
+	protoPathFlag  = flag.StringArrayP("proto_path", "I", nil, "Directory to search for imports, which may be repeated; each .proto file is named relative to the first directory containing it")
+	protocFlag     = flag.BoolP("protoc", "", false, "Generate the .pb.go files of .proto files by running protoc and protoc-gen-gogofaster from PATH, rather than in process")
 )
 
 var binName = filepath.Base(os.Args[0])
 
+// The commands truss runs instead of generating each service.
+const (
+	// dumpCommand prints the parsed definition as JSON
+	dumpCommand = "dump"
+	// docCommand generates only the documentation of each service
+	docCommand = "doc"
+)
+
+// commands are those which may be named by the first argument.
+var commands = map[string]bool{
+	dumpCommand: true,
+	docCommand:  true,
+}
+
 var (
-	// version is compiled into truss with the flag
-	// go install -ldflags "-X main.version=$SHA"
+	// command is the command named by the first argument, if any, see
+	// commands
+	command string
+	// svcGenerators are run for each service, see serviceGenerators
+	svcGenerators []generators.Generator
+	// out writes the generated files, or with the dry-run or diff flags,
+	// reports them
+	out *output.Writer
+)
+
+var (
+	// version and date are read from the build info of truss, unless
+	// overridden with the flag
+	// go install -ldflags "-X main.version=$SHA -X main.date=$VERSION_DATE"
 	version string
-	// BuildDate is compiled into truss with the flag
-	// go install -ldflags "-X main.date=$VERSION_DATE"
-	date string
+	date    string
 )
 
 func init() {
-	// If Version or VersionDate are not set, truss was not built with make
-	if version == "" || date == "" {
-		rebuild := promptNoMake()
-		if !rebuild {
-			os.Exit(1)
-		}
-		err := makeAndRunTruss(os.Args)
-		if err != nil {
-			log.Fatal(errors.Wrap(err, "please install truss with make manually"))
-		}
-		os.Exit(0)
+	if version == "" && date == "" {
+		version, date = truss.Version()
 	}
 
 	var buildinfo string
-	buildinfo = fmt.Sprintf("version: %s", version)
-	buildinfo = fmt.Sprintf("%s version date: %s", buildinfo, date)
+	if version != "" {
+		buildinfo = fmt.Sprintf("version: %s", version)
+	}
+	if date != "" {
+		buildinfo = fmt.Sprintf("%s version date: %s", buildinfo, date)
+	}
 
 	flag.Usage = func() {
 		if buildinfo != "" && (*verboseFlag || *helpFlag) {
 			fmt.Fprintf(os.Stderr, "%s (%s)\n", binName, strings.TrimSpace(buildinfo))
 		}
 		fmt.Fprintf(os.Stderr, "\nUsage: %s [options] <protofile>...\n", binName)
+		fmt.Fprintf(os.Stderr, "       %s [options] --descriptor_set_in <file> [<protofile>...]\n", binName)
+		fmt.Fprintf(os.Stderr, "       %s [options]    (with the inputs named by %s)\n", binName, configfile.Name)
+		fmt.Fprintf(os.Stderr, "       %s dump [options] [<protofile>...]\n", binName)
+		fmt.Fprintf(os.Stderr, "       %s doc [options] [<protofile>...]\n", binName)
 		fmt.Fprintf(os.Stderr, "\nGenerates go-kit services using proto3 and gRPC definitions.\n")
+		fmt.Fprintf(os.Stderr, "With dump, prints the parsed definition as JSON instead; with doc, generates\nonly the docs/docs.md of each service.\n")
 		fmt.Fprintln(os.Stderr, "\nOptions:")
 		flag.PrintDefaults()
 	}
@@ -93,92 +137,528 @@ func main() {
 		os.Exit(getstarted.Do(pkg))
 	}
 
-	if len(flag.Args()) == 0 {
+	args := flag.Args()
+	if len(args) > 0 && commands[args[0]] {
+		command = args[0]
+		args = args[1:]
+	}
+
+	inputs, options, err := applyConfigFile(args)
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "cannot read config file"))
+	}
+	for _, spec := range *generatorsFlag {
+		if spec == configfile.GenCombined {
+			*combinedFlag = true
+		}
+	}
+
+	if len(inputs) == 0 && *descSetFlag == "" {
 		fmt.Fprintf(os.Stderr, "%s: missing .proto file(s)\n", binName)
 		flag.Usage()
 		os.Exit(1)
 	}
 
-	cfg, err := parseInput()
+	wd, err := os.Getwd()
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "cannot get working directory of truss"))
+	}
+	out = &output.Writer{Out: os.Stdout, Base: wd}
+	switch {
+	case *verifyFlag:
+		out.Mode = output.Verify
+	case *diffFlag:
+		out.Mode = output.Diff
+	case *dryRunFlag:
+		out.Mode = output.DryRun
+	}
+
+	cfg, err := parseInput(inputs, options)
 	if err != nil {
 		log.Fatal(errors.Wrap(err, "cannot parse input"))
 	}
 
-	// If there was no service found in parseInput, the rest can be omitted.
-	if cfg == nil {
+	def, err := parseServiceDefinition(cfg)
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "cannot parse input definition proto files"))
+	}
+	sd := def.Svcdef
+
+	switch command {
+	case dumpCommand:
+		b, err := json.MarshalIndent(sd, "", "  ")
+		if err != nil {
+			log.Fatal(errors.Wrap(err, "cannot encode service definition"))
+		}
+		if _, err := os.Stdout.Write(append(b, '\n')); err != nil {
+			log.Fatal(errors.Wrap(err, "cannot write service definition"))
+		}
+		return
+	case docCommand:
+		if err := generateDocs(cfg, def); err != nil {
+			log.Fatal(errors.Wrap(err, "cannot generate documentation"))
+		}
+		exitIfStale()
 		return
 	}
 
-	sd, err := parseServiceDefinition(cfg)
+	svcGenerators, err = serviceGenerators()
 	if err != nil {
-		log.Fatal(errors.Wrap(err, "cannot parse input definition proto files"))
+		log.Fatal(err)
+	}
+
+	if err := writePBDotGo(cfg, def.Request); err != nil {
+		log.Fatal(errors.Wrap(err, "cannot create .pb.go files"))
 	}
 
-	genFiles, err := generateCode(cfg, sd)
+	// If there was no service found, the rest can be omitted.
+	if len(sd.Services) == 0 {
+		log.Warn("No valid service is defined; exiting now")
+		log.Info(".pb.go generation with protoc-gen-go was successful.")
+		exitIfStale()
+		return
+	}
+
+	// The directories containing the service trees, within which the
+	// trees of services no longer defined are removed
+	var parentDirs []string
+	if *combinedFlag {
+		var rootPath string
+		rootPath, err = generateCombined(cfg, def)
+		parentDirs = append(parentDirs, rootPath)
+	} else {
+		// With several services, svcout names the directory containing
+		// each NAME-service tree
+		nested := len(sd.Services) > 1
+		for _, svc := range sd.Services {
+			var svcPath string
+			svcPath, err = outputPath(cfg, strings.ToLower(svc.Name)+"-service", nested)
+			if err != nil {
+				break
+			}
+			if err = generateService(cfg, def, svc.Name, svcPath); err != nil {
+				break
+			}
+			parentDirs = append(parentDirs, filepath.Dir(svcPath))
+		}
+	}
+	if err == nil {
+		err = removeOrphanServices(cfg, def, parentDirs)
+	}
 	if err != nil {
 		log.Fatal(errors.Wrap(err, "cannot generate service"))
 	}
+	exitIfStale()
+}
+
+// exitIfStale exits with an error if the verify flag is set and any
+// generated file differs from what is on disk.
+func exitIfStale() {
+	if out.Mode == output.Verify && len(out.Changed) > 0 {
+		log.Fatalf("%d generated files are stale; run truss to regenerate them", len(out.Changed))
+	}
+}
+
+// applyConfigFile reads the config file of the definition, if there is one,
+// and applies each of its settings whose flag is not set. It returns the
+// definition files, which are args, or if there are none, the inputs of the
+// file, along with the options of the file.
+func applyConfigFile(args []string) (inputs []string, options map[string]string, err error) {
+	inputs = args
+
+	path := *configFlag
+	if path == "" {
+		dir := "."
+		if *descSetFlag != "" {
+			dir = filepath.Dir(*descSetFlag)
+		} else if len(inputs) > 0 {
+			dir = filepath.Dir(inputs[0])
+		}
+		var ok bool
+		if path, ok = configfile.Find(dir); !ok {
+			return inputs, nil, nil
+		}
+	}
+	log.WithField("Config File", path).Debug()
+
+	f, err := configfile.Read(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	set := flag.CommandLine.Changed
+	if len(inputs) == 0 && (!set("descriptor_set_in") || f.DescriptorSet != "") {
+		inputs = f.Inputs
+	}
+	if !set("descriptor_set_in") {
+		*descSetFlag = f.DescriptorSet
+	}
+	if !set("proto_path") {
+		*protoPathFlag = f.ProtoPaths
+	}
+	if !set("svcout") {
+		*svcPackageFlag = f.SvcOut
+	}
+	if !set("pbout") {
+		*pbOutFlag = f.PBOut
+	}
+	if !set("combined") {
+		*combinedFlag = f.Combined()
+	}
+	if !set("generators") {
+		*generatorsFlag = f.Generators
+	}
+	if !set("templates") {
+		*templatesFlag = f.Templates
+	}
+
+	return inputs, f.Options, nil
+}
+
+// serviceGenerators returns the generators named by the generators flag other
+// than configfile.GenCombined, or generators.Service if there are none. With
+// the combined flag, which configfile.GenCombined sets, the binary generated
+// serves the services generators.Service generates, which is therefore
+// always run.
+func serviceGenerators() ([]generators.Generator, error) {
+	var specs []string
+	for _, spec := range *generatorsFlag {
+		if spec != configfile.GenCombined {
+			specs = append(specs, spec)
+		}
+	}
+	if len(specs) == 0 {
+		specs = []string{generators.Service}
+	}
+
+	var gens []generators.Generator
+	hasService := false
+	for _, spec := range specs {
+		g, err := generators.Lookup(spec)
+		if err != nil {
+			return nil, err
+		}
+		hasService = hasService || spec == generators.Service
+		gens = append(gens, g)
+	}
+	if *combinedFlag && !hasService {
+		g, _ := generators.Lookup(generators.Service)
+		gens = append(gens, g)
+	}
+	return gens, nil
+}
+
+// generateDocs writes the documentation of the definition generated by
+// generators.Docs, the files within docs/, within the tree of each service.
+// Nothing else is generated, not even the svc/docs.go serving the
+// documentation, and the manifests of the trees are left as they are.
+func generateDocs(cfg *truss.Config, def *generators.Input) error {
+	docs, err := generators.Lookup(generators.Docs)
+	if err != nil {
+		return err
+	}
 
-	for path, file := range genFiles {
-		err := writeGenFile(file, filepath.Join(cfg.ServicePath, path))
+	sd := def.Svcdef
+	// With the combined flag the trees are within that of the binary
+	// serving them, as generateCombined places them
+	var rootPath string
+	if *combinedFlag {
+		if rootPath, err = outputPath(cfg, strings.ToLower(sd.PkgName)+"-service", false); err != nil {
+			return err
+		}
+	}
+	nested := len(sd.Services) > 1
+	for _, svc := range sd.Services {
+		dirName := strings.ToLower(svc.Name) + "-service"
+		svcPath := filepath.Join(rootPath, dirName)
+		if rootPath == "" {
+			if svcPath, err = outputPath(cfg, dirName, nested); err != nil {
+				return err
+			}
+		}
+
+		in := *def
+		in.Config = ggkconf.Config{
+			PBPackage:   cfg.PBPackage,
+			Service:     svc.Name,
+			Options:     cfg.Options,
+			Version:     version,
+			VersionDate: date,
+		}
+		files, err := docs.Generate(&in)
 		if err != nil {
-			log.Fatal(errors.Wrap(err, "cannot to write output"))
+			return errors.Wrapf(err, "cannot document service %q", svc.Name)
 		}
+		for path, file := range files {
+			if !strings.HasPrefix(path, "docs/") {
+				continue
+			}
+			if err := out.WriteFile(filepath.Join(svcPath, filepath.FromSlash(path)), file); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// generateService generates the tree of the named service at svcPath,
+// regenerating it if it already exists.
+func generateService(cfg *truss.Config, def *generators.Input, svcName, svcPath string) error {
+	svcCfg, err := serviceConfig(*cfg, svcPath)
+	if err != nil {
+		return err
+	}
+
+	genFiles, err := generateCode(svcCfg, def, svcName)
+	if err != nil {
+		return errors.Wrapf(err, "cannot generate service %q", svcName)
 	}
 
-	cleanupOldFiles(cfg.ServicePath, strings.ToLower(sd.Service.Name))
+	return writeTree(svcCfg.ServicePath, genFiles, manifest.New(cfg.PBPackage, svcName, def.Request.FileToGenerate))
 }
 
-// parseInput constructs a *truss.Config with all values needed to parse
-// service definition files.
-func parseInput() (*truss.Config, error) {
-	var cfg truss.Config
+// generateCombined generates a tree for each service within a tree named
+// after the definition's package, along with a binary serving all of them.
+// It returns the path of that tree.
+func generateCombined(cfg *truss.Config, def *generators.Input) (string, error) {
+	sd := def.Svcdef
+	rootPath, err := outputPath(cfg, strings.ToLower(sd.PkgName)+"-service", false)
+	if err != nil {
+		return "", err
+	}
+	rootCfg, err := serviceConfig(*cfg, rootPath)
+	if err != nil {
+		return "", err
+	}
 
-	// GOPATH
-	cfg.GoPath = filepath.SplitList(os.Getenv("GOPATH"))
-	if len(cfg.GoPath) == 0 {
-		cfg.GoPath = filepath.SplitList(build.Default.GOPATH)
+	svcPackages := make(map[string]string)
+	for _, svc := range sd.Services {
+		dirName := strings.ToLower(svc.Name) + "-service"
+		if err := generateService(cfg, def, svc.Name, filepath.Join(rootPath, dirName)); err != nil {
+			return "", err
+		}
+		svcPackages[svc.Name] = path.Join(rootCfg.ServicePackage, dirName)
+	}
+
+	genFiles, err := combined.Generate(sd, combined.Config{
+		GoPackage:       rootCfg.ServicePackage,
+		PBPackage:       cfg.PBPackage,
+		ServicePackages: svcPackages,
+		Version:         version,
+		VersionDate:     date,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "cannot generate combined binary")
+	}
+
+	return rootPath, writeTree(rootPath, genFiles, manifest.New(cfg.PBPackage, "", def.Request.FileToGenerate))
+}
+
+// writeTree writes the files of a tree generated at root, recording them in
+// next, which is then written as the manifest of the tree. Files the previous
+// manifest of the tree lists which are no longer generated are removed. A
+// service tree without a manifest has the files of older versions of truss
+// removed instead, see cleanupOldFiles.
+func writeTree(root string, files map[string]io.Reader, next *manifest.Manifest) error {
+	prev, err := manifest.Read(root)
+	if err != nil {
+		return err
+	}
+
+	for path, file := range files {
+		content, err := ioutil.ReadAll(file)
+		if err != nil {
+			return errors.Wrapf(err, "cannot read generated file %v", path)
+		}
+		next.Add(path, content)
+		if err := out.WriteFile(filepath.Join(root, path), bytes.NewReader(content)); err != nil {
+			return errors.Wrap(err, "cannot to write output")
+		}
+	}
+
+	if prev != nil {
+		if _, err := removeOrphans(root, prev, next); err != nil {
+			return err
+		}
+	} else if next.Service != "" {
+		cleanupOldFiles(root, strings.ToLower(next.Service))
+	}
+
+	return out.WriteFile(filepath.Join(root, manifest.FileName), bytes.NewReader(next.Marshal()))
+}
+
+// removeOrphans removes the files of the tree at root which prev lists and
+// next does not, i.e. those truss generated and no longer generates. Files
+// modified since they were generated are left in place with a warning. It
+// returns the number of files left in place.
+func removeOrphans(root string, prev, next *manifest.Manifest) (int, error) {
+	kept := 0
+	for _, p := range prev.Orphans(next) {
+		modified, err := prev.Modified(root, p)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return kept, errors.Wrapf(err, "cannot read %v", p)
+		}
+		path := filepath.Join(root, filepath.FromSlash(p))
+		if modified {
+			log.Warnf("%s is no longer generated, but has been modified since it was; remove it once it is no longer needed", path)
+			kept++
+			continue
+		}
+		log.WithField("path", path).Debug("Removing file which is no longer generated")
+		if err := out.RemoveFile(root, path); err != nil {
+			return kept, err
+		}
+	}
+	return kept, nil
+}
+
+// removeOrphanServices removes the trees within dirs of the services of the
+// definition which are no longer defined, such as those of renamed services.
+// A tree is identified by its manifest, so no file truss did not generate is
+// removed. Only the trees generated from the same definition files are
+// removed, as other files may define other services of the same Go package.
+func removeOrphanServices(cfg *truss.Config, def *generators.Input, dirs []string) error {
+	defined := make(map[string]bool)
+	for _, svc := range def.Svcdef.Services {
+		defined[svc.Name] = true
+	}
+	inputs := manifest.New(cfg.PBPackage, "", def.Request.FileToGenerate)
+
+	seen := make(map[string]bool)
+	for _, dir := range dirs {
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+
+		entries, err := ioutil.ReadDir(dir)
+		if err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "cannot read directory %v", dir)
+		}
+		for _, e := range entries {
+			if !e.IsDir() {
+				continue
+			}
+			root := filepath.Join(dir, e.Name())
+			prev, err := manifest.Read(root)
+			if err != nil {
+				return err
+			}
+			if prev == nil || prev.PBPackage != cfg.PBPackage || prev.Service == "" || defined[prev.Service] {
+				continue
+			}
+			if !prev.SameInputs(inputs) {
+				log.Warnf("Service %s in %s is not defined by %s, but was generated from %s; remove it if it is no longer defined", prev.Service, root, strings.Join(inputs.Inputs, ", "), strings.Join(prev.Inputs, ", "))
+				continue
+			}
+
+			log.Infof("Service %s is no longer defined; removing the files generated for it in %s", prev.Service, root)
+			kept, err := removeOrphans(root, prev, manifest.New(prev.PBPackage, prev.Service, prev.Inputs))
+			if err != nil {
+				return err
+			}
+			if kept == 0 {
+				if err := out.RemoveFile(dir, filepath.Join(root, manifest.FileName)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// parseInput constructs a *truss.Config with all values needed to parse
+// service definition files, which inputs names, and to generate the services
+// with the given template options.
+func parseInput(inputs []string, options map[string]string) (*truss.Config, error) {
+	cfg := truss.Config{
+		Options: options,
 	}
-	log.WithField("GOPATH", cfg.GoPath).Debug()
 
-	// DefPaths
 	var err error
-	rawDefinitionPaths := flag.Args()
-	cfg.DefPaths, err = cleanProtofilePath(rawDefinitionPaths)
+	var protoDir string
+	if *descSetFlag != "" {
+		// DescriptorSetPath
+		cfg.DescriptorSetPath, err = filepath.Abs(*descSetFlag)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot get working directory of truss")
+		}
+		cfg.DescriptorSetFiles = inputs
+		log.WithField("DescriptorSetPath", cfg.DescriptorSetPath).Debug()
+		protoDir = filepath.Dir(cfg.DescriptorSetPath)
+	} else {
+		// DefPaths
+		rawDefinitionPaths := inputs
+		cfg.DefPaths, err = cleanProtofilePath(rawDefinitionPaths)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot parse input arguments")
+		}
+		log.WithField("DefPaths", cfg.DefPaths).Debug()
+		protoDir = filepath.Dir(cfg.DefPaths[0])
+	}
+
+	// Modules
+	cfg.Modules, err = gomod.NewResolver(protoDir)
 	if err != nil {
-		return nil, errors.Wrap(err, "cannot parse input arguments")
+		return nil, errors.Wrap(err, "cannot load Go modules")
 	}
-	log.WithField("DefPaths", cfg.DefPaths).Debug()
 
-	protoDir := filepath.Dir(cfg.DefPaths[0])
-	p, err := packages.Load(nil, protoDir)
-	if err != nil || len(p) == 0 {
-		return nil, errors.Wrap(err, "proto files not found in importable go package")
+	// ImportPaths
+	for _, ip := range *protoPathFlag {
+		if !strings.Contains(ip, "=") {
+			if ip, err = filepath.Abs(ip); err != nil {
+				return nil, errors.Wrap(err, "cannot get working directory of truss")
+			}
+		}
+		cfg.ImportPaths = append(cfg.ImportPaths, ip)
 	}
+	// Definition files outside of every --proto_path are named relative to
+	// their own directory
+	for _, def := range cfg.DefPaths {
+		if _, _, ok := parseproto.Name(def, cfg.ImportPaths); !ok {
+			cfg.ImportPaths = append(cfg.ImportPaths, filepath.Dir(def))
+		}
+	}
+	cfg.ImportPaths = append(cfg.ImportPaths, cfg.Modules.ProtoImportPaths()...)
+	log.WithField("ImportPaths", cfg.ImportPaths).Debug()
 
-	cfg.PBPackage = p[0].PkgPath
-	cfg.PBPath = protoDir
-	log.WithField("PB Package", cfg.PBPackage).Debug()
-	log.WithField("PB Path", cfg.PBPath).Debug()
+	// TemplateDir
+	if *templatesFlag != "" {
+		cfg.TemplateDir, err = filepath.Abs(*templatesFlag)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot get working directory of truss")
+		}
+		log.WithField("TemplateDir", cfg.TemplateDir).Debug()
+	}
 
-	if err := execprotoc.GeneratePBDotGo(cfg.DefPaths, cfg.GoPath, cfg.PBPath); err != nil {
-		return nil, errors.Wrap(err, "cannot create .pb.go files")
+	// PBPath
+	cfg.PBPath = protoDir
+	if *pbOutFlag != "" {
+		cfg.PBPath, err = parseSVCOut(*pbOutFlag, cfg.Modules)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot parse pbout: %s", *pbOutFlag)
+		}
 	}
 
-	// Service Path
-	svcName, err := parsesvcname.FromPaths(cfg.GoPath, cfg.DefPaths)
+	cfg.PBPackage, err = cfg.Modules.ImportPath(cfg.PBPath)
 	if err != nil {
-		log.Warnf("No valid service is defined; exiting now: %v", err)
-		log.Info(".pb.go generation with protoc-gen-go was successful.")
-		return nil, nil
+		return nil, errors.Wrap(err, "proto files not found in importable go package")
 	}
 
-	svcName = strings.ToLower(svcName)
+	return &cfg, nil
+}
 
-	svcDirName := svcName + "-service"
-	log.WithField("svcDirName", svcDirName).Debug()
+// outputPath returns the path a service tree named dirName is written to.
+// This is next to the definition files, unless the svcout flag is set. A
+// svcout ending in a separator, or any svcout if nested is true, names the
+// directory the tree is created within.
+func outputPath(cfg *truss.Config, dirName string, nested bool) (string, error) {
+	log.WithField("svcDirName", dirName).Debug()
 
-	svcPath := filepath.Join(filepath.Dir(cfg.DefPaths[0]), svcDirName)
+	svcPath := filepath.Join(cfg.PBPath, dirName)
 
 	if *svcPackageFlag != "" {
 		svcOut := *svcPackageFlag
@@ -189,41 +669,37 @@ func parseInput() (*truss.Config, error) {
 		seperator := file == ""
 		log.WithField("seperator", seperator)
 
-		svcPath, err = parseSVCOut(svcOut, cfg.GoPath[0])
+		var err error
+		svcPath, err = parseSVCOut(svcOut, cfg.Modules)
 		if err != nil {
-			return nil, errors.Wrapf(err, "cannot parse svcout: %s", svcOut)
+			return "", errors.Wrapf(err, "cannot parse svcout: %s", svcOut)
 		}
 
 		// Join the svcDirName as a svcout ending with `/` should create it
-		if seperator {
-			svcPath = filepath.Join(svcPath, svcDirName)
+		if seperator || nested {
+			svcPath = filepath.Join(svcPath, dirName)
 		}
 	}
 
 	log.WithField("svcPath", svcPath).Debug()
+	return svcPath, nil
+}
 
-	// Create svcPath for the case that it does not exist
-	err = os.MkdirAll(svcPath, 0777)
+// serviceConfig returns a copy of cfg completed with the package, path and
+// previously generated files of the service tree at svcPath.
+func serviceConfig(cfg truss.Config, svcPath string) (*truss.Config, error) {
+	var err error
+	cfg.ServicePackage, err = cfg.Modules.ImportPath(svcPath)
 	if err != nil {
-		return nil, errors.Wrapf(err, "cannot create svcPath directory: %s", svcPath)
-	}
-
-	p, err = packages.Load(nil, svcPath)
-	if err != nil || len(p) == 0 {
 		return nil, errors.Wrap(err, "generated service not found in importable go package")
 	}
-
-	log.WithField("Service Packages", p).Debug()
-
-	cfg.ServicePackage = p[0].PkgPath
 	cfg.ServicePath = svcPath
 
 	log.WithField("Service Package", cfg.ServicePackage).Debug()
-	log.WithField("package name", p[0].Name).Debug()
 	log.WithField("Service Path", cfg.ServicePath).Debug()
 
 	// PrevGen
-	cfg.PrevGen, err = readPreviousGeneration(cfg.ServicePath)
+	cfg.PrevGen, err = truss.ReadPreviousGeneration(cfg.ServicePath)
 	if err != nil {
 		return nil, errors.Wrap(err, "cannot read previously generated files")
 	}
@@ -232,105 +708,243 @@ func parseInput() (*truss.Config, error) {
 }
 
 // parseSVCOut handles the difference between relative paths and go package
-// paths
-func parseSVCOut(svcOut string, GOPATH string) (string, error) {
-	if build.IsLocalImport(svcOut) {
+// paths, resolving the latter within the main modules
+func parseSVCOut(svcOut string, modules *gomod.Resolver) (string, error) {
+	if build.IsLocalImport(svcOut) || filepath.IsAbs(svcOut) {
 		return filepath.Abs(svcOut)
 	}
-	return filepath.Join(GOPATH, "src", svcOut), nil
+	return modules.Dir(strings.TrimSuffix(svcOut, "/"))
 }
 
 // parseServiceDefinition returns a svcdef which contains all necessary
-// information for generating a truss service.
-func parseServiceDefinition(cfg *truss.Config) (*svcdef.Svcdef, error) {
-	protoDefPaths := cfg.DefPaths
-	// Create the ServicePath so the .pb.go files may be place within it
-	if cfg.PrevGen == nil {
-		err := os.MkdirAll(cfg.ServicePath, 0777)
+// information for generating a truss service. The parameter of the request of
+// the definition is that the .pb.go files are generated with, see
+// writePBDotGo.
+func parseServiceDefinition(cfg *truss.Config) (*generators.Input, error) {
+	var req *plugin.CodeGeneratorRequest
+	var err error
+	if cfg.DescriptorSetPath != "" {
+		req, err = parseproto.ReadDescriptorSet(cfg.DescriptorSetPath, cfg.DescriptorSetFiles)
 		if err != nil {
-			return nil, errors.Wrap(err, "cannot create service directory")
+			return nil, errors.Wrap(err, "cannot read descriptor set")
+		}
+		req.Parameter = proto.String(pbgo.Parameter)
+	} else {
+		req, err = parseproto.CodeGeneratorRequest(cfg.DefPaths, cfg.ImportPaths)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot parse .proto files")
 		}
-	}
 
-	// Get path names of .pb.go files
-	pbgoPaths := []string{}
-	for _, p := range protoDefPaths {
-		base := filepath.Base(p)
-		barename := strings.TrimSuffix(base, filepath.Ext(p))
-		pbgp := filepath.Join(cfg.PBPath, barename+".pb.go")
-		pbgoPaths = append(pbgoPaths, pbgp)
-	}
-	pbgoFiles, err := openFiles(pbgoPaths)
-	if err != nil {
-		return nil, errors.Wrap(err, "cannot open all .pb.go files")
-	}
+		// The Go package of the definition is that of the first file
+		// defining a service, which is generated in the directory of that
+		// file unless the pbout flag is set
+		svcDir := serviceDir(cfg, req)
+		if *pbOutFlag == "" {
+			cfg.PBPath = svcDir
+			cfg.PBPackage, err = cfg.Modules.ImportPath(svcDir)
+			if err != nil {
+				return nil, errors.Wrap(err, "proto files not found in importable go package")
+			}
+		}
 
-	pbFiles, err := openFiles(protoDefPaths)
-	if err != nil {
-		return nil, errors.Wrap(err, "cannot open all .proto files")
+		goPackages, err := goImportPaths(cfg, req, svcDir)
+		if err != nil {
+			return nil, err
+		}
+		req.Parameter = proto.String(pbgo.MappedParameter(goPackages))
 	}
+	log.WithField("PB Package", cfg.PBPackage).Debug()
+	log.WithField("PB Path", cfg.PBPath).Debug()
 
 	// Create the svcdef
-	sd, err := svcdef.New(pbgoFiles, pbFiles)
+	sd, err := svcdef.NewFromRequest(req)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to create service definition; did you pass ALL the protobuf files to truss?")
 	}
 
-	return sd, nil
+	return &generators.Input{
+		Svcdef:  sd,
+		Request: req,
+	}, nil
 }
 
-// generateCode returns a map[string]io.Reader that represents a gokit
-// service
-func generateCode(cfg *truss.Config, sd *svcdef.Svcdef) (map[string]io.Reader, error) {
-	conf := ggkconf.Config{
-		PBPackage:     cfg.PBPackage,
-		GoPackage:     cfg.ServicePackage,
-		PreviousFiles: cfg.PrevGen,
-		Version:       version,
-		VersionDate:   date,
+// writePBDotGo writes the .pb.go files of the definition of req, parsed by
+// parseServiceDefinition.
+func writePBDotGo(cfg *truss.Config, req *plugin.CodeGeneratorRequest) error {
+	if cfg.DescriptorSetPath == "" {
+		return generatePBDotGo(cfg, req, serviceDir(cfg, req))
 	}
 
-	genGokitFiles, err := gengokit.GenerateGokit(sd, conf)
+	// The .pb.go files of the descriptors are generated in process
+	pbgoFiles, err := pbgo.Generate(req)
 	if err != nil {
-		return nil, errors.Wrap(err, "cannot generate gokit service")
+		return err
+	}
+	// Every file is written to cfg.PBPath, being of the one package
+	written := make(map[string]string)
+	for name, file := range pbgoFiles {
+		base := path.Base(name)
+		if other, ok := written[base]; ok {
+			return errors.Errorf("cannot write both %s and %s to %s", other, name, filepath.Join(cfg.PBPath, base))
+		}
+		written[base] = name
+		if err := out.WriteFile(filepath.Join(cfg.PBPath, base), file); err != nil {
+			return errors.Wrap(err, "cannot write .pb.go files")
+		}
 	}
+	return nil
+}
 
-	return genGokitFiles, nil
+// serviceDir returns the directory of the first of the .proto files of req
+// defining a service, or of the first file if none does.
+func serviceDir(cfg *truss.Config, req *plugin.CodeGeneratorRequest) string {
+	for i, name := range req.FileToGenerate {
+		if definesService(req, name) {
+			return filepath.Dir(cfg.DefPaths[i])
+		}
+	}
+	return filepath.Dir(cfg.DefPaths[0])
 }
 
-func openFiles(paths []string) (map[string]io.Reader, error) {
-	rv := map[string]io.Reader{}
-	for _, p := range paths {
-		reader, err := os.Open(p)
+// goImportPaths returns the Go import path of the package of each .proto file
+// of req which truss determines itself, keyed by file name. These are the
+// definition files, whose .pb.go files are generated next to them, and the
+// files they import which have no go_package option naming an import path.
+// Both are given the import path of the directory they are found in, except
+// for the definition files within svcDir, which are given cfg.PBPackage.
+func goImportPaths(cfg *truss.Config, req *plugin.CodeGeneratorRequest, svcDir string) (map[string]string, error) {
+	rv := make(map[string]string)
+	for i, name := range req.FileToGenerate {
+		dir := filepath.Dir(cfg.DefPaths[i])
+		if dir == svcDir {
+			rv[name] = cfg.PBPackage
+			continue
+		}
+		importPath, err := cfg.Modules.ImportPath(dir)
 		if err != nil {
-			return nil, errors.Wrapf(err, "cannot open file %q", p)
+			return nil, errors.Wrapf(err, "cannot determine Go package of %q", name)
+		}
+		rv[name] = importPath
+	}
+	for _, f := range req.ProtoFile {
+		if _, ok := rv[f.GetName()]; ok {
+			continue
+		}
+		if opt := f.GetOptions().GetGoPackage(); strings.Contains(opt, "/") {
+			continue
+		}
+		p, ok := parseproto.Locate(f.GetName(), cfg.ImportPaths)
+		if !ok {
+			continue
+		}
+		if importPath, err := cfg.Modules.ImportPath(filepath.Dir(p)); err == nil {
+			rv[f.GetName()] = importPath
 		}
-		rv[p] = reader
 	}
+	log.WithField("Go Packages", rv).Debug()
 	return rv, nil
 }
 
-// writeGenFile writes a file at path to the filesystem
-func writeGenFile(file io.Reader, path string) error {
-	err := os.MkdirAll(filepath.Dir(path), 0777)
-	if err != nil {
-		return err
+// definesService returns true if the file of req with the given name
+// defines a service.
+func definesService(req *plugin.CodeGeneratorRequest, name string) bool {
+	for _, f := range req.ProtoFile {
+		if f.GetName() == name {
+			return len(f.Service) > 0
+		}
 	}
+	return false
+}
 
-	outFile, err := os.Create(path)
-	if err != nil {
-		return errors.Wrapf(err, "cannot create file %v", path)
+// generatePBDotGo generates the .pb.go files of the definition files of req
+// next to them, once for the files of each directory, as each directory is a
+// Go package of its own. Those of the files within svcDir are generated in
+// cfg.PBPath instead. They are generated in process from the descriptors of
+// req, with its parameter, unless the protoc flag is set to run protoc and
+// protoc-gen-gogofaster instead.
+func generatePBDotGo(cfg *truss.Config, req *plugin.CodeGeneratorRequest, svcDir string) error {
+	var dirs []string
+	byDir := make(map[string][]string)
+	namesByDir := make(map[string][]string)
+	for i, def := range cfg.DefPaths {
+		dir := filepath.Dir(def)
+		if _, ok := byDir[dir]; !ok {
+			dirs = append(dirs, dir)
+		}
+		byDir[dir] = append(byDir[dir], def)
+		namesByDir[dir] = append(namesByDir[dir], req.FileToGenerate[i])
 	}
 
-	_, err = io.Copy(outFile, file)
-	if err != nil {
-		return errors.Wrapf(err, "cannot write to %v", path)
+	for _, dir := range dirs {
+		name, root, ok := parseproto.Name(byDir[dir][0], cfg.ImportPaths)
+		if !ok {
+			return errors.Errorf("%s is not within any import path", byDir[dir][0])
+		}
+		// The files are named relative to root, as are those generated
+		dirName := path.Dir(name)
+		outDir := dir
+		if dir == svcDir {
+			outDir = cfg.PBPath
+		}
+		var files map[string]io.Reader
+		var err error
+		if *protocFlag {
+			files, err = execprotoc.GeneratePBDotGo(byDir[dir], cfg.ImportPaths, root, req.GetParameter())
+		} else {
+			dirReq := *req
+			dirReq.FileToGenerate = namesByDir[dir]
+			files, err = pbgo.Generate(&dirReq)
+		}
+		if err != nil {
+			return err
+		}
+		for name, file := range files {
+			rel, err := filepath.Rel(filepath.FromSlash(dirName), filepath.FromSlash(name))
+			if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				return errors.Errorf("%s was generated outside of the directory of its .proto file %s", name, dirName)
+			}
+			if err := out.WriteFile(filepath.Join(outDir, rel), file); err != nil {
+				return err
+			}
+		}
 	}
-	return outFile.Close()
+	return nil
 }
 
-// cleanProtofilePath returns the absolute filepath of a group of files
-// of the files, or an error if the files are not in the same directory
+// generateCode returns a map[string]io.Reader that represents the files of the
+// service svcName, as generated by each of svcGenerators
+func generateCode(cfg *truss.Config, def *generators.Input, svcName string) (map[string]io.Reader, error) {
+	in := *def
+	in.Config = ggkconf.Config{
+		TemplateDir:   cfg.TemplateDir,
+		PBPackage:     cfg.PBPackage,
+		GoPackage:     cfg.ServicePackage,
+		Service:       svcName,
+		PreviousFiles: cfg.PrevGen,
+		Options:       cfg.Options,
+		Version:       version,
+		VersionDate:   date,
+	}
+
+	files := make(map[string]io.Reader)
+	for _, g := range svcGenerators {
+		genFiles, err := g.Generate(&in)
+		if err != nil {
+			return nil, err
+		}
+		for path, file := range genFiles {
+			if _, ok := files[path]; ok {
+				return nil, errors.Errorf("%v is generated by more than one generator", path)
+			}
+			files[path] = file
+		}
+	}
+
+	return files, nil
+}
+
+// cleanProtofilePath returns the absolute filepath of each of a group of
+// files
 func cleanProtofilePath(rawPaths []string) ([]string, error) {
 	var fullPaths []string
 
@@ -344,78 +958,19 @@ func cleanProtofilePath(rawPaths []string) ([]string, error) {
 		log.WithField("fullDefPath", full)
 
 		fullPaths = append(fullPaths, full)
-
-		if filepath.Dir(fullPaths[0]) != filepath.Dir(full) {
-			return nil, errors.Errorf("passed .proto files in different directories")
-		}
 	}
 
 	return fullPaths, nil
 }
 
-// readPreviousGeneration returns a map[string]io.Reader representing the files in serviceDir
-func readPreviousGeneration(serviceDir string) (map[string]io.Reader, error) {
-	if !fileExists(serviceDir) {
-		return nil, nil
-	}
-
-	const handlersDirName = "handlers"
-	files := make(map[string]io.Reader)
-
-	addFileToFiles := func(path string, info os.FileInfo, err error) error {
-		if info.IsDir() {
-			switch info.Name() {
-			// Only files within the handlers dir are used to
-			// support regeneration.
-			// See `gengokit/generator/gen.go:generateResponseFile`
-			case filepath.Base(serviceDir), handlersDirName:
-				return nil
-			default:
-				return filepath.SkipDir
-			}
-		}
-
-		file, ioErr := os.Open(path)
-		if ioErr != nil {
-			return errors.Wrapf(ioErr, "cannot read file: %v", path)
-		}
-
-		// trim the prefix of the path to the proto files from the full path to the file
-		relPath, err := filepath.Rel(serviceDir, path)
-		if err != nil {
-			return err
-		}
-
-		// ensure relPath is unix-style, so it matches what we look for later
-		relPath = filepath.ToSlash(relPath)
-
-		files[relPath] = file
-
-		return nil
-	}
-
-	err := filepath.Walk(serviceDir, addFileToFiles)
-	if err != nil {
-		return nil, errors.Wrapf(err, "cannot fully walk directory %v", serviceDir)
-	}
-
-	return files, nil
-}
-
-// fileExists checks if a file at the given path exists. Returns true if the
-// file exists, and false if the file does not exist.
-func fileExists(path string) bool {
-	if _, err := os.Stat(path); err == nil {
-		return true
-	}
-	return false
-}
-
+// cleanupOldFiles removes the files older versions of truss generated within
+// the tree of the named service at servicePath, which has no manifest listing
+// them.
 func cleanupOldFiles(servicePath, serviceName string) {
 	serverCLI := filepath.Join(servicePath, "svc/server/cli")
 	if _, err := os.Stat(serverCLI); err == nil {
 		log.Warnf("Removing stale 'svc/server/cli' files")
-		err := os.RemoveAll(serverCLI)
+		err := out.RemoveAll(serverCLI)
 		if err != nil {
 			log.Error(err)
 		}
@@ -423,7 +978,7 @@ func cleanupOldFiles(servicePath, serviceName string) {
 	clientCLI := filepath.Join(servicePath, "svc/client/cli")
 	if _, err := os.Stat(clientCLI); err == nil {
 		log.Warnf("Removing stale 'svc/client/cli' files")
-		err := os.RemoveAll(clientCLI)
+		err := out.RemoveAll(clientCLI)
 		if err != nil {
 			log.Error(err)
 		}
@@ -432,63 +987,9 @@ func cleanupOldFiles(servicePath, serviceName string) {
 	oldServer := filepath.Join(servicePath, fmt.Sprintf("cmd/%s-server", serviceName))
 	if _, err := os.Stat(oldServer); err == nil {
 		log.Warnf(fmt.Sprintf("Removing stale 'cmd/%s-server' files, use cmd/%s going forward", serviceName, serviceName))
-		err := os.RemoveAll(oldServer)
+		err := out.RemoveAll(oldServer)
 		if err != nil {
 			log.Error(err)
 		}
 	}
 }
-
-// promptNoMake prints that truss was not built with make and prompts the user
-// asking if they would like for this process to be automated
-// returns true if yes, false if not.
-func promptNoMake() bool {
-	const msg = `
-truss was not built using Makefile.
-Please run 'make' inside go import path %s.
-
-Do you want to automatically run 'make' and rerun command:
-
-	$ `
-	fmt.Printf(msg, trussImportPath)
-	for _, a := range os.Args {
-		fmt.Print(a)
-		fmt.Print(" ")
-	}
-	const q = `
-
-? [Y/n] `
-	fmt.Print(q)
-
-	var response string
-	_, err := fmt.Scanln(&response)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	switch strings.ToLower(strings.TrimSpace(response)) {
-	case "y", "yes":
-		return true
-	}
-	return false
-}
-
-const trussImportPath = "github.com/metaverse/truss"
-
-// makeAndRunTruss installs truss by running make in trussImportPath.
-// It then passes through args to newly installed truss.
-func makeAndRunTruss(args []string) error {
-	p, err := build.Default.Import(trussImportPath, "", build.FindOnly)
-	if err != nil {
-		return errors.Wrap(err, "could not find truss directory")
-	}
-	make := exec.Command("make")
-	make.Dir = p.Dir
-	err = make.Run()
-	if err != nil {
-		return errors.Wrap(err, "could not run make in truss directory")
-	}
-	truss := exec.Command("truss", args[1:]...)
-	truss.Stdin, truss.Stdout, truss.Stderr = os.Stdin, os.Stdout, os.Stderr
-	return truss.Run()
-}
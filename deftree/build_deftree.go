@@ -19,7 +19,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/metaverse/truss/svcdef/svcparse"
-	"github.com/metaverse/truss/truss/execprotoc"
+	"github.com/metaverse/truss/truss/parseproto"
 )
 
 var gengo *generator.Generator
@@ -36,13 +36,38 @@ func initGenGo(req *plugin.CodeGeneratorRequest) {
 // New accepts a Protobuf plugin.CodeGeneratorRequest and the contents of the
 // file containing the service declaration and returns a Deftree struct
 func New(req *plugin.CodeGeneratorRequest, serviceFile io.Reader) (Deftree, error) {
+	dt, err := newDefinition(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var svc *ProtoService
+	var serviceFileName string
+	for _, file := range dt.Files {
+		if len(file.Services) > 0 {
+			svc = file.Services[0]
+			serviceFileName = file.GetName()
+		}
+	}
+
+	err = addHttpOptions(dt, svc, serviceFile)
+	if err != nil {
+		log.WithError(err).Warnf("Error found while parsing file %v", serviceFileName)
+		log.Warnf("Due to the above warning(s), http options and bindings where not parsed and will not be present in the generated documentation.")
+	}
+
+	return dt, nil
+}
+
+// newDefinition returns the MicroserviceDefinition of the files of req within
+// the package of the files to generate, described by the comments of req,
+// without the http bindings of its services.
+func newDefinition(req *plugin.CodeGeneratorRequest) (*MicroserviceDefinition, error) {
 	dt := MicroserviceDefinition{}
 	dt.SetName(findDeftreePackage(req))
 
 	initGenGo(req)
 
-	var svc *ProtoService
-	var serviceFileName string
 	for _, file := range req.ProtoFile {
 		// Check if this file is one we even should examine, and if it's not,
 		// skip it
@@ -56,11 +81,6 @@ func New(req *plugin.CodeGeneratorRequest, serviceFile io.Reader) (Deftree, erro
 			return nil, errors.Wrapf(err, "file creation of %q failed", file.GetName())
 		}
 
-		if len(newFile.Services) > 0 {
-			svc = newFile.Services[0]
-			serviceFileName = newFile.GetName()
-		}
-
 		dt.Files = append(dt.Files, newFile)
 	}
 
@@ -71,12 +91,6 @@ func New(req *plugin.CodeGeneratorRequest, serviceFile io.Reader) (Deftree, erro
 	// The implementation of this function is in deftree/associate_comments.go
 	AssociateComments(&dt, req)
 
-	err := addHttpOptions(&dt, svc, serviceFile)
-	if err != nil {
-		log.WithError(err).Warnf("Error found while parsing file %v", serviceFileName)
-		log.Warnf("Due to the above warning(s), http options and bindings where not parsed and will not be present in the generated documentation.")
-	}
-
 	return &dt, nil
 }
 
@@ -98,7 +112,7 @@ func NewFromString(def string, gopath []string) (Deftree, error) {
 		return nil, errors.Wrap(err, "cannot write proto definition to file")
 	}
 
-	req, err := execprotoc.CodeGeneratorRequest([]string{defPath}, gopath)
+	req, err := parseproto.CodeGeneratorRequest([]string{defPath}, parseproto.GoPathImports(gopath))
 	if err != nil {
 		return nil, errors.Wrap(err, "cannot create a proto CodeGeneratorRequest")
 	}
@@ -0,0 +1,191 @@
+package deftree
+
+import (
+	"strings"
+
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+	"github.com/gogo/protobuf/protoc-gen-gogo/generator"
+	plugin "github.com/gogo/protobuf/protoc-gen-gogo/plugin"
+	"github.com/pkg/errors"
+
+	"github.com/metaverse/truss/svcdef"
+)
+
+// NewForService returns a Deftree documenting the named service of the
+// definition of req, which sd is parsed from; service may be named as within
+// either. Of the services of the definition only the named one is included,
+// along with only the messages and enums its methods refer to. The HTTP
+// bindings of its methods are those of sd, read from the descriptors of req,
+// so that no source is needed.
+func NewForService(req *plugin.CodeGeneratorRequest, sd *svcdef.Svcdef, service string) (Deftree, error) {
+	service = generator.CamelCase(service)
+	var sdSvc *svcdef.Service
+	for _, s := range sd.Services {
+		if s.Name == service {
+			sdSvc = s
+		}
+	}
+	if sdSvc == nil {
+		return nil, errors.Errorf("service %q is not defined", service)
+	}
+
+	dt, err := newDefinition(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var svc *ProtoService
+	for _, f := range dt.Files {
+		var services []*ProtoService
+		for _, s := range f.Services {
+			if generator.CamelCase(s.Name) == service {
+				svc = s
+				services = append(services, s)
+			}
+		}
+		f.Services = services
+	}
+	if svc == nil {
+		return nil, errors.Errorf("service %q is not defined in package %q", service, dt.Name)
+	}
+
+	for _, meth := range svc.Methods {
+		for _, m := range sdSvc.Methods {
+			if m.Name != generator.CamelCase(meth.Name) {
+				continue
+			}
+			for _, b := range m.Bindings {
+				meth.HttpBindings = append(meth.HttpBindings, newHttpBinding(meth, b))
+			}
+		}
+	}
+
+	keepReferenced(dt, serviceTypes(req, svc.FullyQualifiedName))
+	return dt, nil
+}
+
+// newHttpBinding returns the MethodHttpBinding of meth equivalent to b, with
+// a parameter for each field of the request of meth.
+func newHttpBinding(meth *ServiceMethod, b *svcdef.HTTPBinding) *MethodHttpBinding {
+	// The options of a oneof are found where the oneof is
+	locations := make(map[string]string)
+	for _, p := range b.Params {
+		locations[p.Field.PBFieldName] = p.Location
+		for _, option := range p.Field.Type.Oneof {
+			locations[option.PBFieldName] = p.Location
+		}
+	}
+
+	rv := &MethodHttpBinding{
+		Verb: strings.ToLower(b.Verb),
+		Path: b.Path,
+	}
+	for _, f := range meth.RequestType.Fields {
+		location, ok := locations[f.Name]
+		if !ok {
+			location = "query"
+		}
+		rv.Params = append(rv.Params, &HttpParameter{
+			Name:     f.Name,
+			Location: location,
+			Type:     f.Type.Name,
+		})
+	}
+	return rv
+}
+
+// serviceTypes returns the fully qualified names, e.g. ".pkg.Outer.Inner", of
+// the messages and enums the methods of the named service of req refer to,
+// directly or through the fields of other messages.
+func serviceTypes(req *plugin.CodeGeneratorRequest, service string) map[string]bool {
+	messages := make(map[string]*descriptor.DescriptorProto)
+	var declare func(prefix string, msgs []*descriptor.DescriptorProto)
+	declare = func(prefix string, msgs []*descriptor.DescriptorProto) {
+		for _, m := range msgs {
+			name := prefix + "." + m.GetName()
+			messages[name] = m
+			declare(name, m.NestedType)
+		}
+	}
+
+	var pending []string
+	for _, f := range req.GetProtoFile() {
+		prefix := ""
+		if f.GetPackage() != "" {
+			prefix = "." + f.GetPackage()
+		}
+		declare(prefix, f.MessageType)
+		for _, s := range f.Service {
+			// Named as ProtoService.FullyQualifiedName is
+			if "."+f.GetPackage()+"."+s.GetName() != service {
+				continue
+			}
+			for _, m := range s.Method {
+				pending = append(pending, m.GetInputType(), m.GetOutputType())
+			}
+		}
+	}
+
+	rv := make(map[string]bool)
+	for len(pending) > 0 {
+		name := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if rv[name] {
+			continue
+		}
+		rv[name] = true
+		if m, ok := messages[name]; ok {
+			for _, f := range m.Field {
+				if f.GetTypeName() != "" {
+					pending = append(pending, f.GetTypeName())
+				}
+			}
+		}
+	}
+	return rv
+}
+
+// keepReferenced removes the messages and enums of dt which are not named by
+// types, except for those declaring a type named by types within them, and
+// then the files left with nothing to document.
+func keepReferenced(dt *MicroserviceDefinition, types map[string]bool) {
+	referenced := func(name string) bool {
+		full := "." + dt.Name + "." + name
+		if dt.Name == "" {
+			full = "." + name
+		}
+		if types[full] {
+			return true
+		}
+		for t := range types {
+			if strings.HasPrefix(t, full+".") {
+				return true
+			}
+		}
+		return false
+	}
+
+	var files []*ProtoFile
+	for _, f := range dt.Files {
+		var msgs []*ProtoMessage
+		for _, m := range f.Messages {
+			if referenced(m.Name) {
+				msgs = append(msgs, m)
+			}
+		}
+		f.Messages = msgs
+
+		var enums []*ProtoEnum
+		for _, e := range f.Enums {
+			if referenced(e.Name) {
+				enums = append(enums, e)
+			}
+		}
+		f.Enums = enums
+
+		if len(f.Messages) > 0 || len(f.Enums) > 0 || len(f.Services) > 0 {
+			files = append(files, f)
+		}
+	}
+	dt.Files = files
+}
@@ -0,0 +1,126 @@
+package deftree
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/metaverse/truss/svcdef"
+	"github.com/metaverse/truss/truss/parseproto"
+)
+
+func TestNewForService(t *testing.T) {
+	const def = `
+		syntax = "proto3";
+
+		package general;
+
+		import "github.com/metaverse/truss/deftree/googlethirdparty/annotations.proto";
+
+		message GetRequest {
+			string id = 1;
+			Filter filter = 2;
+		}
+
+		message Filter {
+			Kind kind = 1;
+		}
+
+		enum Kind {
+			ANY = 0;
+			SOME = 1;
+		}
+
+		message Item {
+			string id = 1;
+		}
+
+		message Other {
+			string name = 1;
+		}
+
+		// Items gets items
+		service Items {
+			// Get gets an item
+			rpc Get (GetRequest) returns (Item) {
+				option (google.api.http) = {
+					get: "/items/{id}"
+				};
+			}
+		}
+
+		service Others {
+			rpc Get (Other) returns (Other) {}
+		}
+	`
+	dir, err := ioutil.TempDir("", "truss-deftree-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "general.proto")
+	if err := ioutil.WriteFile(path, []byte(def), 0666); err != nil {
+		t.Fatal(err)
+	}
+	req, err := parseproto.CodeGeneratorRequest([]string{path}, append([]string{dir}, parseproto.GoPathImports(gopath)...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sd, err := svcdef.NewFromRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dt, err := NewForService(req, sd, "Items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := dt.(*MicroserviceDefinition)
+	if len(md.Files) != 1 {
+		t.Fatalf("Files = %v, want general.proto only", md.Files)
+	}
+	f := md.Files[0]
+
+	var names []string
+	for _, s := range f.Services {
+		names = append(names, s.Name)
+	}
+	for _, m := range f.Messages {
+		names = append(names, m.Name)
+	}
+	for _, e := range f.Enums {
+		names = append(names, e.Name)
+	}
+	if want := []string{"Items", "GetRequest", "Filter", "Item", "Kind"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("documented %v, want %v", names, want)
+	}
+
+	meth := f.Services[0].Methods[0]
+	if got, want := meth.Description, "Get gets an item"; got != want {
+		t.Errorf("Description = %q, want %q", got, want)
+	}
+	if len(meth.HttpBindings) != 1 {
+		t.Fatalf("HttpBindings = %v, want one", meth.HttpBindings)
+	}
+	b := meth.HttpBindings[0]
+	if b.Verb != "get" || b.Path != "/items/{id}" {
+		t.Errorf("binding = %s %s, want get /items/{id}", b.Verb, b.Path)
+	}
+	var params []HttpParameter
+	for _, p := range b.Params {
+		params = append(params, *p)
+	}
+	want := []HttpParameter{
+		{Name: "id", Location: "path", Type: "TYPE_STRING"},
+		{Name: "filter", Location: "query", Type: ".general.Filter"},
+	}
+	if !reflect.DeepEqual(params, want) {
+		t.Errorf("Params = %+v, want %+v", params, want)
+	}
+
+	if _, err := NewForService(req, sd, "Missing"); err == nil {
+		t.Error("expected an error documenting an undefined service")
+	}
+}
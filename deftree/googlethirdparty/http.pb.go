@@ -3,11 +3,13 @@
 
 package google_api
 
-import proto "github.com/gogo/protobuf/proto"
-import fmt "fmt"
-import math "math"
-
-import io "io"
+import (
+	fmt "fmt"
+	proto "github.com/gogo/protobuf/proto"
+	io "io"
+	math "math"
+	math_bits "math/bits"
+)
 
 // Reference imports to suppress errors if they are not otherwise used.
 var _ = proto.Marshal
@@ -27,14 +29,14 @@ type Http struct {
 	// A list of HTTP configuration rules that apply to individual API methods.
 	//
 	// **NOTE:** All service configuration rules follow "last one wins" order.
-	Rules []*HttpRule `protobuf:"bytes,1,rep,name=rules" json:"rules,omitempty"`
+	Rules []*HttpRule `protobuf:"bytes,1,rep,name=rules,proto3" json:"rules,omitempty"`
 }
 
 func (m *Http) Reset()         { *m = Http{} }
 func (m *Http) String() string { return proto.CompactTextString(m) }
 func (*Http) ProtoMessage()    {}
 func (*Http) Descriptor() ([]byte, []int) {
-	return fileDescriptor_http_1fdc2c51640cc709, []int{0}
+	return fileDescriptor_11b04836674e6f94, []int{0}
 }
 func (m *Http) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -51,8 +53,8 @@ func (m *Http) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
 		return b[:n], nil
 	}
 }
-func (dst *Http) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_Http.Merge(dst, src)
+func (m *Http) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Http.Merge(m, src)
 }
 func (m *Http) XXX_Size() int {
 	return m.Size()
@@ -83,21 +85,25 @@ func (m *Http) GetRules() []*HttpRule {
 // operation on a resource collection of messages:
 //
 // ```proto
-// service Messaging {
-//   rpc GetMessage(GetMessageRequest) returns (Message) {
-//     option (google.api.http).get = "/v1/messages/{message_id}/{sub.subfield}";
-//   }
-// }
-// message GetMessageRequest {
-//   message SubMessage {
-//     string subfield = 1;
-//   }
-//   string message_id = 1; // mapped to the URL
-//   SubMessage sub = 2;    // `sub.subfield` is url-mapped
-// }
-// message Message {
-//   string text = 1; // content of the resource
-// }
+//
+//	service Messaging {
+//	  rpc GetMessage(GetMessageRequest) returns (Message) {
+//	    option (google.api.http).get = "/v1/messages/{message_id}/{sub.subfield}";
+//	  }
+//	}
+//
+//	message GetMessageRequest {
+//	  message SubMessage {
+//	    string subfield = 1;
+//	  }
+//	  string message_id = 1; // mapped to the URL
+//	  SubMessage sub = 2;    // `sub.subfield` is url-mapped
+//	}
+//
+//	message Message {
+//	  string text = 1; // content of the resource
+//	}
+//
 // ```
 //
 // This definition enables an automatic, bidrectional mapping of HTTP
@@ -116,14 +122,16 @@ func (m *Http) GetRules() []*HttpRule {
 // parameters. Assume the following definition of the request message:
 //
 // ```proto
-// message GetMessageRequest {
-//   message SubMessage {
-//     string subfield = 1;
-//   }
-//   string message_id = 1; // mapped to the URL
-//   int64 revision = 2;    // becomes a parameter
-//   SubMessage sub = 3;    // `sub.subfield` becomes a parameter
-// }
+//
+//	message GetMessageRequest {
+//	  message SubMessage {
+//	    string subfield = 1;
+//	  }
+//	  string message_id = 1; // mapped to the URL
+//	  int64 revision = 2;    // becomes a parameter
+//	  SubMessage sub = 3;    // `sub.subfield` becomes a parameter
+//	}
+//
 // ```
 //
 // This enables a HTTP JSON to RPC mapping as below:
@@ -142,18 +150,21 @@ func (m *Http) GetRules() []*HttpRule {
 // message resource collection:
 //
 // ```proto
-// service Messaging {
-//   rpc UpdateMessage(UpdateMessageRequest) returns (Message) {
-//     option (google.api.http) = {
-//       put: "/v1/messages/{message_id}"
-//       body: "message"
-//     };
-//   }
-// }
-// message UpdateMessageRequest {
-//   string message_id = 1; // mapped to the URL
-//   Message message = 2;   // mapped to the body
-// }
+//
+//	service Messaging {
+//	  rpc UpdateMessage(UpdateMessageRequest) returns (Message) {
+//	    option (google.api.http) = {
+//	      put: "/v1/messages/{message_id}"
+//	      body: "message"
+//	    };
+//	  }
+//	}
+//
+//	message UpdateMessageRequest {
+//	  string message_id = 1; // mapped to the URL
+//	  Message message = 2;   // mapped to the body
+//	}
+//
 // ```
 //
 // The following HTTP JSON to RPC mapping is enabled, where the
@@ -170,18 +181,21 @@ func (m *Http) GetRules() []*HttpRule {
 // the update method:
 //
 // ```proto
-// service Messaging {
-//   rpc UpdateMessage(Message) returns (Message) {
-//     option (google.api.http) = {
-//       put: "/v1/messages/{message_id}"
-//       body: "*"
-//     };
-//   }
-// }
-// message Message {
-//   string message_id = 1;
-//   string text = 2;
-// }
+//
+//	service Messaging {
+//	  rpc UpdateMessage(Message) returns (Message) {
+//	    option (google.api.http) = {
+//	      put: "/v1/messages/{message_id}"
+//	      body: "*"
+//	    };
+//	  }
+//	}
+//
+//	message Message {
+//	  string message_id = 1;
+//	  string text = 2;
+//	}
+//
 // ```
 //
 // The following HTTP JSON to RPC mapping is enabled:
@@ -200,20 +214,23 @@ func (m *Http) GetRules() []*HttpRule {
 // the `additional_bindings` option. Example:
 //
 // ```proto
-// service Messaging {
-//   rpc GetMessage(GetMessageRequest) returns (Message) {
-//     option (google.api.http) = {
-//       get: "/v1/messages/{message_id}"
-//       additional_bindings {
-//         get: "/v1/users/{user_id}/messages/{message_id}"
-//       }
-//     };
-//   }
-// }
-// message GetMessageRequest {
-//   string message_id = 1;
-//   string user_id = 2;
-// }
+//
+//	service Messaging {
+//	  rpc GetMessage(GetMessageRequest) returns (Message) {
+//	    option (google.api.http) = {
+//	      get: "/v1/messages/{message_id}"
+//	      additional_bindings {
+//	        get: "/v1/users/{user_id}/messages/{message_id}"
+//	      }
+//	    };
+//	  }
+//	}
+//
+//	message GetMessageRequest {
+//	  string message_id = 1;
+//	  string user_id = 2;
+//	}
+//
 // ```
 //
 // This enables the following two alternative HTTP JSON to RPC
@@ -229,25 +246,25 @@ func (m *Http) GetRules() []*HttpRule {
 // The rules for mapping HTTP path, query parameters, and body fields
 // to the request message are as follows:
 //
-// 1. The `body` field specifies either `*` or a field path, or is
-//    omitted. If omitted, it assumes there is no HTTP body.
-// 2. Leaf fields (recursive expansion of nested messages in the
-//    request) can be classified into three types:
+//  1. The `body` field specifies either `*` or a field path, or is
+//     omitted. If omitted, it assumes there is no HTTP body.
+//  2. Leaf fields (recursive expansion of nested messages in the
+//     request) can be classified into three types:
 //     (a) Matched in the URL template.
 //     (b) Covered by body (if body is `*`, everything except (a) fields;
-//         else everything under the body field)
+//     else everything under the body field)
 //     (c) All other fields.
-// 3. URL query parameters found in the HTTP request are mapped to (c) fields.
-// 4. Any body sent with an HTTP request can contain only (b) fields.
+//  3. URL query parameters found in the HTTP request are mapped to (c) fields.
+//  4. Any body sent with an HTTP request can contain only (b) fields.
 //
 // The syntax of the path template is as follows:
 //
-//     Template = "/" Segments [ Verb ] ;
-//     Segments = Segment { "/" Segment } ;
-//     Segment  = "*" | "**" | LITERAL | Variable ;
-//     Variable = "{" FieldPath [ "=" Segments ] "}" ;
-//     FieldPath = IDENT { "." IDENT } ;
-//     Verb     = ":" LITERAL ;
+//	Template = "/" Segments [ Verb ] ;
+//	Segments = Segment { "/" Segment } ;
+//	Segment  = "*" | "**" | LITERAL | Variable ;
+//	Variable = "{" FieldPath [ "=" Segments ] "}" ;
+//	FieldPath = IDENT { "." IDENT } ;
+//	Verb     = ":" LITERAL ;
 //
 // The syntax `*` matches a single path segment. It follows the semantics of
 // [RFC 6570](https://tools.ietf.org/html/rfc6570) Section 3.2.2 Simple String
@@ -293,17 +310,24 @@ type HttpRule struct {
 	// body. NOTE: the referred field must not be a repeated field and must be
 	// present at the top-level of response message type.
 	Body string `protobuf:"bytes,7,opt,name=body,proto3" json:"body,omitempty"`
+	// Optional. The name of the response field whose value is mapped to the HTTP
+	// response body. When omitted, the entire response message will be used
+	// as the HTTP response body.
+	//
+	// NOTE: The referred field must be present at the top-level of the response
+	// message type.
+	ResponseBody string `protobuf:"bytes,12,opt,name=response_body,json=responseBody,proto3" json:"response_body,omitempty"`
 	// Additional HTTP bindings for the selector. Nested bindings must
 	// not contain an `additional_bindings` field themselves (that is,
 	// the nesting may only be one level deep).
-	AdditionalBindings []*HttpRule `protobuf:"bytes,11,rep,name=additional_bindings,json=additionalBindings" json:"additional_bindings,omitempty"`
+	AdditionalBindings []*HttpRule `protobuf:"bytes,11,rep,name=additional_bindings,json=additionalBindings,proto3" json:"additional_bindings,omitempty"`
 }
 
 func (m *HttpRule) Reset()         { *m = HttpRule{} }
 func (m *HttpRule) String() string { return proto.CompactTextString(m) }
 func (*HttpRule) ProtoMessage()    {}
 func (*HttpRule) Descriptor() ([]byte, []int) {
-	return fileDescriptor_http_1fdc2c51640cc709, []int{1}
+	return fileDescriptor_11b04836674e6f94, []int{1}
 }
 func (m *HttpRule) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -320,8 +344,8 @@ func (m *HttpRule) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
 		return b[:n], nil
 	}
 }
-func (dst *HttpRule) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_HttpRule.Merge(dst, src)
+func (m *HttpRule) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HttpRule.Merge(m, src)
 }
 func (m *HttpRule) XXX_Size() int {
 	return m.Size()
@@ -354,7 +378,7 @@ type HttpRule_Patch struct {
 	Patch string `protobuf:"bytes,6,opt,name=patch,proto3,oneof"`
 }
 type HttpRule_Custom struct {
-	Custom *CustomHttpPattern `protobuf:"bytes,8,opt,name=custom,oneof"`
+	Custom *CustomHttpPattern `protobuf:"bytes,8,opt,name=custom,proto3,oneof"`
 }
 
 func (*HttpRule_Get) isHttpRule_Pattern()    {}
@@ -427,6 +451,13 @@ func (m *HttpRule) GetBody() string {
 	return ""
 }
 
+func (m *HttpRule) GetResponseBody() string {
+	if m != nil {
+		return m.ResponseBody
+	}
+	return ""
+}
+
 func (m *HttpRule) GetAdditionalBindings() []*HttpRule {
 	if m != nil {
 		return m.AdditionalBindings
@@ -576,7 +607,7 @@ func (m *CustomHttpPattern) Reset()         { *m = CustomHttpPattern{} }
 func (m *CustomHttpPattern) String() string { return proto.CompactTextString(m) }
 func (*CustomHttpPattern) ProtoMessage()    {}
 func (*CustomHttpPattern) Descriptor() ([]byte, []int) {
-	return fileDescriptor_http_1fdc2c51640cc709, []int{2}
+	return fileDescriptor_11b04836674e6f94, []int{2}
 }
 func (m *CustomHttpPattern) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -593,8 +624,8 @@ func (m *CustomHttpPattern) XXX_Marshal(b []byte, deterministic bool) ([]byte, e
 		return b[:n], nil
 	}
 }
-func (dst *CustomHttpPattern) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_CustomHttpPattern.Merge(dst, src)
+func (m *CustomHttpPattern) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CustomHttpPattern.Merge(m, src)
 }
 func (m *CustomHttpPattern) XXX_Size() int {
 	return m.Size()
@@ -624,6 +655,36 @@ func init() {
 	proto.RegisterType((*HttpRule)(nil), "google.api.HttpRule")
 	proto.RegisterType((*CustomHttpPattern)(nil), "google.api.CustomHttpPattern")
 }
+
+func init() { proto.RegisterFile("http.proto", fileDescriptor_11b04836674e6f94) }
+
+var fileDescriptor_11b04836674e6f94 = []byte{
+	// 364 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x92, 0xc1, 0x4a, 0xf3, 0x40,
+	0x14, 0x85, 0x33, 0x6d, 0x9a, 0xb6, 0xb7, 0xfd, 0x7f, 0x70, 0x2c, 0x32, 0x08, 0x86, 0x52, 0x37,
+	0xc5, 0x45, 0x16, 0x75, 0xe1, 0xc2, 0x85, 0x18, 0x11, 0xeb, 0xae, 0xe4, 0x05, 0x4a, 0x9a, 0x0c,
+	0x69, 0x30, 0xcd, 0x0c, 0x99, 0x9b, 0x85, 0x6f, 0xe1, 0x33, 0xf8, 0x34, 0x2e, 0xbb, 0x14, 0x57,
+	0xd2, 0xbe, 0x84, 0x4b, 0x99, 0x49, 0x6a, 0x0b, 0x82, 0xbb, 0x7b, 0xbe, 0x73, 0x66, 0x38, 0x73,
+	0x19, 0x80, 0x25, 0xa2, 0xf4, 0x64, 0x21, 0x50, 0x50, 0x48, 0x84, 0x48, 0x32, 0xee, 0x85, 0x32,
+	0x1d, 0x4d, 0xc0, 0x9e, 0x22, 0x4a, 0x7a, 0x01, 0xad, 0xa2, 0xcc, 0xb8, 0x62, 0x64, 0xd8, 0x1c,
+	0xf7, 0x26, 0x03, 0x6f, 0x9f, 0xf1, 0x74, 0x20, 0x28, 0x33, 0x1e, 0x54, 0x91, 0xd1, 0x47, 0x03,
+	0x3a, 0x3b, 0x46, 0x4f, 0xa1, 0xa3, 0x78, 0xc6, 0x23, 0x14, 0x05, 0x23, 0x43, 0x32, 0xee, 0x06,
+	0x3f, 0x9a, 0x52, 0x68, 0x26, 0x1c, 0x59, 0x43, 0xe3, 0xa9, 0x15, 0x68, 0xa1, 0x99, 0x2c, 0x91,
+	0x35, 0x77, 0x4c, 0x96, 0x48, 0x07, 0x60, 0x4b, 0xa1, 0x90, 0xd9, 0x35, 0x34, 0x8a, 0x32, 0x70,
+	0x62, 0x9e, 0x71, 0xe4, 0xac, 0x55, 0xf3, 0x5a, 0xd3, 0x13, 0x68, 0xc9, 0x10, 0xa3, 0x25, 0x73,
+	0x6a, 0xa3, 0x92, 0xf4, 0x0a, 0x9c, 0xa8, 0x54, 0x28, 0x56, 0xac, 0x33, 0x24, 0xe3, 0xde, 0xe4,
+	0xec, 0xf0, 0x15, 0x77, 0xc6, 0xd1, 0xbd, 0x67, 0x21, 0x22, 0x2f, 0x72, 0x7d, 0x61, 0x15, 0xa7,
+	0x14, 0xec, 0x85, 0x88, 0x9f, 0x59, 0xdb, 0x3c, 0xc0, 0xcc, 0xf4, 0x1c, 0xfe, 0x15, 0x5c, 0x49,
+	0x91, 0x2b, 0x3e, 0x37, 0x66, 0xdf, 0x98, 0xfd, 0x1d, 0xf4, 0x75, 0xe8, 0x1e, 0x8e, 0xc3, 0x38,
+	0x4e, 0x31, 0x15, 0x79, 0x98, 0xcd, 0x17, 0x69, 0x1e, 0xa7, 0x79, 0xa2, 0x58, 0xef, 0x8f, 0x25,
+	0xd2, 0xfd, 0x01, 0xbf, 0xce, 0xfb, 0x5d, 0x68, 0xcb, 0xaa, 0xd4, 0xe8, 0x1a, 0x8e, 0x7e, 0x35,
+	0xd5, 0xfd, 0x9e, 0xd2, 0x3c, 0xae, 0x17, 0x6c, 0x66, 0xcd, 0x64, 0x88, 0xcb, 0x6a, 0xbb, 0x81,
+	0x99, 0xfd, 0x9b, 0xb7, 0x8d, 0x4b, 0xd6, 0x1b, 0x97, 0x7c, 0x6e, 0x5c, 0xf2, 0xb2, 0x75, 0xad,
+	0xf5, 0xd6, 0xb5, 0xde, 0xb7, 0xae, 0x05, 0xff, 0x23, 0xb1, 0x3a, 0xa8, 0xe3, 0x77, 0xcd, 0xf5,
+	0xfa, 0x3b, 0xcc, 0xc8, 0x17, 0x21, 0xaf, 0x0d, 0xfb, 0xe1, 0x76, 0xf6, 0xb8, 0x70, 0xcc, 0x0f,
+	0xb9, 0xfc, 0x1e, 0x00, 0x59, 0x23, 0xf3, 0x72, 0x2f, 0x02, 0x00, 0x00,
+}
+
 func (m *Http) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -676,9 +737,9 @@ func (m *HttpRule) MarshalTo(dAtA []byte) (int, error) {
 		i += copy(dAtA[i:], m.Selector)
 	}
 	if m.Pattern != nil {
-		nn1, err := m.Pattern.MarshalTo(dAtA[i:])
-		if err != nil {
-			return 0, err
+		nn1, err1 := m.Pattern.MarshalTo(dAtA[i:])
+		if err1 != nil {
+			return 0, err1
 		}
 		i += nn1
 	}
@@ -700,6 +761,12 @@ func (m *HttpRule) MarshalTo(dAtA []byte) (int, error) {
 			i += n
 		}
 	}
+	if len(m.ResponseBody) > 0 {
+		dAtA[i] = 0x62
+		i++
+		i = encodeVarintHttp(dAtA, i, uint64(len(m.ResponseBody)))
+		i += copy(dAtA[i:], m.ResponseBody)
+	}
 	return i, nil
 }
 
@@ -749,9 +816,9 @@ func (m *HttpRule_Custom) MarshalTo(dAtA []byte) (int, error) {
 		dAtA[i] = 0x42
 		i++
 		i = encodeVarintHttp(dAtA, i, uint64(m.Custom.Size()))
-		n2, err := m.Custom.MarshalTo(dAtA[i:])
-		if err != nil {
-			return 0, err
+		n2, err2 := m.Custom.MarshalTo(dAtA[i:])
+		if err2 != nil {
+			return 0, err2
 		}
 		i += n2
 	}
@@ -834,6 +901,10 @@ func (m *HttpRule) Size() (n int) {
 			n += 1 + l + sovHttp(uint64(l))
 		}
 	}
+	l = len(m.ResponseBody)
+	if l > 0 {
+		n += 1 + l + sovHttp(uint64(l))
+	}
 	return n
 }
 
@@ -917,14 +988,7 @@ func (m *CustomHttpPattern) Size() (n int) {
 }
 
 func sovHttp(x uint64) (n int) {
-	for {
-		n++
-		x >>= 7
-		if x == 0 {
-			break
-		}
-	}
-	return n
+	return (math_bits.Len64(x|1) + 6) / 7
 }
 func sozHttp(x uint64) (n int) {
 	return sovHttp(uint64((x << 1) ^ uint64((int64(x) >> 63))))
@@ -944,7 +1008,7 @@ func (m *Http) Unmarshal(dAtA []byte) error {
 			}
 			b := dAtA[iNdEx]
 			iNdEx++
-			wire |= (uint64(b) & 0x7F) << shift
+			wire |= uint64(b&0x7F) << shift
 			if b < 0x80 {
 				break
 			}
@@ -972,7 +1036,7 @@ func (m *Http) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= (int(b) & 0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
@@ -981,6 +1045,9 @@ func (m *Http) Unmarshal(dAtA []byte) error {
 				return ErrInvalidLengthHttp
 			}
 			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthHttp
+			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
@@ -998,6 +1065,9 @@ func (m *Http) Unmarshal(dAtA []byte) error {
 			if skippy < 0 {
 				return ErrInvalidLengthHttp
 			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthHttp
+			}
 			if (iNdEx + skippy) > l {
 				return io.ErrUnexpectedEOF
 			}
@@ -1025,7 +1095,7 @@ func (m *HttpRule) Unmarshal(dAtA []byte) error {
 			}
 			b := dAtA[iNdEx]
 			iNdEx++
-			wire |= (uint64(b) & 0x7F) << shift
+			wire |= uint64(b&0x7F) << shift
 			if b < 0x80 {
 				break
 			}
@@ -1053,7 +1123,7 @@ func (m *HttpRule) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= (uint64(b) & 0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
@@ -1063,6 +1133,9 @@ func (m *HttpRule) Unmarshal(dAtA []byte) error {
 				return ErrInvalidLengthHttp
 			}
 			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthHttp
+			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
@@ -1082,7 +1155,7 @@ func (m *HttpRule) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= (uint64(b) & 0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
@@ -1092,6 +1165,9 @@ func (m *HttpRule) Unmarshal(dAtA []byte) error {
 				return ErrInvalidLengthHttp
 			}
 			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthHttp
+			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
@@ -1111,7 +1187,7 @@ func (m *HttpRule) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= (uint64(b) & 0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
@@ -1121,6 +1197,9 @@ func (m *HttpRule) Unmarshal(dAtA []byte) error {
 				return ErrInvalidLengthHttp
 			}
 			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthHttp
+			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
@@ -1140,7 +1219,7 @@ func (m *HttpRule) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= (uint64(b) & 0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
@@ -1150,6 +1229,9 @@ func (m *HttpRule) Unmarshal(dAtA []byte) error {
 				return ErrInvalidLengthHttp
 			}
 			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthHttp
+			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
@@ -1169,7 +1251,7 @@ func (m *HttpRule) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= (uint64(b) & 0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
@@ -1179,6 +1261,9 @@ func (m *HttpRule) Unmarshal(dAtA []byte) error {
 				return ErrInvalidLengthHttp
 			}
 			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthHttp
+			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
@@ -1198,7 +1283,7 @@ func (m *HttpRule) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= (uint64(b) & 0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
@@ -1208,6 +1293,9 @@ func (m *HttpRule) Unmarshal(dAtA []byte) error {
 				return ErrInvalidLengthHttp
 			}
 			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthHttp
+			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
@@ -1227,7 +1315,7 @@ func (m *HttpRule) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= (uint64(b) & 0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
@@ -1237,6 +1325,9 @@ func (m *HttpRule) Unmarshal(dAtA []byte) error {
 				return ErrInvalidLengthHttp
 			}
 			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthHttp
+			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
@@ -1256,7 +1347,7 @@ func (m *HttpRule) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= (int(b) & 0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
@@ -1265,6 +1356,9 @@ func (m *HttpRule) Unmarshal(dAtA []byte) error {
 				return ErrInvalidLengthHttp
 			}
 			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthHttp
+			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
@@ -1288,7 +1382,7 @@ func (m *HttpRule) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= (int(b) & 0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
@@ -1297,6 +1391,9 @@ func (m *HttpRule) Unmarshal(dAtA []byte) error {
 				return ErrInvalidLengthHttp
 			}
 			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthHttp
+			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
@@ -1305,6 +1402,38 @@ func (m *HttpRule) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 12:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ResponseBody", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHttp
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthHttp
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthHttp
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ResponseBody = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipHttp(dAtA[iNdEx:])
@@ -1314,6 +1443,9 @@ func (m *HttpRule) Unmarshal(dAtA []byte) error {
 			if skippy < 0 {
 				return ErrInvalidLengthHttp
 			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthHttp
+			}
 			if (iNdEx + skippy) > l {
 				return io.ErrUnexpectedEOF
 			}
@@ -1341,7 +1473,7 @@ func (m *CustomHttpPattern) Unmarshal(dAtA []byte) error {
 			}
 			b := dAtA[iNdEx]
 			iNdEx++
-			wire |= (uint64(b) & 0x7F) << shift
+			wire |= uint64(b&0x7F) << shift
 			if b < 0x80 {
 				break
 			}
@@ -1369,7 +1501,7 @@ func (m *CustomHttpPattern) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= (uint64(b) & 0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
@@ -1379,6 +1511,9 @@ func (m *CustomHttpPattern) Unmarshal(dAtA []byte) error {
 				return ErrInvalidLengthHttp
 			}
 			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthHttp
+			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
@@ -1398,7 +1533,7 @@ func (m *CustomHttpPattern) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= (uint64(b) & 0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
@@ -1408,6 +1543,9 @@ func (m *CustomHttpPattern) Unmarshal(dAtA []byte) error {
 				return ErrInvalidLengthHttp
 			}
 			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthHttp
+			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
@@ -1422,6 +1560,9 @@ func (m *CustomHttpPattern) Unmarshal(dAtA []byte) error {
 			if skippy < 0 {
 				return ErrInvalidLengthHttp
 			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthHttp
+			}
 			if (iNdEx + skippy) > l {
 				return io.ErrUnexpectedEOF
 			}
@@ -1488,10 +1629,13 @@ func skipHttp(dAtA []byte) (n int, err error) {
 					break
 				}
 			}
-			iNdEx += length
 			if length < 0 {
 				return 0, ErrInvalidLengthHttp
 			}
+			iNdEx += length
+			if iNdEx < 0 {
+				return 0, ErrInvalidLengthHttp
+			}
 			return iNdEx, nil
 		case 3:
 			for {
@@ -1520,6 +1664,9 @@ func skipHttp(dAtA []byte) (n int, err error) {
 					return 0, err
 				}
 				iNdEx = start + next
+				if iNdEx < 0 {
+					return 0, ErrInvalidLengthHttp
+				}
 			}
 			return iNdEx, nil
 		case 4:
@@ -1538,31 +1685,3 @@ var (
 	ErrInvalidLengthHttp = fmt.Errorf("proto: negative length found during unmarshaling")
 	ErrIntOverflowHttp   = fmt.Errorf("proto: integer overflow")
 )
-
-func init() { proto.RegisterFile("http.proto", fileDescriptor_http_1fdc2c51640cc709) }
-
-var fileDescriptor_http_1fdc2c51640cc709 = []byte{
-	// 347 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x91, 0x4f, 0x4a, 0xc3, 0x40,
-	0x14, 0xc6, 0x33, 0x6d, 0x9a, 0xb6, 0xaf, 0x20, 0xf8, 0x2c, 0x32, 0x08, 0x86, 0xd2, 0x55, 0x71,
-	0x91, 0x45, 0x5d, 0xb8, 0x70, 0x21, 0x46, 0xc4, 0xba, 0x2b, 0xb9, 0x80, 0xa4, 0xc9, 0x90, 0x06,
-	0xd3, 0xcc, 0x90, 0xbc, 0x2c, 0xbc, 0x85, 0x67, 0xf0, 0x0e, 0xde, 0xc1, 0x65, 0x97, 0x2e, 0xa5,
-	0xbd, 0x84, 0x4b, 0x99, 0x49, 0x6a, 0x0b, 0x82, 0xbb, 0xf7, 0xfd, 0xe6, 0x9b, 0xf7, 0x17, 0x60,
-	0x49, 0xa4, 0x3c, 0x55, 0x48, 0x92, 0x08, 0x89, 0x94, 0x49, 0x26, 0xbc, 0x50, 0xa5, 0xe3, 0x29,
-	0xd8, 0x33, 0x22, 0x85, 0x17, 0xd0, 0x29, 0xaa, 0x4c, 0x94, 0x9c, 0x8d, 0xda, 0x93, 0xc1, 0x74,
-	0xe8, 0xed, 0x3d, 0x9e, 0x36, 0x04, 0x55, 0x26, 0x82, 0xda, 0x32, 0x7e, 0x6f, 0x41, 0x6f, 0xc7,
-	0xf0, 0x0c, 0x7a, 0xa5, 0xc8, 0x44, 0x44, 0xb2, 0xe0, 0x6c, 0xc4, 0x26, 0xfd, 0xe0, 0x57, 0x23,
-	0x42, 0x3b, 0x11, 0xc4, 0x5b, 0x1a, 0xcf, 0xac, 0x40, 0x0b, 0xcd, 0x54, 0x45, 0xbc, 0xbd, 0x63,
-	0xaa, 0x22, 0x1c, 0x82, 0xad, 0x64, 0x49, 0xdc, 0x6e, 0xa0, 0x51, 0xc8, 0xc1, 0x89, 0x45, 0x26,
-	0x48, 0xf0, 0x4e, 0xc3, 0x1b, 0x8d, 0xa7, 0xd0, 0x51, 0x21, 0x45, 0x4b, 0xee, 0x34, 0x0f, 0xb5,
-	0x44, 0x04, 0x7b, 0x21, 0xe3, 0x17, 0xde, 0x35, 0x7d, 0x98, 0x18, 0xaf, 0xc0, 0x89, 0xaa, 0x92,
-	0xe4, 0x8a, 0xf7, 0x46, 0x6c, 0x32, 0x98, 0x9e, 0x1f, 0x4e, 0x76, 0x67, 0x5e, 0xf4, 0x2c, 0xf3,
-	0x90, 0x48, 0x14, 0xb9, 0x2e, 0x52, 0xdb, 0xf1, 0x1e, 0x4e, 0xc2, 0x38, 0x4e, 0x29, 0x95, 0x79,
-	0x98, 0x3d, 0x2d, 0xd2, 0x3c, 0x4e, 0xf3, 0xa4, 0xe4, 0x83, 0x7f, 0xf6, 0x83, 0xfb, 0x0f, 0x7e,
-	0xe3, 0xf7, 0xfb, 0xd0, 0x55, 0x75, 0xee, 0xf1, 0x35, 0x1c, 0xff, 0x29, 0xa8, 0x7b, 0x7e, 0x4e,
-	0xf3, 0xb8, 0xd9, 0x9d, 0x89, 0x35, 0x53, 0x21, 0x2d, 0xeb, 0xc5, 0x05, 0x26, 0xf6, 0x6f, 0x3e,
-	0x36, 0x2e, 0x5b, 0x6f, 0x5c, 0xf6, 0xb5, 0x71, 0xd9, 0xeb, 0xd6, 0xb5, 0xd6, 0x5b, 0xd7, 0xfa,
-	0xdc, 0xba, 0x16, 0x1c, 0x45, 0x72, 0x75, 0xd0, 0x8e, 0xdf, 0x37, 0xe9, 0xf5, 0xa5, 0xe7, 0xec,
-	0x9b, 0xb1, 0xb7, 0x96, 0xfd, 0x70, 0x3b, 0x7f, 0x5c, 0x38, 0xe6, 0xf8, 0x97, 0x3f, 0x01, 0x00,
-	0x00, 0xff, 0xff, 0x66, 0x6b, 0xef, 0x92, 0x0a, 0x02, 0x00, 0x00,
-}
@@ -1,11 +1,13 @@
-// Package gendoc is a truss plugin to generate markdown documentation for a
-// protobuf definition file.
+// Package gendoc is a truss plugin to generate markdown and HTML documentation
+// for a protobuf definition file.
 package gendoc
 
 import (
 	"io"
 	"strings"
 
+	"github.com/pkg/errors"
+
 	"github.com/metaverse/truss/deftree"
 )
 
@@ -22,19 +24,22 @@ func findServiceName(md *deftree.MicroserviceDefinition) string {
 // GenerateDocs accepts a deftree that represents an ast of a group of
 // protofiles and returns map[string]io.Reader that represents a relative
 // filestructure of generated docs
-func GenerateDocs(dt deftree.Deftree) map[string]io.Reader {
-	response := ""
-
+func GenerateDocs(dt deftree.Deftree) (map[string]io.Reader, error) {
 	microDef, ok := dt.(*deftree.MicroserviceDefinition)
-	if ok {
-		response = MdMicroserviceDefinition(microDef, 1)
-	} else {
-		response = "Error, could not cast Deftree to MicroserviceDefinition"
+	if !ok {
+		return nil, errors.Errorf("cannot document a %T, want a *deftree.MicroserviceDefinition", dt)
+	}
+
+	response := MdMicroserviceDefinition(microDef, 1)
+	html, err := HTMLMicroserviceDefinition(microDef)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot render HTML")
 	}
 
 	files := map[string]io.Reader{
-		"docs/docs.md": strings.NewReader(response),
+		"docs/docs.md":   strings.NewReader(response),
+		"docs/docs.html": strings.NewReader(html),
 	}
 
-	return files
+	return files, nil
 }
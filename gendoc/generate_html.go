@@ -0,0 +1,202 @@
+package gendoc
+
+import (
+	"bytes"
+	"html/template"
+	"strings"
+
+	"github.com/metaverse/truss/deftree"
+)
+
+// HTMLMicroserviceDefinition returns a self-contained HTML page documenting m.
+// The page opens with navigation to each service, method, message and enum of
+// m, each of which has an anchor of its own: "service-NAME",
+// "method-SERVICE-NAME", "message-NAME" and "enum-NAME". Field and method types
+// link to the anchor of the message or enum they name, when it is part of m,
+// and each method lists its HTTP bindings with the parameters of each.
+func HTMLMicroserviceDefinition(m *deftree.MicroserviceDefinition) (string, error) {
+	// The anchor of each message and enum, keyed by fully qualified name,
+	// for linking to; those of other packages are not part of m
+	anchors := make(map[string]string)
+	for _, f := range m.Files {
+		for _, msg := range f.Messages {
+			anchors[qualifiedName(m.Name, msg.Name)] = "message-" + msg.Name
+		}
+		for _, enum := range f.Enums {
+			anchors[qualifiedName(m.Name, enum.Name)] = "enum-" + enum.Name
+		}
+	}
+
+	t, err := template.New("docs").Funcs(template.FuncMap{
+		"css":   func() template.CSS { return template.CSS(htmlCSS()) },
+		"upper": strings.ToUpper,
+		"label": fieldLabel,
+		"type": func(name string) template.HTML {
+			return typeLink(m.Name, name, anchors)
+		},
+	}).Parse(htmlTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, m); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// htmlCSS returns doc_css without the <style> element wrapping it, followed by
+// the layout of the navigation.
+func htmlCSS() string {
+	css := strings.TrimSpace(doc_css)
+	css = strings.TrimPrefix(css, `<style type="text/css">`)
+	css = strings.TrimSuffix(css, `</style>`)
+	return css + htmlNavCSS
+}
+
+// qualifiedName returns the fully qualified name of the message or enum of
+// package pkg with the given name, e.g. ".pkg.Name".
+func qualifiedName(pkg, name string) string {
+	if pkg == "" {
+		return "." + name
+	}
+	return "." + pkg + "." + name
+}
+
+// typeLink returns the last element of the dotted type name, linked to its
+// anchor if it has one, or the lowercased name of a scalar type. The name is
+// fully qualified, unless it is that of a message of package pkg.
+func typeLink(pkg, name string, anchors map[string]string) template.HTML {
+	if strings.HasPrefix(name, "TYPE_") {
+		// Scalar types are named e.g. TYPE_STRING
+		return template.HTML(strings.ToLower(strings.TrimPrefix(name, "TYPE_")))
+	}
+	if !strings.HasPrefix(name, ".") {
+		name = qualifiedName(pkg, name)
+	}
+	short := name[strings.LastIndex(name, ".")+1:]
+	text := template.HTMLEscapeString(short)
+	anchor, ok := anchors[name]
+	if !ok {
+		return template.HTML(text)
+	}
+	return template.HTML(`<a href="#` + template.HTMLEscapeString(anchor) + `">` + text + `</a>`)
+}
+
+// fieldLabel returns how a field is labelled within the definition, e.g.
+// "repeated".
+func fieldLabel(f *deftree.MessageField) string {
+	switch {
+	case f.IsMap:
+		return "map"
+	case f.Label == "LABEL_REPEATED":
+		return "repeated"
+	case f.Label == "LABEL_REQUIRED":
+		return "required"
+	}
+	return ""
+}
+
+var htmlNavCSS = `
+nav ul {
+    list-style : none;
+    padding    : 0 0 0 1em;
+}
+
+nav > ul {
+    padding : 0;
+}
+
+.method-type {
+    font-family : monospace;
+}
+`
+
+var htmlTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Name}}</title>
+<style type="text/css">{{css}}</style>
+</head>
+<body>
+<h1>{{.Name}}</h1>
+{{with .Description}}<p>{{.}}</p>{{end}}
+<nav>
+<ul>
+{{- range .Files}}{{range .Services}}
+<li><a href="#service-{{.Name}}">{{.Name}}</a>
+<ul>
+{{- $svc := .Name}}{{range .Methods}}
+<li><a href="#method-{{$svc}}-{{.Name}}">{{.Name}}</a></li>
+{{- end}}
+</ul>
+</li>
+{{- end}}{{end}}
+<li>Messages
+<ul>
+{{- range .Files}}{{range .Messages}}
+<li><a href="#message-{{.Name}}">{{.Name}}</a></li>
+{{- end}}{{end}}
+</ul>
+</li>
+<li>Enums
+<ul>
+{{- range .Files}}{{range .Enums}}
+<li><a href="#enum-{{.Name}}">{{.Name}}</a></li>
+{{- end}}{{end}}
+</ul>
+</li>
+</ul>
+</nav>
+{{range .Files}}
+{{- range .Services}}
+<h2 id="service-{{.Name}}">{{.Name}}</h2>
+{{with .Description}}<p>{{.}}</p>{{end}}
+{{- $svc := .Name}}{{range .Methods}}
+<h3 id="method-{{$svc}}-{{.Name}}">{{.Name}}</h3>
+{{with .Description}}<p>{{.}}</p>{{end}}
+<p class="method-type">{{.Name}}({{type .RequestType.Name}}) returns ({{type .ResponseType.Name}})</p>
+{{- range .HttpBindings}}
+<h4>{{upper .Verb}} <code>{{.Path}}</code></h4>
+{{with .Description}}<p>{{.}}</p>{{end}}
+{{- if .Params}}
+<table>
+<tr><th>Parameter Name</th><th>Location</th><th>Type</th></tr>
+{{- range .Params}}
+<tr><td>{{.Name}}</td><td>{{.Location}}</td><td>{{type .Type}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- end}}
+{{- end}}
+{{- end}}
+{{- end}}
+<h2>Messages</h2>
+{{- range .Files}}{{range .Messages}}
+<h3 id="message-{{.Name}}">{{.Name}}</h3>
+{{with .Description}}<p>{{.}}</p>{{end}}
+{{- if .Fields}}
+<table>
+<tr><th>Name</th><th>Type</th><th>Field Number</th><th>Description</th></tr>
+{{- range .Fields}}
+<tr><td>{{.Name}}</td><td>{{with label .}}{{.}} {{end}}{{type .Type.Name}}</td><td>{{.Number}}</td><td>{{.Description}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- end}}{{end}}
+<h2>Enums</h2>
+{{- range .Files}}{{range .Enums}}
+<h3 id="enum-{{.Name}}">{{.Name}}</h3>
+{{with .Description}}<p>{{.}}</p>{{end}}
+<table>
+<tr><th>Number</th><th>Name</th><th>Description</th></tr>
+{{- range .Values}}
+<tr><td>{{.Number}}</td><td>{{.Name}}</td><td>{{.Description}}</td></tr>
+{{- end}}
+</table>
+{{- end}}{{end}}
+</body>
+</html>
+`
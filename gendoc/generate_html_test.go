@@ -0,0 +1,26 @@
+package gendoc
+
+import (
+	"html/template"
+	"testing"
+)
+
+func TestTypeLink(t *testing.T) {
+	anchors := map[string]string{
+		".example.Item": "message-Item",
+		".example.Kind": "enum-Kind",
+	}
+	for name, want := range map[string]template.HTML{
+		"TYPE_STRING":     "string",
+		"Item":            `<a href="#message-Item">Item</a>`,
+		".example.Item":   `<a href="#message-Item">Item</a>`,
+		".example.Kind":   `<a href="#enum-Kind">Kind</a>`,
+		".other.Item":     "Item",
+		".example.Other":  "Other",
+		".other.sub.Kind": "Kind",
+	} {
+		if got := typeLink("example", name, anchors); got != want {
+			t.Errorf("typeLink(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
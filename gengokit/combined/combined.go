@@ -0,0 +1,121 @@
+// Package combined renders the files of a binary which serves several
+// services, each generated into its own service tree, on one set of
+// listeners.
+package combined
+
+import (
+	"bytes"
+	"go/format"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/metaverse/truss/gengokit"
+	"github.com/metaverse/truss/gengokit/combined/templates"
+	"github.com/metaverse/truss/svcdef"
+)
+
+// Config contains the import paths of the combined binary and of the service
+// trees it serves.
+type Config struct {
+	// GoPackage is the import path of the combined binary's tree
+	GoPackage string
+	PBPackage string
+	// ServicePackages maps the name of each service to the import path of
+	// its generated service tree
+	ServicePackages map[string]string
+	Version         string
+	VersionDate     string
+}
+
+// Data is passed to the combined templates as the executing struct
+type Data struct {
+	// import path of the combined binary's tree
+	ImportPath string
+	// import path for .pb.go files containing service structs
+	PBImportPath string
+	// PackageName is the name of the package containing the service definition
+	PackageName string
+	Services    []*Service
+
+	Version     string
+	VersionDate string
+}
+
+// Service is one of the services served by the combined binary.
+type Service struct {
+	*svcdef.Service
+	// Alias is the lowercased service name, which prefixes the names the
+	// packages of this service are imported as
+	Alias string
+	// ImportPath is the import path of this service's generated tree
+	ImportPath string
+}
+
+// NewData returns the Data for the combined binary serving every service of
+// sd.
+func NewData(sd *svcdef.Svcdef, conf Config) (*Data, error) {
+	rv := Data{
+		ImportPath:   conf.GoPackage,
+		PBImportPath: conf.PBPackage,
+		PackageName:  sd.PkgName,
+		Version:      conf.Version,
+		VersionDate:  conf.VersionDate,
+	}
+	for _, svc := range sd.Services {
+		importPath, ok := conf.ServicePackages[svc.Name]
+		if !ok {
+			return nil, errors.Errorf("no import path for service %q", svc.Name)
+		}
+		rv.Services = append(rv.Services, &Service{
+			Service:    svc,
+			Alias:      strings.ToLower(svc.Name),
+			ImportPath: importPath,
+		})
+	}
+	return &rv, nil
+}
+
+// Generate returns the files of the combined binary, keyed by their path
+// relative to the root of its tree. The binary is named after the package of
+// the definition.
+func Generate(sd *svcdef.Svcdef, conf Config) (map[string]io.Reader, error) {
+	data, err := NewData(sd, conf)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create template data")
+	}
+
+	files := map[string]string{
+		"cmd/" + strings.ToLower(sd.PkgName) + "/main.go": templates.Main,
+		"svc/server/run.go": templates.Run,
+	}
+
+	rv := make(map[string]io.Reader)
+	for path, templ := range files {
+		code, err := gengokit.ApplyTemplate(templ, path, data, gengokit.FuncMap)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot render template: %s", path)
+		}
+		codeBytes, err := ioutil.ReadAll(code)
+		if err != nil {
+			return nil, err
+		}
+		rv[path] = bytes.NewReader(formatCode(codeBytes))
+	}
+
+	return rv, nil
+}
+
+// formatCode takes a piece of go source code and formats it. If the code
+// cannot be formatted it is returned unchanged.
+func formatCode(code []byte) []byte {
+	formatted, err := format.Source(code)
+	if err != nil {
+		log.WithError(err).Warn("Code formatting error, generated service will not build, outputting unformatted code")
+		return code
+	}
+	return formatted
+}
@@ -0,0 +1,111 @@
+package combined
+
+import (
+	"go/format"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/metaverse/truss/svcdef"
+)
+
+var gopath []string
+
+func init() {
+	gopath = filepath.SplitList(os.Getenv("GOPATH"))
+}
+
+func TestGenerate(t *testing.T) {
+	const def = `
+		syntax = "proto3";
+
+		// General package
+		package general;
+
+		import "github.com/metaverse/truss/deftree/googlethirdparty/annotations.proto";
+
+		message RequestMessage {
+			string input = 1;
+		}
+
+		message ResponseMessage {
+			string output = 1;
+		}
+
+		service Public {
+			rpc Get (RequestMessage) returns (ResponseMessage) {
+				option (google.api.http) = {
+					get: "/route"
+				};
+			}
+			rpc Watch (RequestMessage) returns (stream ResponseMessage) {}
+		}
+
+		service Admin {
+			rpc Reset (RequestMessage) returns (ResponseMessage) {}
+		}
+	`
+	sd, err := svcdef.NewFromString(def, gopath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf := Config{
+		GoPackage: "github.com/metaverse/truss/general-service",
+		PBPackage: "github.com/metaverse/truss/general",
+		ServicePackages: map[string]string{
+			"Public": "github.com/metaverse/truss/general-service/public-service",
+			"Admin":  "github.com/metaverse/truss/general-service/admin-service",
+		},
+	}
+
+	files, err := Generate(sd, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	run, ok := files["svc/server/run.go"]
+	if !ok {
+		t.Fatal("svc/server/run.go was not generated")
+	}
+	if _, ok := files["cmd/general/main.go"]; !ok {
+		t.Fatal("cmd/general/main.go was not generated")
+	}
+
+	code, err := ioutil.ReadAll(run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := format.Source(code); err != nil {
+		t.Fatalf("generated code does not format: %v\n%s", err, code)
+	}
+
+	for _, want := range []string{
+		`publicsvc "github.com/metaverse/truss/general-service/public-service/svc"`,
+		`adminhandlers "github.com/metaverse/truss/general-service/admin-service/handlers"`,
+		"pb.RegisterPublicServer(grpcServer(publicCfg.GRPCAddr), publicsvc.MakeGRPCServer(publicEndpoints))",
+		"pb.RegisterAdminServer(grpcServer(adminCfg.GRPCAddr), adminsvc.MakeGRPCServer(adminEndpoints))",
+		"adminDebug := debugMux(adminCfg.DebugAddr)",
+		"httpHandlers[adminCfg.HTTPAddr] = append(httpHandlers[adminCfg.HTTPAddr],",
+		"WatchStream: service.Watch,",
+		"GetEndpoint: publicsvc.MakeGetEndpoint(service),",
+	} {
+		if !strings.Contains(string(code), want) {
+			t.Errorf("generated code does not contain %q\n%s", want, code)
+		}
+	}
+	if n := strings.Count(string(code), "InterruptHandler(errc)"); n != 1 {
+		t.Errorf("generated code starts %d interrupt handlers, want 1\n%s", n, code)
+	}
+}
+
+func TestNewDataMissingPackage(t *testing.T) {
+	sd := &svcdef.Svcdef{
+		Services: []*svcdef.Service{{Name: "Public"}},
+	}
+	if _, err := NewData(sd, Config{}); err == nil {
+		t.Fatal("expected an error for a service without an import path")
+	}
+}
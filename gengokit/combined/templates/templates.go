@@ -0,0 +1,226 @@
+package templates
+
+const Main = `
+// Code generated by truss. DO NOT EDIT.
+// Rerunning truss will overwrite this file.
+// Version: {{.Version}}
+// Version Date: {{.VersionDate}}
+
+package main
+
+import (
+	"flag"
+
+	// These Services
+	"{{.ImportPath -}} /svc/server"
+)
+
+func main() {
+	// Update addresses if they have been overwritten by flags
+	flag.Parse()
+
+	server.Run(server.DefaultConfig)
+}
+`
+
+const Run = `
+// Code generated by truss. DO NOT EDIT.
+// Rerunning truss will overwrite this file.
+// Version: {{.Version}}
+// Version Date: {{.VersionDate}}
+
+package server
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"strings"
+
+	// 3d Party
+	"github.com/gorilla/mux"
+	"google.golang.org/grpc"
+
+	// These Services
+	pb "{{.PBImportPath -}}"
+	{{- range $s := .Services}}
+	{{$s.Alias}}handlers "{{$s.ImportPath -}} /handlers"
+	{{$s.Alias}}svc "{{$s.ImportPath -}} /svc"
+	{{- end}}
+)
+
+// Config contains the addresses shared by every service.
+type Config struct {
+	HTTPAddr  string
+	DebugAddr string
+	GRPCAddr  string
+}
+
+var DefaultConfig Config
+
+func init() {
+	flag.StringVar(&DefaultConfig.DebugAddr, "debug.addr", ":5060", "Debug and metrics listen address")
+	flag.StringVar(&DefaultConfig.HTTPAddr, "http.addr", ":5050", "HTTP listen address")
+	flag.StringVar(&DefaultConfig.GRPCAddr, "grpc.addr", ":5040", "gRPC (HTTP) listen address")
+
+	// Use environment variables, if set. Flags have priority over Env vars.
+	if addr := os.Getenv("DEBUG_ADDR"); addr != "" {
+		DefaultConfig.DebugAddr = addr
+	}
+	if port := os.Getenv("PORT"); port != "" {
+		DefaultConfig.HTTPAddr = fmt.Sprintf(":%s", port)
+	}
+	if addr := os.Getenv("HTTP_ADDR"); addr != "" {
+		DefaultConfig.HTTPAddr = addr
+	}
+	if addr := os.Getenv("GRPC_ADDR"); addr != "" {
+		DefaultConfig.GRPCAddr = addr
+	}
+}
+
+{{range $s := .Services}}
+func New{{$s.Name}}Endpoints(service pb.{{$s.Name}}Server) {{$s.Alias}}svc.Endpoints {
+	// Business domain.
+
+	// Wrap Service with middlewares. See handlers/middlewares.go
+	service = {{$s.Alias}}handlers.WrapService(service)
+
+	// Endpoint domain.
+	endpoints := {{$s.Alias}}svc.Endpoints{
+	{{range $i := $s.Methods -}}
+	{{if or $i.ClientStreaming $i.ServerStreaming -}}
+		{{$i.Name}}Stream:    service.{{$i.Name}},
+	{{else -}}
+		{{$i.Name}}Endpoint:    {{$s.Alias}}svc.Make{{$i.Name}}Endpoint(service),
+	{{end -}}
+	{{end}}
+	}
+
+	// Wrap selected Endpoints with middlewares. See handlers/middlewares.go
+	endpoints = {{$s.Alias}}handlers.WrapEndpoints(endpoints)
+
+	return endpoints
+}
+{{end}}
+
+// Run starts a new http server, gRPC server, and a debug server serving every
+// service with the passed config. The config of each service is derived from
+// cfg and passed through the SetConfig hook of that service, which may change
+// the addresses the service is served on. Services served on the same address
+// share its listener.
+func Run(cfg Config) {
+	// Mechanical domain.
+	errc := make(chan error)
+
+	// Interrupt handler. The hook of the first service handles interrupts
+	// for the whole binary, so they are reported once.
+	go {{(index .Services 0).Alias}}handlers.InterruptHandler(errc)
+
+	httpAddrs, httpHandlers := []string{}, make(map[string][]http.Handler)
+	// The debug listeners serving the API explorers of the services of each
+	// HTTP listener, whose requests it allows
+	explorerAddrs := make(map[string][]string)
+	grpcAddrs, grpcServers := []string{}, make(map[string]*grpc.Server)
+	debugAddrs, debugMuxes := []string{}, make(map[string]*http.ServeMux)
+	debugMux := func(addr string) *http.ServeMux {
+		if m, ok := debugMuxes[addr]; ok {
+			return m
+		}
+		m := http.NewServeMux()
+		m.Handle("/debug/pprof/", http.HandlerFunc(pprof.Index))
+		m.Handle("/debug/pprof/cmdline", http.HandlerFunc(pprof.Cmdline))
+		m.Handle("/debug/pprof/profile", http.HandlerFunc(pprof.Profile))
+		m.Handle("/debug/pprof/symbol", http.HandlerFunc(pprof.Symbol))
+		m.Handle("/debug/pprof/trace", http.HandlerFunc(pprof.Trace))
+		debugAddrs, debugMuxes[addr] = append(debugAddrs, addr), m
+		return m
+	}
+	grpcServer := func(addr string) *grpc.Server {
+		if s, ok := grpcServers[addr]; ok {
+			return s
+		}
+		s := grpc.NewServer()
+		grpcAddrs, grpcServers[addr] = append(grpcAddrs, addr), s
+		return s
+	}
+
+	{{range $s := .Services}}
+	{{$s.Alias}}Cfg := {{$s.Alias}}handlers.SetConfig({{$s.Alias}}svc.Config{
+		HTTPAddr:  cfg.HTTPAddr,
+		DebugAddr: cfg.DebugAddr,
+		GRPCAddr:  cfg.GRPCAddr,
+	})
+	if {{$s.Alias}}Cfg.GenericHTTPResponseEncoder == nil {
+		{{$s.Alias}}Cfg.GenericHTTPResponseEncoder = {{$s.Alias}}svc.EncodeHTTPGenericResponse
+	}
+	{{$s.Alias}}Endpoints := New{{$s.Name}}Endpoints({{$s.Alias}}handlers.NewService())
+
+	// The debug handlers of {{$s.Name}} are served under /debug/{{$s.Alias}},
+	// its API explorer sending requests to its HTTP listener
+	explorerAddrs[{{$s.Alias}}Cfg.HTTPAddr] = append(explorerAddrs[{{$s.Alias}}Cfg.HTTPAddr], {{$s.Alias}}Cfg.DebugAddr)
+	{{$s.Alias}}svc.APIAddr = {{$s.Alias}}Cfg.HTTPAddr
+	{{$s.Alias}}Debug := debugMux({{$s.Alias}}Cfg.DebugAddr)
+	for pattern, h := range {{$s.Alias}}svc.DebugHandlers {
+		{{$s.Alias}}Debug.Handle("/debug/{{$s.Alias}}"+strings.TrimPrefix(pattern, "/debug"), h)
+	}
+	if _, ok := httpHandlers[{{$s.Alias}}Cfg.HTTPAddr]; !ok {
+		httpAddrs = append(httpAddrs, {{$s.Alias}}Cfg.HTTPAddr)
+	}
+	httpHandlers[{{$s.Alias}}Cfg.HTTPAddr] = append(httpHandlers[{{$s.Alias}}Cfg.HTTPAddr],
+		{{$s.Alias}}svc.MakeHTTPHandler({{$s.Alias}}Endpoints, {{$s.Alias}}Cfg.GenericHTTPResponseEncoder))
+	pb.Register{{$s.Name}}Server(grpcServer({{$s.Alias}}Cfg.GRPCAddr), {{$s.Alias}}svc.MakeGRPCServer({{$s.Alias}}Endpoints))
+	{{end}}
+
+	// Debug listeners.
+	for _, addr := range debugAddrs {
+		go func(addr string, m *http.ServeMux) {
+			log.Println("transport", "debug", "addr", addr)
+			errc <- http.ListenAndServe(addr, m)
+		}(addr, debugMuxes[addr])
+	}
+
+	// HTTP transport.
+	for _, addr := range httpAddrs {
+		go func(addr string, h http.Handler) {
+			log.Println("transport", "HTTP", "addr", addr)
+			errc <- http.ListenAndServe(addr, h)
+		}(addr, {{(index .Services 0).Alias}}svc.AllowExplorer(routeHTTP(httpHandlers[addr]...), explorerAddrs[addr]...))
+	}
+
+	// gRPC transport.
+	for _, addr := range grpcAddrs {
+		go func(addr string, s *grpc.Server) {
+			log.Println("transport", "gRPC", "addr", addr)
+			ln, err := net.Listen("tcp", addr)
+			if err != nil {
+				errc <- err
+				return
+			}
+			errc <- s.Serve(ln)
+		}(addr, grpcServers[addr])
+	}
+
+	// Run!
+	log.Println("exit", <-errc)
+}
+
+// routeHTTP returns an http.Handler which serves each request with the first
+// of handlers which has a route matching that request.
+func routeHTTP(handlers ...http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, h := range handlers {
+			var match mux.RouteMatch
+			if m, ok := h.(*mux.Router); ok && !m.Match(r, &match) {
+				continue
+			}
+			h.ServeHTTP(w, r)
+			return
+		}
+		http.NotFound(w, r)
+	})
+}
+`
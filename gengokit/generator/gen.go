@@ -3,13 +3,20 @@ package generator
 
 import (
 	"bytes"
+	"go/ast"
 	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
 	"io"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/pkg/errors"
+	"golang.org/x/tools/go/ast/astutil"
 
 	"github.com/metaverse/truss/gengokit"
 	"github.com/metaverse/truss/gengokit/handlers"
@@ -27,15 +34,26 @@ func GenerateGokit(sd *svcdef.Svcdef, conf gengokit.Config) (map[string]io.Reade
 		return nil, errors.Wrap(err, "cannot create template data")
 	}
 
+	overlays, err := overlayTemplates(conf.TemplateDir)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read template overlays")
+	}
+	templPaths := templFiles.AssetNames()
+	for templPath := range overlays {
+		if _, err := templFiles.AssetInfo(templPath); err != nil {
+			templPaths = append(templPaths, templPath)
+		}
+	}
+
 	codeGenFiles := make(map[string]io.Reader)
 
 	// Remove the suffix "-service" since it's added back in by templatePathToActual
-	svcname := strings.ToLower(sd.Service.Name)
-	for _, templPath := range templFiles.AssetNames() {
+	svcname := strings.ToLower(data.Service.Name)
+	for _, templPath := range templPaths {
 		// Re-derive the actual path for this file based on the service output
 		// path provided by the truss main.go
 		actualPath := templatePathToActual(templPath, svcname)
-		file, err := generateResponseFile(templPath, data, conf.PreviousFiles[actualPath])
+		file, err := generateResponseFile(templPath, overlays[templPath], data, conf.PreviousFiles[actualPath])
 		if err != nil {
 			return nil, errors.Wrap(err, "cannot render template")
 		}
@@ -46,37 +64,76 @@ func GenerateGokit(sd *svcdef.Svcdef, conf gengokit.Config) (map[string]io.Reade
 	return codeGenFiles, nil
 }
 
+// overlayTemplates returns the templates within dir, keyed by their slash
+// separated paths relative to dir, which are those of the built-in templates
+// they override. It returns none if dir is empty.
+func overlayTemplates(dir string) (map[string][]byte, error) {
+	overlays := make(map[string][]byte)
+	if dir == "" {
+		return overlays, nil
+	}
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		// Skip hidden files and directories, and editor swap files
+		if path != dir && strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() || strings.HasSuffix(path, ".swp") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		templ, err := ioutil.ReadFile(path)
+		if err != nil {
+			return errors.Wrapf(err, "cannot read template %v", path)
+		}
+		overlays[filepath.ToSlash(rel)] = templ
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return overlays, nil
+}
+
 // generateResponseFile contains logic to choose how to render a template file
 // based on path and if that file was generated previously. It accepts a
-// template path to render, a templateExecutor to apply to the template, and a
-// map of paths to files for the previous generation. It returns a
+// template path to render, the overlay template replacing the built-in
+// template at that path if any, a templateExecutor to apply to the template,
+// and a map of paths to files for the previous generation. It returns a
 // io.Reader representing the generated file.
-func generateResponseFile(templFP string, data *gengokit.Data, prevFile io.Reader) (io.Reader, error) {
+//
+// The handlers, hooks and middlewares files previously generated code is
+// merged into are rendered from an overlay only when they do not exist yet.
+func generateResponseFile(templFP string, overlay []byte, data *gengokit.Data, prevFile io.Reader) (io.Reader, error) {
 	var genCode io.Reader
 	var err error
 
 	// Get the actual path to the file rather than the template file path
 	actualFP := templatePathToActual(templFP, data.Service.Name)
 
-	switch templFP {
-	case handlers.ServerHandlerPath:
-		h, err := handlers.New(data.Service, prevFile)
+	merge, merged := mergers[templFP]
+	switch {
+	case overlay != nil && (prevFile == nil || !merged):
+		if genCode, err = data.ApplyTemplate(string(overlay), templFP); err != nil {
+			return nil, errors.Wrapf(err, "cannot render overlay template: %s", templFP)
+		}
+	case merged:
+		r, err := merge(data.Service, prevFile)
 		if err != nil {
-			return nil, errors.Wrapf(err, "cannot parse previous handler: %q", actualFP)
+			return nil, errors.Wrapf(err, "cannot parse previous file: %q", actualFP)
 		}
 
-		if genCode, err = h.Render(templFP, data); err != nil {
-			return nil, errors.Wrapf(err, "cannot render template: %s", templFP)
-		}
-	case handlers.HookPath:
-		hook := handlers.NewHook(prevFile)
-		if genCode, err = hook.Render(templFP, data); err != nil {
-			return nil, errors.Wrapf(err, "cannot render template: %s", templFP)
-		}
-	case handlers.MiddlewaresPath:
-		m := handlers.NewMiddlewares()
-		m.Load(prevFile)
-		if genCode, err = m.Render(templFP, data); err != nil {
+		if genCode, err = r.Render(templFP, data); err != nil {
 			return nil, errors.Wrapf(err, "cannot render template: %s", templFP)
 		}
 	default:
@@ -90,6 +147,13 @@ func generateResponseFile(templFP string, data *gengokit.Data, prevFile io.Reade
 		return nil, err
 	}
 
+	// Overlays may add files other than Go code
+	if !strings.HasSuffix(actualFP, ".go") {
+		return bytes.NewReader(codeBytes), nil
+	}
+
+	codeBytes = importPackages(codeBytes, data.Imports)
+
 	// ignore error as we want to write the code either way to inspect after
 	// writing to disk
 	formattedCode := formatCode(codeBytes)
@@ -97,6 +161,21 @@ func generateResponseFile(templFP string, data *gengokit.Data, prevFile io.Reade
 	return bytes.NewReader(formattedCode), nil
 }
 
+// mergers hold, keyed by template path, the constructor of the Renderable of
+// each file which previously generated code is merged into. prev is nil if
+// the file does not exist yet.
+var mergers = map[string]func(svc *svcdef.Service, prev io.Reader) (gengokit.Renderable, error){
+	handlers.ServerHandlerPath: handlers.New,
+	handlers.HookPath: func(_ *svcdef.Service, prev io.Reader) (gengokit.Renderable, error) {
+		return handlers.NewHook(prev), nil
+	},
+	handlers.MiddlewaresPath: func(_ *svcdef.Service, prev io.Reader) (gengokit.Renderable, error) {
+		m := handlers.NewMiddlewares()
+		m.Load(prev)
+		return m, nil
+	},
+}
+
 // templatePathToActual accepts a templateFilePath and the svcName of the
 // service and returns what the relative file path of what should be written to
 // disk
@@ -120,6 +199,52 @@ func applyTemplateFromPath(templFP string, data *gengokit.Data) (io.Reader, erro
 	return data.ApplyTemplate(string(templBytes), templFP)
 }
 
+// importPackages returns code importing those of pkgs which code refers to,
+// and not importing those it does not. Templates and previously generated
+// handlers refer to the types of such packages without importing them, as
+// which packages are needed depends on the definition. If code cannot be
+// parsed it is returned unchanged.
+func importPackages(code []byte, pkgs []*svcdef.GoPackage) []byte {
+	if len(pkgs) == 0 {
+		return code
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", code, parser.ParseComments)
+	if err != nil {
+		return code
+	}
+
+	// The parser resolves identifiers declared within the file, such as
+	// variables, so only those left unresolved may refer to a package
+	used := make(map[string]bool)
+	ast.Inspect(f, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok && id.Obj == nil {
+				used[id.Name] = true
+			}
+		}
+		return true
+	})
+
+	changed := false
+	for _, pkg := range pkgs {
+		if used[pkg.Alias] {
+			changed = astutil.AddNamedImport(fset, f, pkg.Alias, pkg.ImportPath) || changed
+		} else {
+			changed = astutil.DeleteNamedImport(fset, f, pkg.Alias, pkg.ImportPath) || changed
+		}
+	}
+	if !changed {
+		return code
+	}
+
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, f); err != nil {
+		return code
+	}
+	return buf.Bytes()
+}
+
 // formatCode takes a string representing golang code and attempts to return a
 // formated copy of that code.  If formatting fails, a warning is logged and
 // the original code is returned.
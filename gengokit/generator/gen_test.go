@@ -42,6 +42,37 @@ func TestTemplatePathToActual(t *testing.T) {
 	}
 }
 
+func TestImportPackages(t *testing.T) {
+	code := []byte(`package handlers
+
+import (
+	"context"
+)
+
+func (s svc) Get(ctx context.Context, in *pb.Req) (*typespb.Resp, error) {
+	var otherpb struct{ Name string }
+	_ = otherpb.Name
+	return nil, nil
+}
+`)
+	pkgs := []*svcdef.GoPackage{
+		{ImportPath: "example.com/types", Alias: "typespb"},
+		{ImportPath: "example.com/other", Alias: "otherpb"},
+		{ImportPath: "example.com/unused", Alias: "unusedpb"},
+	}
+
+	got := string(importPackages(code, pkgs))
+	if !strings.Contains(got, `typespb "example.com/types"`) {
+		t.Errorf("package typespb is not imported:\n%s", got)
+	}
+	if strings.Contains(got, `"example.com/other"`) {
+		t.Errorf("package otherpb is imported for a local variable of that name:\n%s", got)
+	}
+	if strings.Contains(got, `"example.com/unused"`) {
+		t.Errorf("unused package unusedpb is imported:\n%s", got)
+	}
+}
+
 func TestApplyTemplateFromPath(t *testing.T) {
 	const def = `
 		syntax = "proto3";
@@ -103,6 +134,97 @@ func TestApplyTemplateFromPath(t *testing.T) {
 	}
 }
 
+func TestGenerateGokitOverlays(t *testing.T) {
+	const def = `
+		syntax = "proto3";
+
+		package general;
+
+		message Msg {
+			string a = 1;
+		}
+
+		service ProtoService {
+			rpc ProtoMethod (Msg) returns (Msg) {}
+		}
+	`
+	sd, err := svcdef.NewFromString(def, gopath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "truss-overlays-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	overlays := map[string]string{
+		// Overrides a built-in template
+		"svc/server/run.gotemplate": "package server\n\n// Run{{.Service.Name}} is overridden\nfunc Run{{.Service.Name}}() {}\n",
+		// Adds a file, following the NAME substitution
+		"cmd/NAME/README.mdtemplate": "# {{ToLower .Service.Name}} of team {{.Options.team}}\n",
+		// Replaces handlers.go only when it does not exist yet
+		"handlers/handlers.gotemplate": "package handlers\n\n// overlay\n",
+		".hidden/skipped.gotemplate":   "{{",
+	}
+	for name, templ := range overlays {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(templ), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	conf := gengokit.Config{
+		GoPackage:   "github.com/metaverse/truss/gengokit/general-service",
+		PBPackage:   "github.com/metaverse/truss/gengokit/general-service",
+		Options:     map[string]string{"team": "core"},
+		TemplateDir: dir,
+	}
+	files, err := GenerateGokit(sd, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	read := func(name string) string {
+		f, ok := files[name]
+		if !ok {
+			t.Fatalf("%s was not generated", name)
+		}
+		b, err := ioutil.ReadAll(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	if run := read("svc/server/run.go"); !strings.Contains(run, "RunProtoService is overridden") {
+		t.Errorf("run.go was not rendered from the overlay:\n%s", run)
+	}
+	if got, want := read("cmd/protoservice/README.md"), "# protoservice of team core\n"; got != want {
+		t.Errorf("README.md = %q, want %q", got, want)
+	}
+	if h := read("handlers/handlers.go"); !strings.Contains(h, "// overlay") {
+		t.Errorf("new handlers.go was not rendered from the overlay:\n%s", h)
+	}
+	if e := read("svc/endpoints.go"); !strings.Contains(e, "package svc") {
+		t.Errorf("endpoints.go was not rendered from the built-in template:\n%s", e)
+	}
+
+	// Methods are merged into an existing handlers.go as usual
+	conf.PreviousFiles = map[string]io.Reader{
+		"handlers/handlers.go": strings.NewReader("package handlers\n\n// mine\n"),
+	}
+	files, err = GenerateGokit(sd, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := read("handlers/handlers.go"); !strings.Contains(h, "// mine") || !strings.Contains(h, "ProtoMethod") {
+		t.Errorf("methods were not merged into the previous handlers.go:\n%s", h)
+	}
+}
+
 func svcMethodsNames(methods []*svcdef.ServiceMethod) []string {
 	var mNames []string
 	for _, m := range methods {
@@ -270,6 +392,72 @@ func TestAllTemplates(t *testing.T) {
 	}
 }
 
+func TestAllTemplatesStreaming(t *testing.T) {
+	const def = `
+		syntax = "proto3";
+
+		// General package
+		package general;
+
+		import "github.com/metaverse/truss/deftree/googlethirdparty/annotations.proto";
+
+		message RequestMessage {
+			string input = 1;
+		}
+
+		message ResponseMessage {
+			string output = 1;
+		}
+
+		service ProtoService {
+			rpc ProtoMethod (RequestMessage) returns (ResponseMessage) {
+				option (google.api.http) = {
+					get: "/route"
+				};
+			}
+			rpc ServerStream (RequestMessage) returns (stream ResponseMessage) {
+				option (google.api.http) = {
+					get: "/stream"
+				};
+			}
+			rpc ClientStream (stream RequestMessage) returns (ResponseMessage) {}
+			rpc BidiStream (stream RequestMessage) returns (stream ResponseMessage) {}
+		}
+	`
+
+	sd, err := svcdef.NewFromString(def, gopath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf := gengokit.Config{
+		GoPackage: "github.com/metaverse/truss/gengokit",
+		PBPackage: "github.com/metaverse/truss/gengokit/general-service",
+	}
+
+	data, err := gengokit.NewData(sd, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, templFP := range templateFileAssets.AssetNames() {
+		firstCode, err := testGenerateResponseFile(templFP, data, nil)
+		if err != nil {
+			t.Fatalf("%s failed to format on first generation\n\nERROR:\n\n%s\n\nCODE:\n\n%s", templFP, err, firstCode)
+		}
+
+		secondCode, err := testGenerateResponseFile(templFP, data, strings.NewReader(firstCode))
+		if err != nil {
+			t.Fatalf("%s failed to format on second identical generation\n\nERROR: %s\nCODE:\n\n%s",
+				templFP, err, secondCode)
+		}
+
+		if firstCode != secondCode {
+			t.Fatal("Generated code differs after regeneration with same definition\n" + diff(firstCode, secondCode))
+		}
+	}
+}
+
 func diff(a, b string) string {
 	return gentesthelper.DiffStrings(
 		a,
@@ -282,7 +470,7 @@ func diff(a, b string) string {
 // addition this function will return an error if the code fails to format,
 // while generateResponseFile will not.
 func testGenerateResponseFile(templPath string, data *gengokit.Data, prev io.Reader) (string, error) {
-	code, err := generateResponseFile(templPath, data, prev)
+	code, err := generateResponseFile(templPath, nil, data, prev)
 	if err != nil {
 		return "", err
 	}
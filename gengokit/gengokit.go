@@ -3,6 +3,7 @@ package gengokit
 import (
 	"bytes"
 	"io"
+	"strconv"
 	"strings"
 	"text/template"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/metaverse/truss/gengokit/httptransport"
+	"github.com/metaverse/truss/genopenapi"
 	"github.com/metaverse/truss/svcdef"
 )
 
@@ -18,10 +20,20 @@ type Renderable interface {
 }
 
 type Config struct {
-	GoPackage   string
-	PBPackage   string
+	GoPackage string
+	PBPackage string
+	// Service is the name of the service to generate. It may be left empty
+	// if the definition contains only one service.
+	Service     string
 	Version     string
 	VersionDate string
+	// Options are arbitrary values made available to templates, such as
+	// those of the options of a truss.yaml file
+	Options map[string]string
+	// TemplateDir is a directory of templates overriding or adding to the
+	// built-in templates by path, e.g. svc/server/run.gotemplate; it may be
+	// left empty
+	TemplateDir string
 
 	PreviousFiles map[string]io.Reader
 }
@@ -29,8 +41,24 @@ type Config struct {
 // FuncMap contains a series of utility functions to be passed into
 // templates and used within those templates.
 var FuncMap = template.FuncMap{
-	"ToLower": strings.ToLower,
-	"GoName":  generatego.CamelCase,
+	"ToLower":  strings.ToLower,
+	"GoName":   generatego.CamelCase,
+	"PBType":   PBType,
+	"GoString": GoString,
+}
+
+// GoString returns s as a Go string literal, raw if s has no backquote.
+func GoString(s string) string {
+	if strings.Contains(s, "`") {
+		return strconv.Quote(s)
+	}
+	return "`" + s + "`"
+}
+
+// PBType returns the name of the Go type of the message or enum t represents,
+// qualified by the name its package is imported as, e.g. "pb.Msg".
+func PBType(t *svcdef.FieldType) string {
+	return t.Package().Qualifier() + "." + generatego.CamelCase(t.Name)
 }
 
 // Data is passed to templates as the executing struct; its fields
@@ -44,27 +72,93 @@ type Data struct {
 	PackageName string
 	// GRPC/Protobuff service, with all parameters and return values accessible
 	Service *svcdef.Service
+	// Go packages of messages and enums generated outside of PBImportPath;
+	// generated files import those they refer to
+	Imports []*svcdef.GoPackage
 	// A helper struct for generating http transport functionality.
 	HTTPHelper *httptransport.Helper
 	FuncMap    template.FuncMap
+	// Options of the Config, for use by templates
+	Options map[string]string
 
 	Version     string
 	VersionDate string
+
+	// sd is the definition Service is selected from
+	sd *svcdef.Svcdef
 }
 
 func NewData(sd *svcdef.Svcdef, conf Config) (*Data, error) {
+	svc, err := selectService(sd, conf.Service)
+	if err != nil {
+		return nil, err
+	}
 	return &Data{
 		ImportPath:   conf.GoPackage,
 		PBImportPath: conf.PBPackage,
 		PackageName:  sd.PkgName,
-		Service:      sd.Service,
-		HTTPHelper:   httptransport.NewHelper(sd.Service),
+		Service:      svc,
+		Imports:      sd.Imports,
+		HTTPHelper:   httptransport.NewHelper(svc),
 		FuncMap:      FuncMap,
+		Options:      conf.Options,
 		Version:      conf.Version,
 		VersionDate:  conf.VersionDate,
+		sd:           sd,
 	}, nil
 }
 
+// OpenAPI returns the OpenAPI 3 document of the HTTP API of the service, as
+// JSON. It is generated for the templates which use it only.
+func (e *Data) OpenAPI() (string, error) {
+	api, err := genopenapi.OpenAPI3(e.sd, e.Service.Name, genopenapi.Info{})
+	if err != nil {
+		return "", errors.Wrap(err, "cannot generate OpenAPI document")
+	}
+	return string(api), nil
+}
+
+// UnaryMethods returns the methods of the service which stream neither their
+// requests nor their responses.
+func (e *Data) UnaryMethods() []*svcdef.ServiceMethod {
+	var rv []*svcdef.ServiceMethod
+	for _, m := range e.Service.Methods {
+		if !m.ClientStreaming && !m.ServerStreaming {
+			rv = append(rv, m)
+		}
+	}
+	return rv
+}
+
+// StreamingMethods returns the methods of the service which stream their
+// requests, their responses, or both.
+func (e *Data) StreamingMethods() []*svcdef.ServiceMethod {
+	var rv []*svcdef.ServiceMethod
+	for _, m := range e.Service.Methods {
+		if m.ClientStreaming || m.ServerStreaming {
+			rv = append(rv, m)
+		}
+	}
+	return rv
+}
+
+// selectService returns the service of sd named name, or the only service of
+// sd if name is empty.
+func selectService(sd *svcdef.Svcdef, name string) (*svcdef.Service, error) {
+	if name == "" {
+		if len(sd.Services) != 1 {
+			return nil, errors.Errorf("definition contains %d services; the service to generate must be named", len(sd.Services))
+		}
+		return sd.Services[0], nil
+	}
+	for _, svc := range sd.Services {
+		if svc.Name == name {
+			return svc, nil
+		}
+	}
+	return nil, errors.Errorf("definition contains no service named %q", name)
+}
+
 // ApplyTemplate applies the passed template with the Data
 func (e *Data) ApplyTemplate(templ string, templName string) (io.Reader, error) {
 	return ApplyTemplate(templ, templName, e, e.FuncMap)
@@ -3,6 +3,8 @@ package gengokit
 import (
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/metaverse/truss/svcdef"
@@ -52,6 +54,7 @@ func TestNewData(t *testing.T) {
 	conf := Config{
 		GoPackage: "github.com/metaverse/truss/gengokit/general-service",
 		PBPackage: "github.com/metaverse/truss/gengokit/general-service",
+		Options:   map[string]string{"team": "core"},
 	}
 
 	te, err := NewData(sd, conf)
@@ -62,4 +65,78 @@ func TestNewData(t *testing.T) {
 	if got, want := te.PackageName, sd.PkgName; got != want {
 		t.Fatalf("\n`%v` was PackageName\n`%v` was wanted", got, want)
 	}
+	if got, want := te.Options["team"], "core"; got != want {
+		t.Fatalf("\n`%v` was Options[\"team\"]\n`%v` was wanted", got, want)
+	}
+	api, err := te.OpenAPI()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(api, `"/route"`) {
+		t.Fatalf("OpenAPI document has no operation of /route:\n%s", api)
+	}
+}
+
+func TestGoString(t *testing.T) {
+	for _, s := range []string{"", "plain", "line\nbreak", "back`quote", "\"quoted\""} {
+		lit := GoString(s)
+		got, err := strconv.Unquote(lit)
+		if err != nil {
+			t.Fatalf("GoString(%q) = %s, not a Go string literal: %v", s, lit, err)
+		}
+		if got != s {
+			t.Errorf("GoString(%q) = %s, which is %q", s, lit, got)
+		}
+	}
+}
+
+func TestNewDataSelectsService(t *testing.T) {
+	sd := &svcdef.Svcdef{
+		Services: []*svcdef.Service{
+			{Name: "Admin"},
+			{Name: "Public"},
+		},
+	}
+
+	if _, err := NewData(sd, Config{}); err == nil {
+		t.Fatal("expected an error when the service of a multi-service definition is not named")
+	}
+	if _, err := NewData(sd, Config{Service: "Missing"}); err == nil {
+		t.Fatal("expected an error for a service not in the definition")
+	}
+
+	te, err := NewData(sd, Config{Service: "Public"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := te.Service.Name, "Public"; got != want {
+		t.Fatalf("\n`%v` was Service.Name\n`%v` was wanted", got, want)
+	}
+}
+
+func TestUnaryAndStreamingMethods(t *testing.T) {
+	te := &Data{
+		Service: &svcdef.Service{
+			Methods: []*svcdef.ServiceMethod{
+				{Name: "Unary"},
+				{Name: "Server", ServerStreaming: true},
+				{Name: "Client", ClientStreaming: true},
+				{Name: "Bidi", ClientStreaming: true, ServerStreaming: true},
+			},
+		},
+	}
+
+	var unary, streaming []string
+	for _, m := range te.UnaryMethods() {
+		unary = append(unary, m.Name)
+	}
+	for _, m := range te.StreamingMethods() {
+		streaming = append(streaming, m.Name)
+	}
+	if got, want := strings.Join(unary, ","), "Unary"; got != want {
+		t.Errorf("UnaryMethods() = %s, want %s", got, want)
+	}
+	if got, want := strings.Join(streaming, ","), "Server,Client,Bidi"; got != want {
+		t.Errorf("StreamingMethods() = %s, want %s", got, want)
+	}
 }
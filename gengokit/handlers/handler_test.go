@@ -67,8 +67,8 @@ func TestServerMethsTempl(t *testing.T) {
 	}
 
 	var he handlerData
-	he.Methods = sd.Service.Methods
-	he.ServiceName = sd.Service.Name
+	he.Methods = sd.Services[0].Methods
+	he.ServiceName = sd.Services[0].Name
 
 	gen, err := applyServerMethsTempl(he)
 	if err != nil {
@@ -156,6 +156,81 @@ func TestApplyServerTempl(t *testing.T) {
 	}
 }
 
+func TestApplyServerTemplStreaming(t *testing.T) {
+	const def = `
+		syntax = "proto3";
+
+		// General package
+		package general;
+
+		message RequestMessage {
+			string input = 1;
+		}
+
+		message ResponseMessage {
+			string output = 1;
+		}
+
+		service Proto {
+			rpc ServerStream (RequestMessage) returns (stream ResponseMessage) {}
+			rpc ClientStream (stream RequestMessage) returns (ResponseMessage) {}
+			rpc BidiStream (stream RequestMessage) returns (stream ResponseMessage) {}
+		}
+	`
+	conf := gengokit.Config{
+		GoPackage: "github.com/metaverse/truss/gengokit/general-service",
+		PBPackage: "github.com/metaverse/truss/gengokit/general-service",
+	}
+	sd, err := svcdef.NewFromString(def, gopath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	te, err := gengokit.NewData(sd, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gen, err := applyServerTempl(te)
+	if err != nil {
+		t.Fatal(err)
+	}
+	genBytes, err := ioutil.ReadAll(gen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `
+		package handlers
+
+		import (
+			pb "github.com/metaverse/truss/gengokit/general-service"
+		)
+
+		// NewService returns a naïve, stateless implementation of Service.
+		func NewService() pb.ProtoServer {
+			return protoService{}
+		}
+
+		type protoService struct{}
+
+		func (s protoService) ServerStream(in *pb.RequestMessage, stream pb.Proto_ServerStreamServer) error {
+			return nil
+		}
+
+		func (s protoService) ClientStream(stream pb.Proto_ClientStreamServer) error {
+			var resp pb.ResponseMessage
+			return stream.SendAndClose(&resp)
+		}
+
+		func (s protoService) BidiStream(stream pb.Proto_BidiStreamServer) error {
+			return nil
+		}
+	`
+	a, b, di := helper.DiffGoCode(string(genBytes), expected)
+	if strings.Compare(a, b) != 0 {
+		t.Fatalf("Server template output different than expected\n %s", di)
+	}
+}
+
 func TestRecvTypeToString(t *testing.T) {
 	values := []string{
 		`package p; func NoRecv() {}`, "",
@@ -210,7 +285,7 @@ func TestIsValidFunc(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	m := newMethodMap(sd.Service.Methods)
+	m := newMethodMap(sd.Services[0].Methods)
 	const validUnexported = `package p;
 	func init() {}`
 
@@ -223,7 +298,7 @@ func TestIsValidFunc(t *testing.T) {
 	const invalidFuncName = `package p;
 	func (generalService) FOOBAR(context.Context, pb.RequestMessage) (pb.ResponseMessage, error) {}`
 
-	svcName := strings.ToLower(sd.Service.Name)
+	svcName := strings.ToLower(sd.Services[0].Name)
 
 	var in string
 	in = validUnexported
@@ -293,7 +368,7 @@ func TestPruneDecls(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	m := newMethodMap(sd.Service.Methods)
+	m := newMethodMap(sd.Services[0].Methods)
 
 	prev := `
 		package handlers
@@ -331,7 +406,7 @@ func TestPruneDecls(t *testing.T) {
 	lenDeclsBefore := len(f.Decls)
 	lenMMapBefore := len(m)
 
-	newDecls := m.pruneDecls(f.Decls, strings.ToLower(sd.Service.Name))
+	newDecls := m.pruneDecls(f.Decls, strings.ToLower(sd.Services[0].Name))
 
 	lenDeclsAfter := len(newDecls)
 	lenMMapAfter := len(m)
@@ -356,13 +431,29 @@ func TestUpdatePBFieldType(t *testing.T) {
 		if err != nil {
 			t.Error(err)
 		}
-		updatePBFieldType(exp, values[i+1])
+		updatePBFieldType(exp, &svcdef.FieldType{Name: values[i+1]})
 		got := exprString(exp)
 		want := values[i+2]
 		if got != want {
 			t.Errorf("Func Recv got: \"%s\", want: \"%s\": for func: %s", got, want, values[i])
 		}
 	}
+
+	// Types of other Go packages are qualified by the alias of their package
+	exp, err := parser.ParseExpr(`*pb.Old`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	updatePBFieldType(exp, &svcdef.FieldType{
+		Name: "New",
+		Message: &svcdef.Message{
+			Name:    "New",
+			Package: &svcdef.GoPackage{ImportPath: "example.com/types", Alias: "typespb"},
+		},
+	})
+	if got, want := exprString(exp), "*typespb.New"; got != want {
+		t.Errorf("Func Recv got: %q, want: %q", got, want)
+	}
 }
 
 func TestUpdateMethods(t *testing.T) {
@@ -424,7 +515,7 @@ func TestUpdateMethods(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	svc := sd.Service
+	svc := sd.Services[0]
 	allMethods := svc.Methods
 
 	conf := gengokit.Config{
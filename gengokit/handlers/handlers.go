@@ -195,13 +195,32 @@ func (m methodMap) pruneDecls(decls []ast.Decl, svcName string) []ast.Decl {
 // replaced by the new input type defined in m.RequestType.Name:
 //
 //     func ProtoMethod(ctx context.Context, *pb.{m.RequestType.Name})...
+//
+// Server streaming methods instead have the request as their first param,
+// followed by the stream, while client and bidirectional streaming methods
+// only accept the stream and so have nothing to update.
 func updateParams(f *ast.FuncDecl, m *svcdef.ServiceMethod) {
-	if f.Type.Params.NumFields() != 2 {
-		log.WithField("Function", f.Name.Name).
-			Warn("Function params signature should be func NAME(ctx context.Context, in *pb.TYPE), cannot fix")
-		return
+	switch {
+	case m.ClientStreaming:
+		if f.Type.Params.NumFields() != 1 {
+			log.WithField("Function", f.Name.Name).
+				Warn("Function params signature should be func NAME(stream pb.SVC_NAMEServer), cannot fix")
+		}
+	case m.ServerStreaming:
+		if f.Type.Params.NumFields() != 2 {
+			log.WithField("Function", f.Name.Name).
+				Warn("Function params signature should be func NAME(in *pb.TYPE, stream pb.SVC_NAMEServer), cannot fix")
+			return
+		}
+		updatePBFieldType(f.Type.Params.List[0].Type, m.RequestType)
+	default:
+		if f.Type.Params.NumFields() != 2 {
+			log.WithField("Function", f.Name.Name).
+				Warn("Function params signature should be func NAME(ctx context.Context, in *pb.TYPE), cannot fix")
+			return
+		}
+		updatePBFieldType(f.Type.Params.List[1].Type, m.RequestType)
 	}
-	updatePBFieldType(f.Type.Params.List[1].Type, m.RequestType.Name)
 }
 
 // updateResults updates the first result of f to be `X`.{m.ResponseType.Name}.
@@ -213,25 +232,38 @@ func updateParams(f *ast.FuncDecl, m *svcdef.ServiceMethod) {
 // replaced with the return type defined in m.ResponseType.Name:
 //
 //     func ProtoMethod(...) (*pb.{m.ResponseType.Name}, error)
+//
+// Streaming methods return only an error, so there is nothing to update.
 func updateResults(f *ast.FuncDecl, m *svcdef.ServiceMethod) {
+	if m.ClientStreaming || m.ServerStreaming {
+		if f.Type.Results.NumFields() != 1 {
+			log.WithField("Function", f.Name.Name).
+				Warn("Function results signature should be (error), cannot fix")
+		}
+		return
+	}
 	if f.Type.Results.NumFields() != 2 {
 		log.WithField("Function", f.Name.Name).
 			Warn("Function results signature should be (*pb.TYPE, error), cannot fix")
 		return
 	}
-	updatePBFieldType(f.Type.Results.List[0].Type, m.ResponseType.Name)
+	updatePBFieldType(f.Type.Results.List[0].Type, m.ResponseType)
 }
 
-// updatePBFieldType updates t if in the form X.Sel/*X.Sel to X.newType/*X.newType.
-func updatePBFieldType(t ast.Expr, newType string) {
+// updatePBFieldType updates t if in the form X.Sel/*X.Sel to Y.newType/*Y.newType,
+// where Y is the name the package of newType is imported as.
+func updatePBFieldType(t ast.Expr, newType *svcdef.FieldType) {
 	// *pb.TYPE -> pb.TYPE
 	if ptr, _ := t.(*ast.StarExpr); ptr != nil {
 		t = ptr.X
 	}
 	// pb.TYPE -> TYPE
 	if sel, _ := t.(*ast.SelectorExpr); sel != nil {
-		//pb.SOMETYPE -> pb.newType
-		sel.Sel.Name = newType
+		//pb.SOMETYPE -> typespb.newType
+		if x, _ := sel.X.(*ast.Ident); x != nil {
+			x.Name = newType.Package().Qualifier()
+		}
+		sel.Sel.Name = newType.Name
 	}
 }
 
@@ -3,10 +3,25 @@ package templates
 const HandlerMethods = `
 {{ with $te := .}}
 		{{range $i := .Methods}}
-		func (s {{ToLower $te.ServiceName}}Service) {{.Name}}(ctx context.Context, in *pb.{{GoName .RequestType.Name}}) (*pb.{{GoName .ResponseType.Name}}, error){
-			var resp pb.{{GoName .ResponseType.Name}}
+		{{- if and .ServerStreaming (not .ClientStreaming)}}
+		func (s {{ToLower $te.ServiceName}}Service) {{.Name}}(in *{{PBType .RequestType}}, stream pb.{{$te.ServiceName}}_{{.Name}}Server) error {
+			return nil
+		}
+		{{- else if and .ClientStreaming (not .ServerStreaming)}}
+		func (s {{ToLower $te.ServiceName}}Service) {{.Name}}(stream pb.{{$te.ServiceName}}_{{.Name}}Server) error {
+			var resp {{PBType .ResponseType}}
+			return stream.SendAndClose(&resp)
+		}
+		{{- else if .ClientStreaming}}
+		func (s {{ToLower $te.ServiceName}}Service) {{.Name}}(stream pb.{{$te.ServiceName}}_{{.Name}}Server) error {
+			return nil
+		}
+		{{- else}}
+		func (s {{ToLower $te.ServiceName}}Service) {{.Name}}(ctx context.Context, in *{{PBType .RequestType}}) (*{{PBType .ResponseType}}, error){
+			var resp {{PBType .ResponseType}}
 			return &resp, nil
 		}
+		{{- end}}
 		{{end}}
 {{- end}}
 `
@@ -14,8 +29,13 @@ const HandlerMethods = `
 const Handlers = `
 package handlers
 
+{{- $unary := false}}
+{{- range .Service.Methods}}{{if not (or .ClientStreaming .ServerStreaming)}}{{$unary = true}}{{end}}{{end}}
+
 import (
+	{{- if $unary}}
 	"context"
+	{{- end}}
 
 	pb "{{.PBImportPath -}}"
 )
@@ -29,10 +49,25 @@ type {{ToLower .Service.Name}}Service struct{}
 
 {{with $te := . }}
 	{{range $i := $te.Service.Methods}}
-		func (s {{ToLower $te.Service.Name}}Service) {{$i.Name}}(ctx context.Context, in *pb.{{GoName $i.RequestType.Name}}) (*pb.{{GoName $i.ResponseType.Name}}, error){
-			var resp pb.{{GoName $i.ResponseType.Name}}
+	{{- if and $i.ServerStreaming (not $i.ClientStreaming)}}
+		func (s {{ToLower $te.Service.Name}}Service) {{$i.Name}}(in *{{PBType $i.RequestType}}, stream pb.{{$te.Service.Name}}_{{$i.Name}}Server) error {
+			return nil
+		}
+	{{- else if and $i.ClientStreaming (not $i.ServerStreaming)}}
+		func (s {{ToLower $te.Service.Name}}Service) {{$i.Name}}(stream pb.{{$te.Service.Name}}_{{$i.Name}}Server) error {
+			var resp {{PBType $i.ResponseType}}
+			return stream.SendAndClose(&resp)
+		}
+	{{- else if $i.ClientStreaming}}
+		func (s {{ToLower $te.Service.Name}}Service) {{$i.Name}}(stream pb.{{$te.Service.Name}}_{{$i.Name}}Server) error {
+			return nil
+		}
+	{{- else}}
+		func (s {{ToLower $te.Service.Name}}Service) {{$i.Name}}(ctx context.Context, in *{{PBType $i.RequestType}}) (*{{PBType $i.ResponseType}}, error){
+			var resp {{PBType $i.ResponseType}}
 			return &resp, nil
 		}
+	{{- end}}
 	{{end}}
 {{- end}}
 `
@@ -1,9 +1,23 @@
 package httptransport
 
 import (
+	"bytes"
+	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
 	"strings"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/metaverse/truss/svcdef"
 )
 
 // Contains all the functions which must be used within templates. Stored all
@@ -12,29 +26,32 @@ import (
 // they can be properly tested, while still allowing them to be conveniently
 // templated into the code.
 
-// PathParams takes a url and a gRPC-annotation style url template, and
-// returns a map of the named parameters in the template and their values in
-// the given url.
-//
-// PathParams does not support the entirety of the URL template syntax defined
-// in third_party/googleapis/google/api/httprule.proto. Only a small subset of
-// the functionality defined there is implemented here.
-func PathParams(url string, urlTmpl string) (map[string]string, error) {
-	rv := map[string]string{}
-	pmp := BuildParamMap(urlTmpl)
-
-	expectedLen := len(strings.Split(strings.TrimRight(urlTmpl, "/"), "/"))
-	recievedLen := len(strings.Split(strings.TrimRight(url, "/"), "/"))
-	if expectedLen != recievedLen {
-		return nil, fmt.Errorf("expecting a path containing %d parts, provided path contains %d parts", expectedLen, recievedLen)
+// PathParams takes an escaped url path and a gRPC-annotation style url
+// template, and returns a map of the named parameters in the template and
+// their values in the given path, unescaped. The path is matched the way the
+// generated server matches it, supporting the entirety of the URL template
+// syntax defined in third_party/googleapis/google/api/httprule.proto.
+func PathParams(path string, urlTmpl string) (map[string]string, error) {
+	tmpl, err := svcdef.ParsePathTemplate(urlTmpl)
+	if err != nil {
+		return nil, err
 	}
-
-	parts := strings.Split(url, "/")
-	for k, v := range pmp {
-		rv[k] = parts[v]
+	u, err := url.Parse(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot parse path %q", path)
 	}
-
-	return rv, nil
+	var match mux.RouteMatch
+	route := mux.NewRouter().UseEncodedPath().Path(getMuxPathTemplate(tmpl))
+	if !route.Match(&http.Request{URL: u}, &match) {
+		return nil, errors.Errorf("path %q does not match %q", path, urlTmpl)
+	}
+	var multiSegment []string
+	for _, v := range tmpl.Variables() {
+		if v.MultiSegment() {
+			multiSegment = append(multiSegment, v.Variable)
+		}
+	}
+	return unescapePathParams(match.Vars, multiSegment...)
 }
 
 // BuildParamMap takes a string representing a url template and returns a map
@@ -50,15 +67,25 @@ func PathParams(url string, urlTmpl string) (map[string]string, error) {
 //         "a": 2,
 //         "b": 3,
 //     }
+//
+// The location of a parameter bound to several segments, such as
+// {a=shelves/*}, is that of its first segment. The map is empty if the url
+// template is invalid.
 func BuildParamMap(urlTmpl string) map[string]int {
 	rv := map[string]int{}
+	tmpl, err := svcdef.ParsePathTemplate(urlTmpl)
+	if err != nil {
+		return rv
+	}
 
-	parts := strings.Split(urlTmpl, "/")
-	for idx, part := range parts {
-		if strings.ContainsAny(part, "{}") {
-			param := RemoveBraces(part)
-			rv[param] = idx
+	idx := 1
+	for _, seg := range tmpl.Segments {
+		if seg.Variable == "" {
+			idx++
+			continue
 		}
+		rv[seg.Variable] = idx
+		idx += len(seg.Segments)
 	}
 	return rv
 }
@@ -71,6 +98,70 @@ func RemoveBraces(val string) string {
 	return val
 }
 
+// unescapePathParams unescapes the values of the path parameters vars, as
+// matched against the escaped path, the way google.api.HttpRule prescribes:
+// escaped slashes are kept escaped in the values of variables which may match
+// more than one segment, whose names are given as multiSegment. Wildcards
+// outside of any variable, named "*" followed by their index, are left out.
+func unescapePathParams(vars map[string]string, multiSegment ...string) (map[string]string, error) {
+	multi := make(map[string]bool)
+	for _, name := range multiSegment {
+		multi[name] = true
+	}
+
+	rv := make(map[string]string)
+	for name, value := range vars {
+		if strings.HasPrefix(name, "*") {
+			continue
+		}
+		var unescaped strings.Builder
+		for value != "" {
+			end := len(value)
+			if multi[name] {
+				for i := 0; i+2 < len(value); i++ {
+					if value[i] == '%' && value[i+1] == '2' && (value[i+2] == 'F' || value[i+2] == 'f') {
+						end = i
+						break
+					}
+				}
+			}
+			part, err := url.PathUnescape(value[:end])
+			if err != nil {
+				return nil, errors.Wrapf(err, "cannot unescape path parameter %q", name)
+			}
+			unescaped.WriteString(part)
+			if end < len(value) {
+				unescaped.WriteString(value[end : end+3])
+				end += 3
+			}
+			value = value[end:]
+		}
+		rv[name] = unescaped.String()
+	}
+	return rv, nil
+}
+
+// escapePathVariable escapes the value of a path variable the way
+// google.api.HttpRule prescribes: every character but [-_.~0-9a-zA-Z] is
+// percent-encoded, except for slashes in the value of a variable which may
+// match more than one segment.
+func escapePathVariable(value string, multiSegment bool) string {
+	const hex = "0123456789ABCDEF"
+	var escaped strings.Builder
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/' && multiSegment:
+			escaped.WriteByte(c)
+		default:
+			escaped.WriteByte('%')
+			escaped.WriteByte(hex[c>>4])
+			escaped.WriteByte(hex[c&15])
+		}
+	}
+	return escaped.String()
+}
+
 // encodePathParams encodes `mux.Vars()` with dot notations into JSON objects
 // to be unmarshaled into non-basetype fields.
 // e.g. {"book.name": "books/1"} -> {"book": {"name": "books/1"}}
@@ -106,3 +197,353 @@ func encodePathParams(vars map[string]string) map[string]string {
 	}
 	return ret
 }
+
+// encodeHTTPResponseBody returns a transport/http.EncodeResponseFunc that
+// encodes the field named field of the response as JSON, the way the
+// response_body of google.api.HttpRule prescribes. The field is encoded as it
+// would be within the whole response, except that a default value is encoded
+// rather than left out.
+func encodeHTTPResponseBody(field string) func(context.Context, http.ResponseWriter, interface{}) error {
+	return func(_ context.Context, w http.ResponseWriter, response interface{}) error {
+		marshaller := jsonpb.Marshaler{
+			EmitDefaults: true,
+			OrigName:     true,
+		}
+		var buf bytes.Buffer
+		if err := marshaller.Marshal(&buf, response.(proto.Message)); err != nil {
+			return err
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(buf.Bytes(), &fields); err != nil {
+			return err
+		}
+		body, ok := fields[field]
+		if !ok {
+			body = json.RawMessage("null")
+		}
+		_, err := w.Write(body)
+		return err
+	}
+}
+
+// wrapJSONField returns the JSON object of a message whose field named field
+// is value, so that the body of an HTTP request or response bound to a single
+// field can be decoded as the whole message.
+func wrapJSONField(field string, value []byte) ([]byte, error) {
+	return json.Marshal(map[string]json.RawMessage{field: value})
+}
+
+// populateQueryParams sets the fields of msg named by dotted query parameters
+// such as "filter.author.name", the way grpc-gateway decodes them. The first
+// part of such a parameter must be one of fields, the parts but the last name
+// message fields, and the last names a field of a base type, an enum by name
+// or number, or a message, map or repeated message as JSON. A repeated field
+// of base types or enums is set by repeating the parameter. Parameters naming
+// no field are ignored, as are oneofs within messages.
+func populateQueryParams(msg proto.Message, values url.Values, fields ...string) error {
+	allowed := make(map[string]bool)
+	for _, f := range fields {
+		allowed[f] = true
+	}
+params:
+	for key, vals := range values {
+		path := strings.Split(key, ".")
+		if len(path) < 2 || !allowed[path[0]] {
+			continue
+		}
+		v := reflect.ValueOf(msg).Elem()
+		for i, name := range path {
+			field, tag := protoField(v, name)
+			if !field.IsValid() {
+				continue params
+			}
+			if i == len(path)-1 {
+				if err := setQueryField(field, tag, vals); err != nil {
+					return errors.Wrapf(err, "cannot decode query parameter %q", key)
+				}
+				break
+			}
+			if field.Kind() != reflect.Ptr || field.Type().Elem().Kind() != reflect.Struct {
+				return errors.Errorf("cannot decode query parameter %q: %s is not a message", key, name)
+			}
+			if field.IsNil() {
+				field.Set(reflect.New(field.Type().Elem()))
+			}
+			v = field.Elem()
+		}
+	}
+	return nil
+}
+
+// protoField returns the field of v, a message struct, named name in the
+// definition or in JSON, along with its protobuf struct tag. The returned
+// value is invalid if there is no such field.
+func protoField(v reflect.Value, name string) (reflect.Value, string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("protobuf")
+		for _, opt := range strings.Split(tag, ",") {
+			if opt == "name="+name || opt == "json="+name {
+				return v.Field(i), tag
+			}
+		}
+	}
+	return reflect.Value{}, ""
+}
+
+// setQueryField sets field, whose protobuf struct tag is tag, to vals, the
+// values of a query parameter.
+func setQueryField(field reflect.Value, tag string, vals []string) error {
+	t := field.Type()
+	switch {
+	case t.Kind() == reflect.Map, t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Ptr:
+		return json.Unmarshal([]byte(vals[0]), field.Addr().Interface())
+	case t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8:
+		slice := reflect.MakeSlice(t, len(vals), len(vals))
+		for i, s := range vals {
+			if err := parseQueryValue(slice.Index(i), tag, s); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+	return parseQueryValue(field, tag, vals[0])
+}
+
+// parseQueryValue sets v, a single value of a field whose protobuf struct tag
+// is tag, to s, parsed according to its type. Bytes are base64 encoded, and
+// well-known types given by their string form. A pointer to a scalar, as of an
+// optional proto2 field, is set to a new value parsed the same way.
+func parseQueryValue(v reflect.Value, tag, s string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			// An enum may also be given by the name of its value
+			for _, opt := range strings.Split(tag, ",") {
+				if !strings.HasPrefix(opt, "enum=") {
+					continue
+				}
+				if number, ok := proto.EnumValueMap(strings.TrimPrefix(opt, "enum="))[s]; ok {
+					n, err = int64(number), nil
+				}
+			}
+		}
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return err
+		}
+		v.SetBytes(b)
+	case reflect.Ptr:
+		if v.Type().Elem().Kind() != reflect.Struct {
+			elem := reflect.New(v.Type().Elem())
+			if err := parseQueryValue(elem.Elem(), tag, s); err != nil {
+				return err
+			}
+			v.Set(elem)
+			return nil
+		}
+		msg := reflect.New(v.Type().Elem())
+		if m, ok := msg.Interface().(proto.Message); ok && isWellKnownType(proto.MessageName(m)) {
+			if err := parseWellKnownType(s, m); err != nil {
+				return err
+			}
+			v.Set(msg)
+			return nil
+		}
+		return json.Unmarshal([]byte(s), v.Addr().Interface())
+	default:
+		return errors.Errorf("cannot decode a %s from a query parameter", v.Type())
+	}
+	return nil
+}
+
+// addQueryParams adds the fields of msg, a pointer to a message, which are
+// set to values as dotted query parameters following prefix, the way
+// populateQueryParams decodes them. Enums are added by number, well-known
+// types by their string form, pointers to scalars by the value they point to,
+// and oneofs are left out.
+func addQueryParams(values url.Values, prefix string, msg interface{}) error {
+	v := reflect.ValueOf(msg)
+	if v.IsNil() {
+		return nil
+	}
+	v = v.Elem()
+	for i := 0; i < v.NumField(); i++ {
+		var name string
+		for _, opt := range strings.Split(v.Type().Field(i).Tag.Get("protobuf"), ",") {
+			if strings.HasPrefix(opt, "name=") {
+				name = strings.TrimPrefix(opt, "name=")
+			}
+		}
+		field := v.Field(i)
+		if name == "" || field.IsZero() {
+			continue
+		}
+		key := prefix + "." + name
+		switch t := field.Type(); {
+		case t.Kind() == reflect.Map, t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Ptr:
+			b, err := json.Marshal(field.Interface())
+			if err != nil {
+				return errors.Wrapf(err, "cannot encode query parameter %q", key)
+			}
+			values.Add(key, string(b))
+		case t.Kind() == reflect.Ptr:
+			if m, ok := field.Interface().(proto.Message); ok && isWellKnownType(proto.MessageName(m)) {
+				s, err := formatWellKnownType(m)
+				if err != nil {
+					return errors.Wrapf(err, "cannot encode query parameter %q", key)
+				}
+				values.Add(key, s)
+				break
+			}
+			if t.Elem().Kind() != reflect.Struct {
+				values.Add(key, formatQueryValue(field.Elem()))
+				break
+			}
+			if err := addQueryParams(values, key, field.Interface()); err != nil {
+				return err
+			}
+		case t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8:
+			for j := 0; j < field.Len(); j++ {
+				values.Add(key, formatQueryValue(field.Index(j)))
+			}
+		default:
+			values.Add(key, formatQueryValue(field))
+		}
+	}
+	return nil
+}
+
+// formatQueryValue returns v, a single value of a field, as a query parameter
+// value parseQueryValue parses.
+func formatQueryValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Slice:
+		return base64.StdEncoding.EncodeToString(v.Bytes())
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// isWellKnownType returns whether the message named protoName is a well-known
+// type whose JSON form is a string or a scalar, which may be given by a path
+// or query parameter.
+func isWellKnownType(protoName string) bool {
+	switch protoName {
+	case "google.protobuf.Timestamp",
+		"google.protobuf.Duration",
+		"google.protobuf.FieldMask",
+		"google.protobuf.DoubleValue",
+		"google.protobuf.FloatValue",
+		"google.protobuf.Int64Value",
+		"google.protobuf.UInt64Value",
+		"google.protobuf.Int32Value",
+		"google.protobuf.UInt32Value",
+		"google.protobuf.BoolValue",
+		"google.protobuf.StringValue",
+		"google.protobuf.BytesValue":
+		return true
+	}
+	return false
+}
+
+// parseWellKnownType sets msg, a well-known type as isWellKnownType accepts,
+// to s, its JSON form without any quotes: an RFC 3339 timestamp, a duration
+// such as "1.5s", comma separated field paths, or the value of a wrapper.
+// Field paths may be in lowerCamelCase, as in JSON, or in snake_case.
+func parseWellKnownType(s string, msg proto.Message) error {
+	if proto.MessageName(msg) == "google.protobuf.FieldMask" {
+		var paths []string
+		for _, path := range strings.Split(s, ",") {
+			if path == "" {
+				continue
+			}
+			var snake strings.Builder
+			for _, r := range path {
+				if 'A' <= r && r <= 'Z' {
+					snake.WriteByte('_')
+					r += 'a' - 'A'
+				}
+				snake.WriteRune(r)
+			}
+			paths = append(paths, snake.String())
+		}
+		reflect.ValueOf(msg).Elem().FieldByName("Paths").Set(reflect.ValueOf(paths))
+		return nil
+	}
+	// Most are JSON strings, while numbers and bools may be bare
+	err := jsonpb.UnmarshalString(strconv.Quote(s), msg)
+	if err != nil {
+		msg.Reset()
+		if jsonpb.UnmarshalString(s, msg) == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// formatWellKnownType returns msg, a well-known type as isWellKnownType
+// accepts, in the form parseWellKnownType parses; empty if msg is nil. Field
+// paths are given in lowerCamelCase, as in JSON.
+func formatWellKnownType(msg proto.Message) (string, error) {
+	v := reflect.ValueOf(msg)
+	if v.IsNil() {
+		return "", nil
+	}
+	if proto.MessageName(msg) == "google.protobuf.FieldMask" {
+		var paths []string
+		for _, path := range v.Elem().FieldByName("Paths").Interface().([]string) {
+			var camel strings.Builder
+			upper := false
+			for _, r := range path {
+				if r == '_' {
+					upper = true
+					continue
+				}
+				if upper && 'a' <= r && r <= 'z' {
+					r -= 'a' - 'A'
+				}
+				upper = false
+				camel.WriteRune(r)
+			}
+			paths = append(paths, camel.String())
+		}
+		return strings.Join(paths, ","), nil
+	}
+	s, err := (&jsonpb.Marshaler{}).MarshalToString(msg)
+	if err != nil {
+		return "", err
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		return unquoted, nil
+	}
+	return s, nil
+}
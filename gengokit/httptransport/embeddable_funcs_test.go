@@ -1,10 +1,131 @@
 package httptransport
 
 import (
+	"context"
+	"net/http/httptest"
+	"net/url"
 	"reflect"
 	"testing"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
 )
 
+// TestPathParams checks that paths are matched and their variables unescaped
+// as google.api.HttpRule prescribes, taking its examples.
+func TestPathParams(t *testing.T) {
+	tests := []struct {
+		tmpl string
+		path string
+		want map[string]string
+	}{
+		{"/v1/{name=messages/*}", "/v1/messages/123456", map[string]string{"name": "messages/123456"}},
+		{"/v1/messages/{message_id}", "/v1/messages/123456", map[string]string{"message_id": "123456"}},
+		{"/v1/users/{user_id}/messages/{message_id}", "/v1/users/me/messages/123456", map[string]string{"user_id": "me", "message_id": "123456"}},
+		{"/v1/{name=shelves/*/books/*}", "/v1/shelves/shelf1/books/book2", map[string]string{"name": "shelves/shelf1/books/book2"}},
+		{"/v1/{book.name=shelves/*/books/*}", "/v1/shelves/shelf1/books/book2", map[string]string{"book.name": "shelves/shelf1/books/book2"}},
+		{"/v1/{name=operations/**}", "/v1/operations/a/b/c", map[string]string{"name": "operations/a/b/c"}},
+		{"/v1/{name=operations/**}", "/v1/operations", map[string]string{"name": "operations"}},
+		{"/v1/{name=**}", "/v1/a/b", map[string]string{"name": "a/b"}},
+		// Custom verbs
+		{"/v1/{name}:cancel", "/v1/operation1:cancel", map[string]string{"name": "operation1"}},
+		{"/v1/{name=operations/**}:cancel", "/v1/operations/a/b:cancel", map[string]string{"name": "operations/a/b"}},
+		{"/v1/operations:list", "/v1/operations:list", map[string]string{}},
+		// Wildcards outside of variables
+		{"/v1/*/books/{book}", "/v1/shelf1/books/book2", map[string]string{"book": "book2"}},
+		{"/v1/books/**", "/v1/books/a/b", map[string]string{}},
+		// Every escaped character is unescaped in the value of a single
+		// segment, while slashes are kept escaped in that of several
+		{"/v1/{name}", "/v1/a%2Fb%20c%3Ad", map[string]string{"name": "a/b c:d"}},
+		{"/v1/{name=**}", "/v1/a%2Fb/c%20d%2fe", map[string]string{"name": "a%2Fb/c d%2fe"}},
+		{"/v1/{name=shelves/*}", "/v1/shelves/a%2Fb", map[string]string{"name": "shelves/a%2Fb"}},
+	}
+	for _, tt := range tests {
+		got, err := PathParams(tt.path, tt.tmpl)
+		if err != nil {
+			t.Errorf("PathParams(%q, %q): %v", tt.path, tt.tmpl, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("PathParams(%q, %q) = %v, want %v", tt.path, tt.tmpl, got, tt.want)
+		}
+	}
+
+	for _, tt := range []struct {
+		tmpl string
+		path string
+	}{
+		{"/v1/{name=messages/*}", "/v1/other/123456"},
+		{"/v1/{name=messages/*}", "/v1/messages/1/2"},
+		{"/v1/{name}", "/v1/a/b"},
+		{"/v1/{name}:cancel", "/v1/operation1"},
+		{"/v1/{name}:cancel", "/v1/operation1:undelete"},
+		{"/v1/*/books", "/v1/books"},
+		{"/v1/{name}", "/v1/%zz"},
+		{"/v1/{name", "/v1/a"},
+	} {
+		if got, err := PathParams(tt.path, tt.tmpl); err == nil {
+			t.Errorf("PathParams(%q, %q) = %v, want an error", tt.path, tt.tmpl, got)
+		}
+	}
+}
+
+func TestEscapePathVariable(t *testing.T) {
+	tests := []struct {
+		value        string
+		multiSegment bool
+		want         string
+	}{
+		{"abc-_.~XYZ019", false, "abc-_.~XYZ019"},
+		{"a/b c:d", false, "a%2Fb%20c%3Ad"},
+		{"shelves/a b", true, "shelves/a%20b"},
+		{"é", false, "%C3%A9"},
+	}
+	for _, tt := range tests {
+		if got := escapePathVariable(tt.value, tt.multiSegment); got != tt.want {
+			t.Errorf("escapePathVariable(%q, %v) = %q, want %q", tt.value, tt.multiSegment, got, tt.want)
+		}
+	}
+
+	// The server gets back the values the client escapes
+	for _, tt := range []struct {
+		tmpl         string
+		value        string
+		multiSegment bool
+	}{
+		{"/v1/{name}", "a/b c:d?e#f%g", false},
+		{"/v1/{name=shelves/*}", "shelves/a b", true},
+		{"/v1/{name=**}", "a/b/c:d", true},
+	} {
+		got, err := PathParams("/v1/"+escapePathVariable(tt.value, tt.multiSegment), tt.tmpl)
+		if err != nil {
+			t.Errorf("PathParams of %q escaped: %v", tt.value, err)
+			continue
+		}
+		if got["name"] != tt.value {
+			t.Errorf("PathParams of %q escaped = %q", tt.value, got["name"])
+		}
+	}
+}
+
+func TestBuildParamMap(t *testing.T) {
+	tests := []struct {
+		tmpl string
+		want map[string]int
+	}{
+		{"/v1/{a}/{b}", map[string]int{"a": 2, "b": 3}},
+		{"/v1/{a=shelves/*}/books/{b}", map[string]int{"a": 2, "b": 5}},
+		{"/v1/*/{a}:cancel", map[string]int{"a": 3}},
+		{"/v1/{a", map[string]int{}},
+	}
+	for _, tt := range tests {
+		if got := BuildParamMap(tt.tmpl); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("BuildParamMap(%q) = %v, want %v", tt.tmpl, got, tt.want)
+		}
+	}
+}
+
 func TestEncodePathParams(t *testing.T) {
 	tests := []struct {
 		name string
@@ -67,3 +188,262 @@ func TestEncodePathParams(t *testing.T) {
 		})
 	}
 }
+
+// TestResponseBody checks that the field of a response encoded by
+// encodeHTTPResponseBody decodes back into the response once wrapped by
+// wrapJSONField.
+func TestResponseBody(t *testing.T) {
+	api := &types.Api{
+		Name:    "library",
+		Methods: []*types.Method{{Name: "ListBooks"}},
+	}
+	tests := []struct {
+		field string
+		body  string
+		want  *types.Api
+	}{
+		{"name", `"library"`, &types.Api{Name: "library"}},
+		{"methods", `[{"name":"ListBooks","request_type_url":"","request_streaming":false,"response_type_url":"","response_streaming":false,"options":[],"syntax":"SYNTAX_PROTO2"}]`, &types.Api{Methods: api.Methods}},
+		{"version", `""`, &types.Api{}},
+		{"source_context", `null`, &types.Api{}},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		if err := encodeHTTPResponseBody(tt.field)(context.Background(), w, api); err != nil {
+			t.Errorf("encodeHTTPResponseBody(%q): %v", tt.field, err)
+			continue
+		}
+		if got := w.Body.String(); got != tt.body {
+			t.Errorf("encodeHTTPResponseBody(%q) wrote %s, want %s", tt.field, got, tt.body)
+		}
+
+		wrapped, err := wrapJSONField(tt.field, w.Body.Bytes())
+		if err != nil {
+			t.Errorf("wrapJSONField(%q, %s): %v", tt.field, w.Body, err)
+			continue
+		}
+		var got types.Api
+		if err := jsonpb.UnmarshalString(string(wrapped), &got); err != nil {
+			t.Errorf("cannot decode %s: %v", wrapped, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("decoded %s into %v, want %v", wrapped, &got, tt.want)
+		}
+	}
+
+	if _, err := wrapJSONField("name", []byte("not json")); err == nil {
+		t.Error("wrapJSONField succeeded with a body which is not JSON")
+	}
+}
+
+// queryRequest and queryFilter are messages as protoc-gen-gogo generates
+// them, to test dotted query parameters with.
+type queryRequest struct {
+	Filter *queryFilter `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	Body   *queryFilter `protobuf:"bytes,2,opt,name=body,proto3" json:"body,omitempty"`
+}
+
+func (*queryRequest) Reset()         {}
+func (*queryRequest) String() string { return "" }
+func (*queryRequest) ProtoMessage()  {}
+
+type queryFilter struct {
+	Name          string             `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Author        *queryFilter       `protobuf:"bytes,2,opt,name=author,proto3" json:"author,omitempty"`
+	Year          int32              `protobuf:"varint,3,opt,name=year,proto3" json:"year,omitempty"`
+	Tags          []string           `protobuf:"bytes,4,rep,name=tags,proto3" json:"tags,omitempty"`
+	Syntax        types.Syntax       `protobuf:"varint,5,opt,name=syntax,proto3,enum=google.protobuf.Syntax" json:"syntax,omitempty"`
+	Kinds         []types.Field_Kind `protobuf:"varint,6,rep,packed,name=kinds,proto3,enum=google.protobuf.Field_Kind" json:"kinds,omitempty"`
+	Data          []byte             `protobuf:"bytes,7,opt,name=data,proto3" json:"data,omitempty"`
+	Labels        map[string]string  `protobuf:"bytes,8,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Ratio         float64            `protobuf:"fixed64,9,opt,name=ratio,proto3" json:"ratio,omitempty"`
+	IsNew         bool               `protobuf:"varint,10,opt,name=is_new,json=isNew,proto3" json:"is_new,omitempty"`
+	Count         uint64             `protobuf:"varint,11,opt,name=count,proto3" json:"count,omitempty"`
+	Related       []*queryFilter     `protobuf:"bytes,12,rep,name=related,proto3" json:"related,omitempty"`
+	Since         *types.Timestamp   `protobuf:"bytes,13,opt,name=since,proto3" json:"since,omitempty"`
+	Mask          *types.FieldMask   `protobuf:"bytes,14,opt,name=mask,proto3" json:"mask,omitempty"`
+	Nickname      *string            `protobuf:"bytes,15,opt,name=nickname" json:"nickname,omitempty"`
+	Rank          *int32             `protobuf:"varint,16,opt,name=rank" json:"rank,omitempty"`
+	XXX_sizecache int32              `json:"-"`
+}
+
+func TestPopulateQueryParams(t *testing.T) {
+	values := url.Values{
+		"filter.name":           {"a"},
+		"filter.author.name":    {"x"},
+		"filter.author.year":    {"2020"},
+		"filter.tags":           {"b", "c"},
+		"filter.syntax":         {"SYNTAX_PROTO3"},
+		"filter.kinds":          {"TYPE_STRING", "1"},
+		"filter.data":           {"aGk="},
+		"filter.labels":         {`{"k":"v"}`},
+		"filter.ratio":          {"0.5"},
+		"filter.isNew":          {"true"},
+		"filter.count":          {"18446744073709551615"},
+		"filter.related":        {`[{"name":"r"}]`},
+		"filter.since":          {"2020-01-02T03:04:05Z"},
+		"filter.mask":           {"name,author.isNew"},
+		"filter.nickname":       {"n"},
+		"filter.rank":           {"-3"},
+		"filter.unknown":        {"ignored"},
+		"filter.author.unknown": {"ignored"},
+		"body.name":             {"ignored"},
+		"name":                  {"ignored"},
+	}
+	var got queryRequest
+	if err := populateQueryParams(&got, values, "filter"); err != nil {
+		t.Fatal(err)
+	}
+	want := queryRequest{
+		Filter: &queryFilter{
+			Name:     "a",
+			Author:   &queryFilter{Name: "x", Year: 2020},
+			Tags:     []string{"b", "c"},
+			Syntax:   types.Syntax_SYNTAX_PROTO3,
+			Kinds:    []types.Field_Kind{types.Field_TYPE_STRING, types.Field_TYPE_DOUBLE},
+			Data:     []byte("hi"),
+			Labels:   map[string]string{"k": "v"},
+			Ratio:    0.5,
+			IsNew:    true,
+			Count:    18446744073709551615,
+			Related:  []*queryFilter{{Name: "r"}},
+			Since:    &types.Timestamp{Seconds: 1577934245},
+			Mask:     &types.FieldMask{Paths: []string{"name", "author.is_new"}},
+			Nickname: proto.String("n"),
+			Rank:     proto.Int32(-3),
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("populateQueryParams(%v) set %+v, want %+v", values, got.Filter, want.Filter)
+	}
+
+	for _, values := range []url.Values{
+		{"filter.year": {"x"}},
+		{"filter.syntax": {"UNKNOWN"}},
+		{"filter.name.first": {"x"}},
+		{"filter.data": {"not base64"}},
+		{"filter.since": {"yesterday"}},
+		{"filter.rank": {"x"}},
+	} {
+		if err := populateQueryParams(&queryRequest{}, values, "filter"); err == nil {
+			t.Errorf("populateQueryParams(%v) succeeded, want an error", values)
+		}
+	}
+}
+
+// TestAddQueryParams checks that the query parameters added by
+// addQueryParams decode back into the message they were added from.
+func TestAddQueryParams(t *testing.T) {
+	filter := &queryFilter{
+		Name:     "a",
+		Author:   &queryFilter{Name: "x", Year: 2020},
+		Tags:     []string{"b", "c"},
+		Syntax:   types.Syntax_SYNTAX_PROTO3,
+		Kinds:    []types.Field_Kind{types.Field_TYPE_STRING, types.Field_TYPE_DOUBLE},
+		Data:     []byte("hi"),
+		Labels:   map[string]string{"k": "v"},
+		Ratio:    0.5,
+		IsNew:    true,
+		Count:    18446744073709551615,
+		Related:  []*queryFilter{{Name: "r"}},
+		Since:    &types.Timestamp{Seconds: 1577934245, Nanos: 500000000},
+		Mask:     &types.FieldMask{Paths: []string{"name", "author.year"}},
+		Nickname: proto.String(""),
+		Rank:     proto.Int32(-3),
+	}
+	values := url.Values{}
+	if err := addQueryParams(values, "filter", filter); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := values.Get("filter.author.year"), "2020"; got != want {
+		t.Errorf("filter.author.year = %q, want %q", got, want)
+	}
+	if got, want := values["filter.kinds"], []string{"9", "1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("filter.kinds = %q, want %q", got, want)
+	}
+	if got, want := values.Get("filter.is_new"), "true"; got != want {
+		t.Errorf("filter.is_new = %q, want %q", got, want)
+	}
+	if got, want := values.Get("filter.since"), "2020-01-02T03:04:05.500Z"; got != want {
+		t.Errorf("filter.since = %q, want %q", got, want)
+	}
+	if got, want := values["filter.nickname"], []string{""}; !reflect.DeepEqual(got, want) {
+		t.Errorf("filter.nickname = %q, want %q", got, want)
+	}
+	if got, want := values.Get("filter.rank"), "-3"; got != want {
+		t.Errorf("filter.rank = %q, want %q", got, want)
+	}
+
+	var got queryRequest
+	if err := populateQueryParams(&got, values, "filter"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Filter, filter) {
+		t.Errorf("decoded %v into %+v, want %+v", values, got.Filter, filter)
+	}
+
+	values = url.Values{}
+	if err := addQueryParams(values, "filter", (*queryFilter)(nil)); err != nil || len(values) != 0 {
+		t.Errorf("addQueryParams of nil added %v, %v; want none", values, err)
+	}
+}
+
+// TestWellKnownTypes checks that well-known types are parsed from, and
+// formatted as, the string forms of their JSON mapping.
+func TestWellKnownTypes(t *testing.T) {
+	tests := []struct {
+		s    string
+		want proto.Message
+	}{
+		{"2020-01-02T03:04:05Z", &types.Timestamp{Seconds: 1577934245}},
+		{"1.500s", &types.Duration{Seconds: 1, Nanos: 500000000}},
+		{"name,author.isNew", &types.FieldMask{Paths: []string{"name", "author.is_new"}}},
+		{"-1.5", &types.DoubleValue{Value: -1.5}},
+		{"1.5", &types.FloatValue{Value: 1.5}},
+		{"-9007199254740993", &types.Int64Value{Value: -9007199254740993}},
+		{"18446744073709551615", &types.UInt64Value{Value: 18446744073709551615}},
+		{"-7", &types.Int32Value{Value: -7}},
+		{"7", &types.UInt32Value{Value: 7}},
+		{"true", &types.BoolValue{Value: true}},
+		{"a, b", &types.StringValue{Value: "a, b"}},
+		{"aGk=", &types.BytesValue{Value: []byte("hi")}},
+	}
+	for _, tt := range tests {
+		name := proto.MessageName(tt.want)
+		if !isWellKnownType(name) {
+			t.Errorf("isWellKnownType(%q) = false, want true", name)
+		}
+		got := reflect.New(reflect.TypeOf(tt.want).Elem()).Interface().(proto.Message)
+		if err := parseWellKnownType(tt.s, got); err != nil {
+			t.Errorf("parseWellKnownType(%q) into a %s: %v", tt.s, name, err)
+		} else if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseWellKnownType(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+		if got, err := formatWellKnownType(tt.want); err != nil || got != tt.s {
+			t.Errorf("formatWellKnownType(%v) = %q, %v; want %q", tt.want, got, err, tt.s)
+		}
+	}
+
+	mask := &types.FieldMask{}
+	if err := parseWellKnownType("name,author.is_new", mask); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"name", "author.is_new"}; !reflect.DeepEqual(mask.Paths, want) {
+		t.Errorf("parseWellKnownType of snake_case paths = %q, want %q", mask.Paths, want)
+	}
+	if got, err := formatWellKnownType((*types.Timestamp)(nil)); err != nil || got != "" {
+		t.Errorf("formatWellKnownType(nil) = %q, %v; want empty", got, err)
+	}
+	if _, err := formatWellKnownType(&types.Timestamp{Nanos: -1}); err == nil {
+		t.Error("formatWellKnownType of an invalid timestamp succeeded, want an error")
+	}
+	if isWellKnownType("google.protobuf.Struct") {
+		t.Error("isWellKnownType(\"google.protobuf.Struct\") = true, want false")
+	}
+	for _, msg := range []proto.Message{&types.Timestamp{}, &types.Duration{}, &types.Int32Value{}, &types.BoolValue{}} {
+		if err := parseWellKnownType("x", msg); err == nil {
+			t.Errorf("parseWellKnownType(%q) into a %s succeeded, want an error", "x", proto.MessageName(msg))
+		}
+	}
+}
@@ -40,6 +40,11 @@ func NewHelper(svc *svcdef.Service) *Helper {
 		ClientTemplate: GenClientTemplate,
 	}
 	for _, meth := range svc.Methods {
+		if len(meth.Bindings) > 0 && (meth.ClientStreaming || meth.ServerStreaming) {
+			log.WithField("Method", meth.Name).
+				Warn("Streaming methods are not supported over HTTP; skipping HTTP bindings")
+			continue
+		}
 		if len(meth.Bindings) > 0 {
 			nMeth := NewMethod(meth)
 			rv.Methods = append(rv.Methods, nMeth)
@@ -54,6 +59,8 @@ func NewMethod(meth *svcdef.ServiceMethod) *Method {
 		Name:         meth.Name,
 		RequestType:  meth.RequestType.Name,
 		ResponseType: meth.ResponseType.Name,
+		RequestPkg:   meth.RequestType.Package(),
+		ResponsePkg:  meth.ResponseType.Package(),
 	}
 	for i := range meth.Bindings {
 		nBinding := NewBinding(i, meth)
@@ -72,10 +79,14 @@ func NewBinding(i int, meth *svcdef.ServiceMethod) *Binding {
 	binding := meth.Bindings[i]
 	nBinding := Binding{
 		Label:        meth.Name + EnglishNumber(i),
-		PathTemplate: getMuxPathTemplate(binding.Path),
+		PathTemplate: getMuxPathTemplate(binding.Template),
+		Template:     binding.Template,
 		BasePath:     basePath(binding.Path),
 		Verb:         binding.Verb,
 	}
+	if binding.ResponseBody != nil {
+		nBinding.ResponseBody = binding.ResponseBody.PBFieldName
+	}
 	// Handle oneofs which need to be specially formed for query params
 	for _, param := range binding.Params {
 		// The 'Field' attr of each HTTPParameter always point to it's bound
@@ -102,7 +113,7 @@ func NewBinding(i int, meth *svcdef.ServiceMethod) *Binding {
 			if oneofType.Type.Enum == nil && oneofType.Type.Map == nil {
 				option.IsBaseType = true
 			} else {
-				option.GoType = "pb." + option.GoType
+				option.GoType = oneofType.Type.Package().Qualifier() + "." + option.GoType
 			}
 
 			// Modify GoType to reflect pointer or repeated status
@@ -114,7 +125,7 @@ func NewBinding(i int, meth *svcdef.ServiceMethod) *Binding {
 
 			option.IsEnum = oneofType.Type.Enum != nil
 			option.ConvertFunc, option.ConvertFuncNeedsErrorCheck = createDecodeConvertFunc(option)
-			option.TypeConversion = fmt.Sprintf("&pb.%s{%s: %s}", oneofType.Type.Message.Name, gogen.CamelCase(oneofType.Name), createDecodeTypeConversion(option))
+			option.TypeConversion = fmt.Sprintf("&%s.%s{%s: %s}", oneofType.Type.Message.Package.Qualifier(), oneofType.Type.Message.Name, gogen.CamelCase(oneofType.Name), createDecodeTypeConversion(option))
 			option.ZeroValue = getZeroValue(option)
 
 			oneofField.Options = append(oneofField.Options, option)
@@ -143,7 +154,7 @@ func NewBinding(i int, meth *svcdef.ServiceMethod) *Binding {
 		if field.Type.Message == nil && field.Type.Enum == nil && field.Type.Map == nil {
 			newField.IsBaseType = true
 		} else {
-			newField.GoType = "pb." + newField.GoType
+			newField.GoType = field.Type.Package().Qualifier() + "." + newField.GoType
 		}
 
 		// Modify GoType to reflect pointer or repeated status
@@ -155,13 +166,20 @@ func NewBinding(i int, meth *svcdef.ServiceMethod) *Binding {
 
 		// IsEnum needed for ConvertFunc and TypeConversion logic just below
 		newField.IsEnum = field.Type.Enum != nil
+		newField.IsMessage = field.Type.Message != nil
+		newField.IsWellKnownType = newField.IsMessage && isWellKnownType(field.Type.Message.ProtoName)
 		newField.ConvertFunc, newField.ConvertFuncNeedsErrorCheck = createDecodeConvertFunc(newField)
 		newField.TypeConversion = createDecodeTypeConversion(newField)
 
 		nBinding.Fields = append(nBinding.Fields, &newField)
+		if field == binding.Body {
+			nBinding.BodyField = &newField
+		}
 
-		// Enums are allowed in query/path parameters, skip warning
-		if newField.IsEnum {
+		// Enums and well-known types are allowed in query/path parameters, as
+		// are messages in the query, skip warning
+		if newField.IsEnum || newField.IsWellKnownType && !newField.Repeated ||
+			newField.IsMessage && !newField.Repeated && newField.Location == "query" {
 			continue
 		}
 
@@ -191,11 +209,25 @@ func GenServerTemplate(exec interface{}) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	encodeFuncSource, err := FuncSourceCode(encodePathParams)
-	if err != nil {
-		return "", err
+	for _, f := range []interface{}{
+		unescapePathParams,
+		encodePathParams,
+		encodeHTTPResponseBody,
+		wrapJSONField,
+		populateQueryParams,
+		protoField,
+		setQueryField,
+		parseQueryValue,
+		isWellKnownType,
+		parseWellKnownType,
+	} {
+		funcSource, err := FuncSourceCode(f)
+		if err != nil {
+			return "", err
+		}
+		code += "\n" + funcSource
 	}
-	code = FormatCode(code + encodeFuncSource)
+	code = FormatCode(code)
 	return code, nil
 }
 
@@ -204,10 +236,42 @@ func GenClientTemplate(exec interface{}) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	for _, f := range []interface{}{
+		escapePathVariable,
+		wrapJSONField,
+		addQueryParams,
+		formatQueryValue,
+		isWellKnownType,
+		formatWellKnownType,
+	} {
+		funcSource, err := FuncSourceCode(f)
+		if err != nil {
+			return "", err
+		}
+		code += "\n" + funcSource
+	}
 	code = FormatCode(code)
 	return code, nil
 }
 
+// Routes returns the bindings of the methods of h in the order their routes
+// are to be matched in. Those with a custom verb come first, as the verb
+// would otherwise be taken for part of the last segment of another route,
+// e.g. "/v1/{name}" matching "/v1/book:cancel".
+func (h *Helper) Routes() []*Binding {
+	var verbs, others []*Binding
+	for _, m := range h.Methods {
+		for _, b := range m.Bindings {
+			if b.Template != nil && b.Template.Verb != "" {
+				verbs = append(verbs, b)
+			} else {
+				others = append(others, b)
+			}
+		}
+	}
+	return append(verbs, others...)
+}
+
 // GenServerDecode returns the generated code for the server-side decoding of
 // an http request into its request struct.
 func (b *Binding) GenServerDecode() (string, error) {
@@ -242,45 +306,99 @@ func (b *Binding) GenClientEncode() (string, error) {
 //     []string{
 //         "\"\"",
 //         "\"sum\"",
-//         "fmt.Sprint(req.A)",
+//         "escapePathVariable(fmt.Sprint(req.A), false)",
 //     }
+//
+// The value of a variable is escaped as google.api.HttpRule prescribes, see
+// escapePathVariable. A wildcard outside of any variable has no field to take
+// its value from, so a "*" is sent as "-", and a "**" as an empty segment. A
+// custom verb is appended to the last section. A well-known type is given by
+// its string form, formatted beforehand into a variable named for its
+// LocalName, see PathWellKnownTypes.
 func (b *Binding) PathSections() []string {
-	path := b.PathTemplate
-	re := regexp.MustCompile(`{.+:.+}`)
-	path = re.ReplaceAllStringFunc(path, func(v string) string {
-		return strings.Split(v, ":")[0] + "}"
-	})
-
 	isEnum := make(map[string]struct{})
+	wellKnownTypes := make(map[string]*Field)
+	for _, v := range b.PathWellKnownTypes() {
+		wellKnownTypes[v.CamelName] = v
+	}
 	for _, v := range b.Fields {
 		if v.IsEnum {
 			isEnum[v.CamelName] = struct{}{}
 		}
 	}
 
-	rv := []string{}
-	parts := strings.Split(path, "/")
-	for _, part := range parts {
-		if len(part) > 2 && part[0] == '{' && part[len(part)-1] == '}' {
-			name := part[1 : len(part)-1]
-			parts := strings.Split(name, ".")
+	rv := []string{`""`}
+	for _, seg := range b.Template.Segments {
+		switch {
+		case seg.Variable != "":
+			parts := strings.Split(seg.Variable, ".")
 			for idx, part := range parts {
 				parts[idx] = gogen.CamelCase(part)
 			}
 			camelName := strings.Join(parts, ".")
 
+			convert := fmt.Sprintf("fmt.Sprint(req.%v)", camelName)
 			if _, ok := isEnum[camelName]; ok {
-				convert := fmt.Sprintf("fmt.Sprintf(\"%%d\", req.%v)", camelName)
-				rv = append(rv, convert)
-				continue
+				convert = fmt.Sprintf("fmt.Sprintf(\"%%d\", req.%v)", camelName)
 			}
-			convert := fmt.Sprintf("fmt.Sprint(req.%v)", camelName)
-			rv = append(rv, convert)
-		} else {
+			if f, ok := wellKnownTypes[camelName]; ok {
+				convert = f.LocalName + "Str"
+			}
+			rv = append(rv, fmt.Sprintf("escapePathVariable(%s, %t)", convert, seg.MultiSegment()))
+		case seg.Wildcard == "*":
+			rv = append(rv, `"-"`)
+		case seg.Wildcard == "**":
+			rv = append(rv, `""`)
+		default:
 			// Add quotes around things which'll be embeded as string literals,
 			// so that the 'fmt.Sprint' lines will be unquoted and thus
 			// evaluated as code.
-			rv = append(rv, `"`+part+`"`)
+			rv = append(rv, strconv.Quote(seg.Literal))
+		}
+	}
+	if b.Template.Verb != "" {
+		rv[len(rv)-1] += " + " + strconv.Quote(":"+b.Template.Verb)
+	}
+	return rv
+}
+
+// PathWellKnownTypes returns the fields of b of well-known types in the path,
+// whose string forms the client formats, see formatWellKnownType, into
+// variables named for their LocalName with a "Str" suffix.
+func (b *Binding) PathWellKnownTypes() []*Field {
+	var rv []*Field
+	for _, f := range b.Fields {
+		if f.IsWellKnownType && !f.Repeated && f.Location == "path" {
+			rv = append(rv, f)
+		}
+	}
+	return rv
+}
+
+// MessageQueryFields returns the names of the fields of b of a message type
+// in the query, other than well-known types, each of whose fields may be
+// given by a dotted query parameter.
+func (b *Binding) MessageQueryFields() []string {
+	var rv []string
+	for _, f := range b.Fields {
+		if f.IsMessage && !f.IsWellKnownType && !f.Repeated && f.Location == "query" {
+			rv = append(rv, f.QueryParamName)
+		}
+	}
+	return rv
+}
+
+// MultiSegmentVariables returns the field paths of the variables of the path
+// template of b which may match more than one segment, whose values keep
+// escaped slashes escaped.
+func (b *Binding) MultiSegmentVariables() []string {
+	if b.Template == nil {
+		return nil
+	}
+	var rv []string
+	for _, v := range b.Template.Variables() {
+		if v.MultiSegment() {
+			rv = append(rv, v.Variable)
 		}
 	}
 	return rv
@@ -301,7 +419,7 @@ if {{.LocalName}}StrArr, ok := {{.Location}}Params["{{.QueryParamName}}"]; ok {
 if err != nil {
 	return nil, errors.Wrap(err, fmt.Sprintf("Error while extracting {{.LocalName}} from {{.Location}}, {{.Location}}Params: %v", {{.Location}}Params))
 }{{end}}
-{{if or .Repeated .IsBaseType .IsEnum}}req.{{.CamelName}} = {{.TypeConversion}}{{end}}
+{{if or .Repeated .IsBaseType .IsEnum .IsWellKnownType}}req.{{.CamelName}} = {{.TypeConversion}}{{end}}
 `
 	mergedLogic := queryParamLogic + genericLogic + "}"
 	if f.Location == "path" {
@@ -392,6 +510,11 @@ func createDecodeConvertFunc(f Field) (string, bool) {
 		return fmt.Sprintf(fType, f.LocalName, f.LocalName+"Str"), true
 	}
 
+	if f.IsWellKnownType && !f.Repeated {
+		fType = "%s := &%s{}\nerr = parseWellKnownType(%s, %s)"
+		return fmt.Sprintf(fType, f.LocalName, f.GoType, f.LocalName+"Str", f.LocalName), true
+	}
+
 	// Use json unmarshalling for any custom/repeated messages
 	if !f.IsBaseType || f.Repeated {
 		// Args representing single custom message types are represented as
@@ -399,7 +522,7 @@ func createDecodeConvertFunc(f Field) (string, bool) {
 		// pointer as well. So we special case args of a single custom message
 		// type so that the variable LocalName is declared as a pointer.
 		singleCustomTypeUnmarshalTmpl := `
-err = json.Unmarshal([]byte({{.LocalName}}Str), req.{{.CamelName}})`
+err = json.Unmarshal([]byte({{.LocalName}}Str), &req.{{.CamelName}})`
 
 		errorCheckingTmpl := `
 if err != nil {
@@ -505,17 +628,55 @@ func getZeroValue(f Field) string {
 	}
 }
 
-// getMuxPathTemplate translates gRPC Transcoding path into gorilla/mux
-// compatible path template.
-func getMuxPathTemplate(path string) string {
-	re := regexp.MustCompile(`{.+=.+}`)
-	stars := regexp.MustCompile(`\*{2,}`)
-	return re.ReplaceAllStringFunc(path, func(v string) string {
-		v = strings.Replace(v, "=", ":", 1)
-		v = stars.ReplaceAllLiteralString(v, `.+`)
-		v = strings.ReplaceAll(v, "*", `[^/]+`)
-		return v
-	})
+// getMuxPathTemplate translates a gRPC Transcoding path template into a
+// gorilla/mux route template, matching the paths it matches as they are
+// escaped. Each variable is bound to a regular expression matching its
+// segments, unless it is bound to a single "*", which mux matches by default.
+// Wildcards outside of any variable are variables of their own, named "*"
+// followed by their index so as not to be taken for fields.
+func getMuxPathTemplate(t *svcdef.PathTemplate) string {
+	var parts []string
+	for i, seg := range t.Segments {
+		switch {
+		case seg.Variable != "" && len(seg.Segments) == 1 && seg.Segments[0].Wildcard == "*":
+			parts = append(parts, "{"+seg.Variable+"}")
+		case seg.Variable != "":
+			parts = append(parts, "{"+seg.Variable+":"+segmentsPattern(seg.Segments)+"}")
+		case seg.Wildcard != "":
+			parts = append(parts, fmt.Sprintf("{*%d:%s}", i, segmentsPattern([]*svcdef.PathSegment{seg})))
+		default:
+			parts = append(parts, seg.Literal)
+		}
+	}
+	rv := "/" + strings.Join(parts, "/")
+	if t.Verb != "" {
+		rv += ":" + t.Verb
+	}
+	return rv
+}
+
+// segmentsPattern returns a regular expression matching the slash separated
+// segments segs, which are literals and wildcards. A "**" following other
+// segments matches zero segments as well, along with the slash preceding it.
+func segmentsPattern(segs []*svcdef.PathSegment) string {
+	rv := ""
+	for i, seg := range segs {
+		sep := "/"
+		if i == 0 {
+			sep = ""
+		}
+		switch {
+		case seg.Wildcard == "*":
+			rv += sep + `[^/]+`
+		case seg.Wildcard == "**" && i == 0:
+			rv += `.*`
+		case seg.Wildcard == "**":
+			rv += `(?:/.*)?`
+		default:
+			rv += sep + regexp.QuoteMeta(seg.Literal)
+		}
+	}
+	return rv
 }
 
 // The 'basePath' of a path is the section from the start of the string till
@@ -45,6 +45,7 @@ func TestNewMethod(t *testing.T) {
 			rpc Sum(SumRequest) returns (SumReply) {
 				option (google.api.http) = {
 					get: "/sum/{a}"
+					response_body: "v"
 				};
 			}
 		}
@@ -56,8 +57,10 @@ func TestNewMethod(t *testing.T) {
 	binding := &Binding{
 		Label:        "SumZero",
 		PathTemplate: "/sum/{a}",
+		Template:     parsePath(t, "/sum/{a}"),
 		BasePath:     "/sum/",
 		Verb:         "get",
+		ResponseBody: "v",
 		Fields: []*Field{
 			&Field{
 				Name:                       "A",
@@ -110,13 +113,23 @@ func TestNewMethod(t *testing.T) {
 	}
 	binding.Parent = meth
 
-	newMeth := NewMethod(sd.Service.Methods[0])
+	newMeth := NewMethod(sd.Services[0].Methods[0])
 	if got, want := newMeth, meth; !reflect.DeepEqual(got, want) {
 		diff := gentesthelper.DiffStrings(spew.Sdump(got), spew.Sdump(want))
 		t.Errorf("got != want; methods differ: %v\n", diff)
 	}
 }
 
+// parsePath returns the parsed path template path.
+func parsePath(t *testing.T, path string) *svcdef.PathTemplate {
+	t.Helper()
+	tmpl, err := svcdef.ParsePathTemplate(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tmpl
+}
+
 func TestFuncSourceCode(t *testing.T) {
 	_, err := FuncSourceCode(PathParams)
 	if err != nil {
@@ -209,17 +222,37 @@ func Test_getMuxPathTemplate(t *testing.T) {
 		{
 			name: "**",
 			path: "/v1/shelves/{name=books/**}",
-			want: `/v1/shelves/{name:books/.+}`,
+			want: `/v1/shelves/{name:books(?:/.*)?}`,
 		},
 		{
 			name: "mixed * and **",
 			path: "/v1/{name=shelves/*/books/**}",
-			want: `/v1/{name:shelves/[^/]+/books/.+}`,
+			want: `/v1/{name:shelves/[^/]+/books(?:/.*)?}`,
+		},
+		{
+			name: "only **",
+			path: "/v1/{name=**}",
+			want: `/v1/{name:.*}`,
+		},
+		{
+			name: "literal with metacharacters",
+			path: "/v1/{name=v1.2/*}",
+			want: `/v1/{name:v1\.2/[^/]+}`,
+		},
+		{
+			name: "custom verb",
+			path: "/v1/{name=shelves/*}:undelete",
+			want: `/v1/{name:shelves/[^/]+}:undelete`,
+		},
+		{
+			name: "wildcards outside of variables",
+			path: "/v1/*/books/**",
+			want: `/v1/{*1:[^/]+}/books/{*3:.*}`,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := getMuxPathTemplate(tt.path); got != tt.want {
+			if got := getMuxPathTemplate(parsePath(t, tt.path)); got != tt.want {
 				t.Errorf("getMuxPathTemplate() = %v, want %v", got, tt.want)
 			}
 		})
@@ -228,43 +261,72 @@ func Test_getMuxPathTemplate(t *testing.T) {
 
 func TestBinding_PathSections(t *testing.T) {
 	tests := []struct {
-		name         string
-		pathTemplate string
-		want         []string
+		name string
+		path string
+		want []string
 	}{
 		{
-			name:         "simple",
-			pathTemplate: "/sum/{a}",
+			name: "simple",
+			path: "/sum/{a}",
 			want: []string{
 				`""`,
 				`"sum"`,
-				"fmt.Sprint(req.A)",
+				"escapePathVariable(fmt.Sprint(req.A), false)",
 			},
 		},
 		{
-			name:         "pattern",
-			pathTemplate: `/v1/{parent:shelves/[^/]+}/books`,
+			name: "pattern",
+			path: "/v1/{parent=shelves/*}/books",
 			want: []string{
 				`""`,
 				`"v1"`,
-				"fmt.Sprint(req.Parent)",
+				"escapePathVariable(fmt.Sprint(req.Parent), true)",
 				`"books"`,
 			},
 		},
 		{
-			name:         "dot notation",
-			pathTemplate: `/v1/{book.name:shelves/[^/]+/books/[^/]+}`,
+			name: "dot notation",
+			path: "/v1/{book.name=shelves/*/books/*}",
+			want: []string{
+				`""`,
+				`"v1"`,
+				"escapePathVariable(fmt.Sprint(req.Book.Name), true)",
+			},
+		},
+		{
+			name: "custom verb",
+			path: "/v1/{name}:cancel",
+			want: []string{
+				`""`,
+				`"v1"`,
+				`escapePathVariable(fmt.Sprint(req.Name), false) + ":cancel"`,
+			},
+		},
+		{
+			name: "wildcards outside of variables",
+			path: "/v1/*/books/**",
 			want: []string{
 				`""`,
 				`"v1"`,
-				"fmt.Sprint(req.Book.Name)",
+				`"-"`,
+				`"books"`,
+				`""`,
+			},
+		},
+		{
+			name: "trailing slash",
+			path: "/echo/",
+			want: []string{
+				`""`,
+				`"echo"`,
+				`""`,
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			b := &Binding{
-				PathTemplate: tt.pathTemplate,
+				Template: parsePath(t, tt.path),
 			}
 			if got := b.PathSections(); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("Binding.PathSections() = %v, want %v", got, tt.want)
@@ -272,3 +334,17 @@ func TestBinding_PathSections(t *testing.T) {
 		})
 	}
 }
+
+func TestHelper_Routes(t *testing.T) {
+	get := &Binding{Label: "GetZero", Template: parsePath(t, "/v1/{name}")}
+	cancel := &Binding{Label: "CancelZero", Template: parsePath(t, "/v1/{name}:cancel")}
+	h := &Helper{
+		Methods: []*Method{
+			{Name: "Get", Bindings: []*Binding{get}},
+			{Name: "Cancel", Bindings: []*Binding{cancel}},
+		},
+	}
+	if got, want := h.Routes(), []*Binding{cancel, get}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Routes() = %v, want %v", got, want)
+	}
+}
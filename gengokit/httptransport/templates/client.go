@@ -10,13 +10,19 @@ var ClientEncodeTemplate = `
 	func EncodeHTTP{{$binding.Label}}Request(_ context.Context, r *http.Request, request interface{}) error {
 		strval := ""
 		_ = strval
-		req := request.(*pb.{{GoName $binding.Parent.RequestType}})
+		req := request.(*{{$binding.Parent.RequestPkg.Qualifier}}.{{GoName $binding.Parent.RequestType}})
 		_ = req
 
 		r.Header.Set("transport", "HTTPJSON")
 		r.Header.Set("request-url", r.URL.Path)
 
 		// Set the path parameters
+		{{- range $field := $binding.PathWellKnownTypes}}
+		{{$field.LocalName}}Str, err := formatWellKnownType(req.{{$field.CamelName}})
+		if err != nil {
+			return errors.Wrap(err, "failed to encode req.{{$field.CamelName}}")
+		}
+		{{- end}}
 		path := strings.Join([]string{
 		{{- range $section := $binding.PathSections}}
 			{{$section}},
@@ -43,6 +49,18 @@ var ClientEncodeTemplate = `
 						values.Add("{{$field.QueryParamName}}", fmt.Sprint(v))
 					}
 					{{- end}}
+				{{else if and $field.IsWellKnownType (not $field.Repeated)}}
+					if req.{{$field.CamelName}} != nil {
+						strval, err = formatWellKnownType(req.{{$field.CamelName}})
+						if err != nil {
+							return errors.Wrap(err, "failed to encode req.{{$field.CamelName}}")
+						}
+						values.Add("{{$field.QueryParamName}}", strval)
+					}
+				{{else if and $field.IsMessage (not $field.Repeated)}}
+					if err := addQueryParams(values, "{{$field.QueryParamName}}", req.{{$field.CamelName}}); err != nil {
+						return errors.Wrap(err, "failed to encode req.{{$field.CamelName}}")
+					}
 				{{else if or (not $field.IsBaseType) $field.Repeated}}
 					tmp, err = json.Marshal(req.{{$field.CamelName}})
 					if err != nil {
@@ -81,7 +99,11 @@ var ClientEncodeTemplate = `
 		{{- if ne $binding.Verb "get" }}
 		// Set the body parameters
 		var buf bytes.Buffer
-		toRet := request.(*pb.{{GoName $binding.Parent.RequestType}})
+		{{- if $binding.BodyField}}
+		// The body is the {{$binding.BodyField.QueryParamName}} field of the request
+		toRet := req.{{$binding.BodyField.CamelName}}
+		{{- else}}
+		toRet := request.(*{{$binding.Parent.RequestPkg.Qualifier}}.{{GoName $binding.Parent.RequestType}})
 		{{- range $field := $binding.Fields -}}
 			{{if eq $field.Location "body"}}
 				{{/* Only set the fields which should be in the body, so all
@@ -89,6 +111,7 @@ var ClientEncodeTemplate = `
 				toRet.{{$field.CamelName}} = req.{{$field.CamelName}}
 			{{end}}
 		{{- end }}
+		{{- end }}
 		encoder := json.NewEncoder(&buf)
 		encoder.SetEscapeHTML(false)
 		if err := encoder.Encode(toRet); err != nil {
@@ -112,18 +135,20 @@ package http
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"reflect"
+	"strconv"
 	"strings"
 	"context"
 
-	{{ if len .HTTPHelper.Methods -}}
-		"github.com/gogo/protobuf/jsonpb"
-	{{- end }}
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
 
 	"github.com/go-kit/kit/endpoint"
 	httptransport "github.com/go-kit/kit/transport/http"
@@ -231,8 +256,15 @@ func CtxValuesToSend(keys ...string) httptransport.ClientOption {
 		if r.StatusCode != http.StatusOK {
 			return nil, errors.Wrapf(errorDecoder(buf), "status code: '%d'", r.StatusCode)
 		}
+		{{- with $method.Bindings}}{{with $field := (index . 0).ResponseBody}}
+
+			// The body is the {{$field}} field of the response
+			if buf, err = wrapJSONField({{printf "%q" $field}}, buf); err != nil {
+				return nil, errors.Wrap(err, "cannot parse non-json response body")
+			}
+		{{- end}}{{end}}
 
-		var resp pb.{{GoName $method.ResponseType}}
+		var resp {{$method.ResponsePkg.Qualifier}}.{{GoName $method.ResponseType}}
 		if err = jsonpb.UnmarshalString(string(buf), &resp); err != nil {
 			return nil, errorDecoder(buf)
 		}
@@ -9,12 +9,21 @@ var ServerDecodeTemplate = `
 	// body. Primarily useful in a server.
 	func DecodeHTTP{{$binding.Label}}Request(_ context.Context, r *http.Request) (interface{}, error) {
 		defer r.Body.Close()
-		var req pb.{{GoName $binding.Parent.RequestType}}
+		var req {{$binding.Parent.RequestPkg.Qualifier}}.{{GoName $binding.Parent.RequestType}}
 		buf, err := ioutil.ReadAll(r.Body)
 		if err != nil {
 			return nil, errors.Wrapf(err, "cannot read body of http request")
 		}
 		if len(buf) > 0 {
+			{{- with $field := $binding.BodyField}}
+				// The body is the {{$field.QueryParamName}} field of the request
+				if buf, err = wrapJSONField({{printf "%q" $field.QueryParamName}}, buf); err != nil {
+					return nil, httpError{errors.Wrap(err, "cannot parse non-json request body"),
+						http.StatusBadRequest,
+						nil,
+					}
+				}
+			{{- end}}
 			// AllowUnknownFields stops the unmarshaler from failing if the JSON contains unknown fields.
 			unmarshaller := jsonpb.Unmarshaler{
 				AllowUnknownFields: true,
@@ -31,7 +40,12 @@ var ServerDecodeTemplate = `
 			}
 		}
 
-		pathParams := encodePathParams(mux.Vars(r))
+		pathParams, err := unescapePathParams(mux.Vars(r)
+			{{- range $name := $binding.MultiSegmentVariables}}, {{printf "%q" $name}}{{end}})
+		if err != nil {
+			return nil, httpError{err, http.StatusBadRequest, nil}
+		}
+		pathParams = encodePathParams(pathParams)
 		_ = pathParams
 
 		queryParams := r.URL.Query()
@@ -48,6 +62,12 @@ var ServerDecodeTemplate = `
 				{{$field.GenQueryUnmarshaler}}
 			{{end}}
 		{{end}}
+
+		{{- with $binding.MessageQueryFields}}
+			if err := populateQueryParams(&req, queryParams{{range $name := .}}, {{printf "%q" $name}}{{end}}); err != nil {
+				return nil, httpError{err, http.StatusBadRequest, nil}
+			}
+		{{- end}}
 		return &req, err
 	}
 {{- end -}}
@@ -70,10 +90,13 @@ package svc
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"reflect"
 	"strconv"
 	"strings"
 	"io"
@@ -105,7 +128,9 @@ var (
 )
 
 // MakeHTTPHandler returns a handler that makes a set of endpoints available
-// on predefined paths.
+// on predefined paths. The responses of bindings with a response_body encode
+// only that field of the response, while the others are encoded by
+// responseEncoder, EncodeHTTPGenericResponse if nil.
 func MakeHTTPHandler(endpoints Endpoints, responseEncoder httptransport.EncodeResponseFunc, options ...httptransport.ServerOption) http.Handler {
 	if responseEncoder == nil {
 		responseEncoder = EncodeHTTPGenericResponse
@@ -118,17 +143,21 @@ func MakeHTTPHandler(endpoints Endpoints, responseEncoder httptransport.EncodeRe
 		}
 		serverOptions = append(serverOptions, options...)
 	{{- end }}
-	m := mux.NewRouter()
-
-	{{range $method := .HTTPHelper.Methods}}
-		{{range $binding := $method.Bindings}}
-			m.Methods("{{$binding.Verb | ToUpper}}").Path("{{$binding.PathTemplate}}").Handler(httptransport.NewServer(
-				endpoints.{{$method.Name}}Endpoint,
-				DecodeHTTP{{$binding.Label}}Request,
+	// Routes match the escaped path, so that an escaped slash is matched
+	// within a single segment
+	m := mux.NewRouter().UseEncodedPath()
+
+	{{range $binding := .HTTPHelper.Routes}}
+		m.Methods("{{$binding.Verb | ToUpper}}").Path({{printf "%q" $binding.PathTemplate}}).Handler(httptransport.NewServer(
+			endpoints.{{$binding.Parent.Name}}Endpoint,
+			DecodeHTTP{{$binding.Label}}Request,
+			{{if $binding.ResponseBody -}}
+				encodeHTTPResponseBody({{printf "%q" $binding.ResponseBody}}),
+			{{- else -}}
 				responseEncoder,
-				serverOptions...,
-			))
-		{{- end}}
+			{{- end}}
+			serverOptions...,
+		))
 	{{- end}}
 	return m
 }
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/metaverse/truss/gengokit/gentesthelper"
+	"github.com/metaverse/truss/svcdef"
 )
 
 // Test that rendering certain templates will ouput the code we expect. The
@@ -16,6 +17,7 @@ func TestGenClientEncode(t *testing.T) {
 	binding := &Binding{
 		Label:        "SumZero",
 		PathTemplate: "/sum/{a}",
+		Template:     parsePath(t, "/sum/{a}"),
 		BasePath:     "/sum/",
 		Verb:         "get",
 		Fields: []*Field{
@@ -75,7 +77,7 @@ func EncodeHTTPSumZeroRequest(_ context.Context, r *http.Request, request interf
 	path := strings.Join([]string{
 		"",
 		"sum",
-		fmt.Sprint(req.A),
+		escapePathVariable(fmt.Sprint(req.A), false),
 	}, "/")
 	u, err := url.Parse(path)
 	if err != nil {
@@ -106,6 +108,7 @@ func TestGenServerDecode(t *testing.T) {
 	binding := &Binding{
 		Label:        "SumZero",
 		PathTemplate: "/sum/{a}",
+		Template:     parsePath(t, "/sum/{a}"),
 		BasePath:     "/sum/",
 		Verb:         "get",
 		Fields: []*Field{
@@ -180,7 +183,11 @@ func DecodeHTTPSumZeroRequest(_ context.Context, r *http.Request) (interface{},
 		}
 	}
 
-	pathParams := encodePathParams(mux.Vars(r))
+	pathParams, err := unescapePathParams(mux.Vars(r))
+	if err != nil {
+		return nil, httpError{err, http.StatusBadRequest, nil}
+	}
+	pathParams = encodePathParams(pathParams)
 	_ = pathParams
 
 	queryParams := r.URL.Query()
@@ -211,3 +218,168 @@ func DecodeHTTPSumZeroRequest(_ context.Context, r *http.Request) (interface{},
 		t.Log(gentesthelper.DiffStrings(got, want))
 	}
 }
+
+// Test that a binding whose body is a single field encodes and decodes the
+// body as that field only.
+func TestGenBodyField(t *testing.T) {
+	book := &Field{
+		Name:           "book",
+		CamelName:      "Book",
+		LowCamelName:   "book",
+		QueryParamName: "book",
+		LocalName:      "BookCreateBook",
+		Location:       "body",
+		GoType:         "pb.Book",
+	}
+	binding := &Binding{
+		Label:        "CreateBookZero",
+		PathTemplate: "/books",
+		Template:     parsePath(t, "/books"),
+		BasePath:     "/books",
+		Verb:         "post",
+		Fields:       []*Field{book},
+		BodyField:    book,
+	}
+	binding.Parent = &Method{
+		Name:         "CreateBook",
+		RequestType:  "CreateBookRequest",
+		ResponseType: "Book",
+		Bindings:     []*Binding{binding},
+	}
+
+	client, err := binding.GenClientEncode()
+	if err != nil {
+		t.Fatalf("Failed to generate client code: %v", err)
+	}
+	if want := "toRet := req.Book\n"; !strings.Contains(client, want) {
+		t.Errorf("Generated client code does not contain %q:\n%s", want, client)
+	}
+
+	server, err := binding.GenServerDecode()
+	if err != nil {
+		t.Fatalf("Failed to generate server code: %v", err)
+	}
+	if want := `if buf, err = wrapJSONField("book", buf); err != nil {`; !strings.Contains(server, want) {
+		t.Errorf("Generated server code does not contain %q:\n%s", want, server)
+	}
+}
+
+// Test that a message in the query is decoded from dotted query parameters,
+// and encoded as them.
+func TestGenMessageQueryField(t *testing.T) {
+	defStr := `
+		syntax = "proto3";
+
+		package general;
+
+		import "github.com/metaverse/truss/deftree/googlethirdparty/annotations.proto";
+
+		message Filter {
+			string name = 1;
+			Filter author = 2;
+		}
+
+		message ListBooksRequest {
+			Filter filter = 1;
+		}
+
+		service Library {
+			rpc ListBooks(ListBooksRequest) returns (ListBooksRequest) {
+				option (google.api.http) = {
+					get: "/books"
+				};
+			}
+		}
+	`
+	sd, err := svcdef.NewFromString(defStr, gopath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	binding := NewMethod(sd.Services[0].Methods[0]).Bindings[0]
+
+	client, err := binding.GenClientEncode()
+	if err != nil {
+		t.Fatalf("Failed to generate client code: %v", err)
+	}
+	if want := `if err := addQueryParams(values, "filter", req.Filter); err != nil {`; !strings.Contains(client, want) {
+		t.Errorf("Generated client code does not contain %q:\n%s", want, client)
+	}
+
+	server, err := binding.GenServerDecode()
+	if err != nil {
+		t.Fatalf("Failed to generate server code: %v", err)
+	}
+	for _, want := range []string{
+		`err = json.Unmarshal([]byte(FilterListBooksStr), &req.Filter)`,
+		`if err := populateQueryParams(&req, queryParams, "filter"); err != nil {`,
+	} {
+		if !strings.Contains(server, want) {
+			t.Errorf("Generated server code does not contain %q:\n%s", want, server)
+		}
+	}
+}
+
+func TestGenWellKnownTypeField(t *testing.T) {
+	defStr := `
+		syntax = "proto3";
+
+		package general;
+
+		import "github.com/metaverse/truss/deftree/googlethirdparty/annotations.proto";
+		import "google/protobuf/timestamp.proto";
+		import "google/protobuf/wrappers.proto";
+
+		message ListBooksRequest {
+			google.protobuf.Timestamp since = 1;
+			google.protobuf.Int64Value limit = 2;
+		}
+
+		service Library {
+			rpc ListBooks(ListBooksRequest) returns (ListBooksRequest) {
+				option (google.api.http) = {
+					get: "/books/{since}"
+				};
+			}
+		}
+	`
+	sd, err := svcdef.NewFromString(defStr, gopath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	binding := NewMethod(sd.Services[0].Methods[0]).Bindings[0]
+	if fields := binding.MessageQueryFields(); len(fields) != 0 {
+		t.Errorf("MessageQueryFields() = %q, want none", fields)
+	}
+
+	client, err := binding.GenClientEncode()
+	if err != nil {
+		t.Fatalf("Failed to generate client code: %v", err)
+	}
+	for _, want := range []string{
+		`SinceListBooksStr, err := formatWellKnownType(req.Since)`,
+		`escapePathVariable(SinceListBooksStr, false),`,
+		`strval, err = formatWellKnownType(req.Limit)`,
+	} {
+		if !strings.Contains(client, want) {
+			t.Errorf("Generated client code does not contain %q:\n%s", want, client)
+		}
+	}
+
+	server, err := binding.GenServerDecode()
+	if err != nil {
+		t.Fatalf("Failed to generate server code: %v", err)
+	}
+	for _, want := range []string{
+		`err = parseWellKnownType(SinceListBooksStr, SinceListBooks)`,
+		`req.Since = SinceListBooks`,
+		`err = parseWellKnownType(LimitListBooksStr, LimitListBooks)`,
+		`req.Limit = LimitListBooks`,
+	} {
+		if !strings.Contains(server, want) {
+			t.Errorf("Generated server code does not contain %q:\n%s", want, server)
+		}
+	}
+	if strings.Contains(server, "populateQueryParams") {
+		t.Errorf("Generated server code populates well-known types by dotted query parameters:\n%s", server)
+	}
+}
@@ -1,5 +1,7 @@
 package httptransport
 
+import "github.com/metaverse/truss/svcdef"
+
 // Method contains the distillation of information within an
 // svcdef.ServiceMethod that's useful for templating http transport.
 type Method struct {
@@ -7,7 +9,11 @@ type Method struct {
 	// RequestType is the name of type of the Request, e.g. *EchoRequest
 	RequestType  string
 	ResponseType string
-	Bindings     []*Binding
+	// RequestPkg and ResponsePkg are the Go packages of RequestType and
+	// ResponseType; nil if that of the service definition
+	RequestPkg  *svcdef.GoPackage
+	ResponsePkg *svcdef.GoPackage
+	Bindings    []*Binding
 }
 
 // Binding contains the distillation of information within an
@@ -19,15 +25,26 @@ type Binding struct {
 	// label for this binding would be "SumZero". If it where the third
 	// binding, it would be named "SumTwo".
 	Label string
-	// PathTemplate is the full path template as it appeared in the http
-	// annotation which this binding refers to.
+	// PathTemplate is the gorilla/mux route template matching the escaped
+	// paths Template matches.
 	PathTemplate string
+	// Template is the path template as it appeared in the http annotation
+	// which this binding refers to.
+	Template *svcdef.PathTemplate
 	// BasePath is the longest static portion of the full PathTemplate, and is
 	// given to the net/http mux as the path for the route for this binding.
-	BasePath    string
-	Verb        string
-	Fields      []*Field
-	OneofFields []*OneofField
+	BasePath string
+	Verb     string
+	// ResponseBody is the name of the field of the response which is the body
+	// of an HTTP response, as in the definition; empty if the body is the
+	// whole response.
+	ResponseBody string
+	Fields       []*Field
+	OneofFields  []*OneofField
+	// BodyField is the field of Fields which is the body of an HTTP request,
+	// as set by a body naming a field; nil if the body holds every field
+	// outside of the path, or there is none.
+	BodyField *Field
 	// A pointer back to the parent method of this binding. Used within some
 	// binding methods
 	Parent *Method
@@ -69,6 +86,14 @@ type Field struct {
 	IsBaseType bool
 	// Protobuf Enums need to be handled uniquely when parsing queryparameters
 	IsEnum bool
+	// IsMessage is true if this field is of a message type. A message outside
+	// of the body is given either as JSON, or by a dotted query parameter for
+	// each of its fields.
+	IsMessage bool
+	// IsWellKnownType is true if this field is of a well-known type whose
+	// JSON form is a string or a scalar, such as a google.protobuf.Timestamp,
+	// which is given outside of the body in that form.
+	IsWellKnownType bool
 	// Repeated is true if this arg corresponds to a protobuf field which is
 	// given an identifier of "repeated", meaning it will represented in Go as
 	// a slice of it's type.
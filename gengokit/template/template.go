@@ -1,520 +1,55 @@
-// Code generated by go-bindata. DO NOT EDIT.
-// sources:
-// NAME-service/cmd/NAME/main.gotemplate (431B)
-// NAME-service/handlers/handlers.gotemplate (62B)
-// NAME-service/handlers/hooks.gotemplate (114B)
-// NAME-service/handlers/middlewares.gotemplate (75B)
-// NAME-service/svc/client/grpc/client.gotemplate (3.184kB)
-// NAME-service/svc/client/http/client.gotemplate (105B)
-// NAME-service/svc/config.gotemplate (328B)
-// NAME-service/svc/endpoints.gotemplate (4.25kB)
-// NAME-service/svc/server/run.gotemplate (3.27kB)
-// NAME-service/svc/transport_grpc.gotemplate (2.962kB)
-// NAME-service/svc/transport_http.gotemplate (106B)
+/*
+	Package template holds the templates of the files of a generated service,
+	stored in ./NAME-service and compiled into truss with go:embed.
 
+	Templates are named by their slash separated path within NAME-service,
+	e.g. "svc/server/run.gotemplate".
+*/
 package template
 
 import (
-	"bytes"
-	"compress/gzip"
-	"crypto/sha256"
-	"fmt"
-	"io"
-	"io/ioutil"
+	"embed"
+	"io/fs"
 	"os"
-	"path/filepath"
-	"strings"
-	"time"
-)
-
-func bindataRead(data []byte, name string) ([]byte, error) {
-	gz, err := gzip.NewReader(bytes.NewBuffer(data))
-	if err != nil {
-		return nil, fmt.Errorf("read %q: %w", name, err)
-	}
-
-	var buf bytes.Buffer
-	_, err = io.Copy(&buf, gz)
-	clErr := gz.Close()
-
-	if err != nil {
-		return nil, fmt.Errorf("read %q: %w", name, err)
-	}
-	if clErr != nil {
-		return nil, err
-	}
-
-	return buf.Bytes(), nil
-}
-
-type asset struct {
-	bytes  []byte
-	info   os.FileInfo
-	digest [sha256.Size]byte
-}
-
-type bindataFileInfo struct {
-	name    string
-	size    int64
-	mode    os.FileMode
-	modTime time.Time
-}
-
-func (fi bindataFileInfo) Name() string {
-	return fi.name
-}
-func (fi bindataFileInfo) Size() int64 {
-	return fi.size
-}
-func (fi bindataFileInfo) Mode() os.FileMode {
-	return fi.mode
-}
-func (fi bindataFileInfo) ModTime() time.Time {
-	return fi.modTime
-}
-func (fi bindataFileInfo) IsDir() bool {
-	return false
-}
-func (fi bindataFileInfo) Sys() interface{} {
-	return nil
-}
-
-var _cmdNameMainGotemplate = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x6c\x90\xbd\x6e\xc2\x30\x14\x85\xe7\xdc\xa7\x38\xca\x14\x86\xda\x3b\x12\x13\xe9\xc0\x52\x10\xd0\xee\x26\xb9\x76\xac\x06\x07\xd9\x4e\x10\x8a\xfc\xee\x55\x12\x5a\x31\x74\xf2\xcf\xf7\x5d\x9d\x63\x4b\x89\x6d\x57\x33\x0c\x3b\xf6\x2a\x72\x8d\xcb\x03\xd1\xf7\x21\x08\x94\x7b\x7c\xec\xcf\x78\x2f\x77\x67\x41\x52\xe2\xc8\xbe\x77\xce\x3a\xb3\x08\xb8\xdb\xb6\x45\x37\xb0\xbf\x7b\x1b\x19\xb1\xb1\x01\xda\xb6\x3c\xcb\x5f\xec\x83\xed\xdc\x1a\xe3\x28\x9e\xfb\x94\x5e\x00\x4a\x15\xf9\x95\x4e\xe7\x94\x88\x6e\xaa\xfa\x56\x86\x71\x55\xd6\x11\xd9\xeb\xad\xf3\x11\x05\x65\xb9\x6e\x95\xc9\x89\x32\x29\x71\x9e\xa2\x4e\xec\x07\x5b\x31\x65\xf9\x38\x8a\xdd\xec\x1d\x54\x6c\xf0\x96\x12\x64\x18\x2a\x19\xd8\x0f\xec\xf3\xff\x85\x46\xb9\xba\x65\x1f\x72\x5a\x11\xe9\xde\x55\x73\x60\xb1\xc2\x38\x27\x7c\xde\x6a\x15\x19\xaa\xae\x3d\x87\xc0\x01\x56\x23\x36\xfc\x40\xa3\x06\xc6\x85\xd9\xfd\xbd\x3c\xb2\x9b\x3e\x6d\xaa\x17\x28\x9b\x16\x71\x50\x3e\x70\xb1\x22\xca\x2a\x6d\xb0\xde\x60\xa9\x22\x4a\xd6\xaa\x6f\xe3\xb6\x73\xda\x9a\x05\x6e\xf0\xdb\x44\x9c\xf8\x49\x8a\x4a\x9b\x69\xf8\x39\x75\xec\xdd\x72\x93\xe8\x27\x00\x00\xff\xff\x27\x3a\x23\x3c\xaf\x01\x00\x00")
-
-func cmdNameMainGotemplateBytes() ([]byte, error) {
-	return bindataRead(
-		_cmdNameMainGotemplate,
-		"cmd/NAME/main.gotemplate",
-	)
-}
-
-func cmdNameMainGotemplate() (*asset, error) {
-	bytes, err := cmdNameMainGotemplateBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "cmd/NAME/main.gotemplate", size: 431, mode: os.FileMode(0644), modTime: time.Unix(1464111000, 0)}
-	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x55, 0x1e, 0xa2, 0x4f, 0x52, 0x2a, 0x32, 0x30, 0x4c, 0x20, 0x0, 0x3, 0x86, 0x8a, 0x7c, 0xdb, 0xa3, 0x40, 0x63, 0x8a, 0x70, 0x23, 0xb1, 0xdf, 0x38, 0x30, 0x9e, 0xde, 0x18, 0xa5, 0x76, 0x59}}
-	return a, nil
-}
-
-var _handlersHandlersGotemplate = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x04\xc0\xc1\x0d\x80\x20\x0c\x05\xd0\xbb\x53\xf4\x4c\xa2\x1d\xc6\x09\x88\x7c\xab\x11\xa9\x69\xeb\x89\xb0\x3b\xaf\x77\x4e\xb4\x03\x24\xba\x86\xfd\xee\x2c\x68\xa2\xcf\x1d\x7c\xe5\x56\x2a\x8c\x03\xef\x57\x73\xc0\x37\x51\x3a\xd5\xe8\xd0\x02\x4a\x3c\xc6\x32\x03\x00\x00\xff\xff\xd6\x21\xab\x2e\x3e\x00\x00\x00")
-
-func handlersHandlersGotemplateBytes() ([]byte, error) {
-	return bindataRead(
-		_handlersHandlersGotemplate,
-		"handlers/handlers.gotemplate",
-	)
-}
-
-func handlersHandlersGotemplate() (*asset, error) {
-	bytes, err := handlersHandlersGotemplateBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "handlers/handlers.gotemplate", size: 62, mode: os.FileMode(0644), modTime: time.Unix(1464111000, 0)}
-	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x1e, 0xcb, 0xd5, 0x72, 0x80, 0xc6, 0xf9, 0x82, 0x4b, 0xe0, 0x8b, 0x90, 0xb8, 0x9b, 0xbc, 0x5d, 0x8d, 0x12, 0xd4, 0x8e, 0x54, 0xf6, 0x72, 0xcb, 0xef, 0xf5, 0x12, 0xd0, 0xe1, 0xb8, 0x41, 0xc8}}
-	return a, nil
-}
-
-var _handlersHooksGotemplate = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x64\xcb\xb1\x0d\x03\x21\x0c\x46\xe1\x3e\x53\xb8\x46\x51\x3c\x4c\x26\x40\xe1\x8f\x89\x20\xf8\x84\x7d\x15\x62\xf7\x13\x05\xd5\xb5\x4f\xdf\x1b\x83\x03\xbd\x01\xf2\x7e\x9a\xb1\xa0\x89\x96\x9f\x73\x8e\x2d\x55\x74\x63\xc7\xff\xa8\xd1\x61\x9c\x55\xcb\x4b\x94\xbe\xda\x69\x57\xfa\x68\xc2\x93\xee\xdb\xc2\xb6\xf5\x42\x82\x46\x81\xe7\x7c\x5c\x01\x00\x00\xff\xff\x81\x74\x2e\x0e\x72\x00\x00\x00")
-
-func handlersHooksGotemplateBytes() ([]byte, error) {
-	return bindataRead(
-		_handlersHooksGotemplate,
-		"handlers/hooks.gotemplate",
-	)
-}
-
-func handlersHooksGotemplate() (*asset, error) {
-	bytes, err := handlersHooksGotemplateBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "handlers/hooks.gotemplate", size: 114, mode: os.FileMode(0644), modTime: time.Unix(1464111000, 0)}
-	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x96, 0x1a, 0x90, 0x39, 0xa9, 0x14, 0x59, 0xbf, 0x4, 0xb0, 0xfd, 0xa3, 0xaf, 0xdf, 0x86, 0x15, 0x67, 0xf0, 0xc7, 0xc, 0xe3, 0x4c, 0xfd, 0xd0, 0x87, 0xea, 0xb3, 0xa1, 0xd2, 0x85, 0x8a, 0x36}}
-	return a, nil
-}
-
-var _handlersMiddlewaresGotemplate = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x04\xc0\x41\x0e\x02\x21\x0c\x05\xd0\xbd\xa7\xe8\x9a\x44\x7b\x18\x4f\x40\xec\xb7\x12\x81\x4e\xda\x4e\x66\x41\xb8\xfb\xbc\xb5\xb8\xd0\x1b\x20\xb5\x67\xfa\x19\xc1\x8a\xa9\xf6\x6f\xc9\xbf\x3a\xa5\xc3\x83\x13\xe3\xe8\x35\x11\x3c\x9a\x48\xc7\x55\x1d\xf1\x52\xa3\xaf\x39\x7d\x4c\x40\x85\xf7\x7e\xdc\x01\x00\x00\xff\xff\xcf\x9e\xe9\x81\x4b\x00\x00\x00")
-
-func handlersMiddlewaresGotemplateBytes() ([]byte, error) {
-	return bindataRead(
-		_handlersMiddlewaresGotemplate,
-		"handlers/middlewares.gotemplate",
-	)
-}
-
-func handlersMiddlewaresGotemplate() (*asset, error) {
-	bytes, err := handlersMiddlewaresGotemplateBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "handlers/middlewares.gotemplate", size: 75, mode: os.FileMode(0644), modTime: time.Unix(1464111000, 0)}
-	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xcc, 0xfe, 0x9d, 0x1a, 0xaf, 0x47, 0xe8, 0x97, 0x82, 0x24, 0x50, 0x17, 0xb4, 0x49, 0x73, 0x3b, 0x68, 0xb7, 0xe5, 0x3a, 0x3d, 0xb6, 0x15, 0x9d, 0xb1, 0x8f, 0xc4, 0x27, 0xaf, 0xa7, 0x3c, 0xc1}}
-	return a, nil
-}
-
-var _svcClientGrpcClientGotemplate = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x56\x4d\x6f\xdb\x38\x10\x3d\x8b\xbf\x62\xd6\x08\x16\x52\xa0\xd0\xf7\x2c\x7c\xa9\xd3\x2d\xba\xd8\xa6\x46\x1a\x74\x0f\x45\x51\x30\xd4\x58\x26\x2c\x93\x2a\x49\x3b\x31\x04\xfd\xf7\xc5\x90\x94\x23\x27\x8e\xdb\x43\x10\x8b\xf3\x38\x1f\xef\x0d\x39\x9c\x4e\x61\x6e\x2a\x84\x1a\x35\x5a\xe1\xb1\x82\x87\x3d\x78\xbb\x75\x8e\xc3\xcd\x67\xb8\xfd\x7c\x0f\xef\x6f\x3e\xde\x73\x36\x9d\xc2\x1d\xda\xad\xd6\x4a\xd7\x11\x00\x8f\xaa\x69\xc0\xec\xd0\x3e\x5a\xe5\x11\xfc\x4a\x39\x58\xaa\x06\x03\xf8\x2b\x5a\xa7\x8c\xbe\x86\xae\xe3\xe9\x77\xdf\x8f\x0c\x70\x23\x3c\x8e\xad\xf4\xdd\xf7\x8c\x20\x0b\x21\xd7\xa2\x46\xa8\x6d\x2b\xa1\xb5\x66\xa7\x2a\x74\x20\xa0\xbe\x5b\xcc\x41\x36\x0a\xb5\x87\xa5\xb1\xe0\x57\x48\x0e\xbe\xa0\xdd\x29\x89\xfc\x56\x6c\xb0\xef\xc1\xa5\x4f\xd6\x8e\xdc\x30\xa6\x36\xad\xb1\x1e\x72\x96\x4d\xa4\xd1\x1e\x9f\xfc\x84\x65\x93\xda\x98\xba\x41\x5e\x9b\x46\xe8\x9a\x1b\x5b\x4f\x09\xfd\xb6\x65\xba\x41\x2f\x2a\xe1\x45\x80\x28\xbf\xda\x3e\x70\x69\x36\xd3\x76\x5d\x4f\xd1\x5a\x63\xdd\x84\x1d\x5b\x6a\x73\xb5\x56\x7e\x4a\x7f\xa8\xab\xd6\x28\x4d\x81\xc9\x97\xb7\x42\xbb\x90\xd4\x1b\xf8\x03\x20\x25\xc5\xb2\xe9\x14\xee\x89\xe6\x54\x32\xcb\x26\x5d\xc7\x3f\x86\xca\x16\xc2\xaf\xe0\xaa\xef\x61\xea\x76\x54\x40\xfb\x00\x64\x5c\xbc\x3b\x36\x4f\x58\x11\x38\xbe\xc5\x47\xb0\xe8\xb7\x56\x3b\x10\x7a\x20\x0d\x1e\x84\x5c\xc7\x26\x38\xa6\x5b\x1a\xad\x51\x7a\x65\x34\x87\x8f\x1e\x94\x23\xf2\xc9\x8f\x45\xd7\x1a\xed\xd4\x83\x6a\x94\xdf\x83\x59\x06\x55\xa4\x68\x1a\xb4\xe0\x0d\x54\x4a\x34\x25\x08\x5d\x41\x23\x3c\x5a\x90\x8d\x71\x58\x46\xd0\xb3\x4f\xb6\xdc\x6a\x49\x39\xe5\xb4\x08\x97\x54\x2f\x9f\x87\xd0\x73\xa3\x75\x09\xa6\x25\x9c\x03\xce\xd3\xf2\xe7\xb0\x50\x40\xde\x3e\xf0\x57\x3d\x40\x5f\x68\x4b\x08\x8a\x14\xd0\xb1\x6c\x27\x2c\x48\x99\xaa\x99\x1b\xbd\x54\x35\x63\x19\x35\xd1\x8f\x12\x96\x70\x3d\x03\x2b\x74\x8d\x87\x38\x1d\xcb\x32\xb4\x96\x0c\xcb\xfc\x4f\x29\x0b\x96\x65\x6a\x49\x0e\xe1\x8f\x19\x68\xd5\x04\x44\x16\x19\xa4\xef\x14\xcc\xf1\xff\xac\x68\x73\xb4\xb6\x84\x89\x14\x5a\x1b\x0f\xa2\x6d\x9b\x7d\xf2\x3c\x21\x47\x3d\xcb\x7a\xc6\x32\x39\x2a\xc4\x51\xa4\x6f\xdf\x8f\xda\xe2\xa8\x52\x0a\x77\xca\xfa\x0e\x97\xc6\x62\x4e\xc9\xa4\xb6\xfe\x2a\x9a\x2d\xba\x7b\xf3\xe1\x6e\x31\xff\x94\xba\x35\x97\x92\xaf\x50\x54\x68\x5d\x51\x94\x14\x3e\xeb\xba\x2b\x78\x54\x7e\x05\x17\x1e\x29\x38\xef\x7b\x96\x8d\x56\xdb\x75\x4d\x64\x92\xe9\xc2\x23\x4f\x67\x32\xf2\x4b\xd1\x08\x19\x39\xbb\x50\x03\x68\x50\xe1\x13\xfa\x95\xa9\x5c\x04\x06\xee\xbb\xee\xde\xfc\x6b\x1e\xd1\xc2\x85\x4a\x22\xbd\x4f\xa7\x01\x86\x63\xc1\x87\x95\xb0\x2b\xf0\x4b\x61\xde\xde\x38\x83\x63\x46\x6e\xf1\x31\x92\x92\xc7\xbd\xc4\x88\x2e\xd3\xef\x49\xd7\x0d\x35\xf5\x3d\xef\xba\x71\xbe\x71\x71\x32\x86\xaa\x97\x8b\xef\xb5\x34\x15\x12\xa9\x23\xeb\x1d\xfe\xdc\xa2\xf3\x03\xe6\x06\x4f\x62\xc2\x09\xc1\x01\x14\x1a\xf6\x83\x09\xe4\x5e\x28\x3e\x98\xef\xf7\xed\x90\x48\xd7\x0f\xd8\xa3\x16\xe1\x9c\xa7\xf5\xe2\x40\x55\x5e\x84\x95\xa4\x08\xea\x2a\xa9\x98\x7e\x0d\x3f\xd8\xd0\xa9\x6e\x27\x0f\x7b\x5d\x47\x80\xb1\x86\x2f\x05\xa4\x0b\x23\xb8\x7b\xc5\xfd\x35\x00\x9c\x13\xb5\x7c\x8e\x9d\xf5\x25\x1d\x10\x16\xef\x76\x22\x07\xa2\x4a\x10\xe9\x62\xe7\x73\x88\x53\xe3\x2c\xb3\x74\x1d\x09\x38\xbe\x2d\x79\xdc\x31\x40\xfe\xa6\xfb\xc5\xaf\x44\xb8\xc9\x76\x68\xbd\x03\x41\x7e\xc3\x1d\x77\xa2\x0e\xb0\x48\x87\xd6\x1b\x10\xb0\x75\x68\xaf\x2a\xb3\x11\x4a\xbf\x01\x8d\x31\x38\x2c\xac\xda\x08\xab\x9a\x3d\xed\x59\x6e\x1b\x50\x1a\x44\xba\x74\xd2\x1d\x77\xb6\x90\xfc\x07\xa4\x43\xcc\xe7\xf1\x7f\x19\x5a\xfc\x2e\x24\xa3\xb4\x47\xbb\x14\x12\xbb\xbe\x80\x7c\xf4\x35\xbe\xe8\x62\xde\xd7\xb3\xe7\x7d\x3c\xbf\xfc\x75\xcb\x15\x87\x0e\x09\x0e\x06\xc5\x0e\xfd\xf3\x42\xb9\x78\x18\x7e\x4b\xb9\x73\xe7\xe6\xa4\x70\x71\x43\x42\xbc\xa5\xdb\xaf\x35\x89\x01\x82\x80\x67\x44\x0e\xa8\xdf\x12\xee\x5c\x1d\xa7\x74\x1b\x32\xf8\x4d\xd5\x7e\x86\x19\x94\xf2\x39\xa1\x58\x30\xbc\x21\xd8\xcf\x57\x72\x31\xbf\x6f\xf1\x68\xda\x81\xf3\x76\x2b\x3d\x05\x4b\x83\x00\xbe\x7d\x77\xde\x2a\x5d\xa7\x93\x39\x9e\x36\x51\x18\xaa\x3b\x7c\x05\x01\x36\xa6\x52\x4b\x85\x2e\xce\xee\xc3\xb3\x80\x26\x69\x88\x76\xb4\x9f\xb6\xe6\x97\xe3\x04\x8a\x58\x2e\x8b\x6c\xce\xfd\xd3\x30\xa7\xbe\xa0\xae\xf2\x35\xee\xc3\x70\x8f\x19\x15\xc7\xce\xba\x43\xad\xc1\xad\x81\x53\x8e\xc3\x40\x36\xc3\x94\x83\x19\x90\x4b\x36\x1e\xd1\x34\xf6\xfa\x14\xff\xdc\xac\x0c\xb9\x0c\xe4\x14\x70\x6a\xea\x8e\xbb\xf3\x45\x76\xd2\x3f\xbd\x6e\x86\x4d\x05\x97\xc3\xcb\x91\x7f\xba\x29\x5e\x22\x42\xf2\x34\x27\x5b\xa1\xc6\xca\x64\xc3\x13\x65\xfd\xfc\x44\x09\xe9\x85\xe9\xa8\x96\xb0\x2b\xc1\x04\x9b\xf4\x4f\x3c\x54\x93\xaf\x0b\x9e\xa7\xdc\xff\x22\x63\x1c\xa4\xd1\xf1\x8c\x1e\x23\xc4\x77\xf8\x2c\x61\x5d\xc2\x2e\x4c\x90\x3e\x3c\x4b\xe2\x23\x27\x42\xc7\xcf\x9c\xcb\x4d\x05\x33\x38\x14\xf0\x8f\x51\x3a\xbf\xdc\x54\xe5\xf3\xd2\x82\xf6\x44\xaf\x9c\xf3\xa2\x18\xdc\x25\x66\xa4\x7f\x8a\xec\xff\x1f\x00\x00\xff\xff\x00\xce\x0e\xa6\x70\x0c\x00\x00")
-
-func svcClientGrpcClientGotemplateBytes() ([]byte, error) {
-	return bindataRead(
-		_svcClientGrpcClientGotemplate,
-		"svc/client/grpc/client.gotemplate",
-	)
-}
-
-func svcClientGrpcClientGotemplate() (*asset, error) {
-	bytes, err := svcClientGrpcClientGotemplateBytes()
-	if err != nil {
-		return nil, err
-	}
+	"path"
 
-	info := bindataFileInfo{name: "svc/client/grpc/client.gotemplate", size: 3184, mode: os.FileMode(0644), modTime: time.Unix(1464111000, 0)}
-	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x63, 0x72, 0x1f, 0xe5, 0x3a, 0x45, 0x1, 0x91, 0xd8, 0x5b, 0xa8, 0x47, 0x45, 0x45, 0x98, 0xee, 0x0, 0xf5, 0xc1, 0x3c, 0x43, 0xf0, 0x86, 0x3c, 0xec, 0xbe, 0x2d, 0x84, 0xed, 0x1a, 0x17, 0x6c}}
-	return a, nil
-}
-
-var _svcClientHttpClientGotemplate = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x2c\xcb\xb1\x0d\xc2\x30\x10\x05\xd0\x3e\x53\x5c\x1d\x09\xdf\x10\x34\x29\x91\xc8\x02\x56\xf8\x98\x88\xc3\x67\x9d\x3f\x95\xe5\xdd\x69\x18\xe0\x8d\xa1\xab\xdc\x01\x29\x7e\x61\x7c\x7b\xd7\x82\x5a\xfc\x7d\x52\x5f\x64\x63\xe4\xda\x9b\x07\x95\xf8\x34\xcb\x44\x2a\x2e\x4f\x0f\x39\xfc\x01\x59\x75\xce\x65\x8c\x23\x9b\x49\xda\xf6\xfd\xb6\xc1\x1a\x22\x5d\xed\x44\xe5\xfe\x27\x92\xe6\x5c\x7e\x01\x00\x00\xff\xff\x0b\x3c\x4c\x9e\x69\x00\x00\x00")
-
-func svcClientHttpClientGotemplateBytes() ([]byte, error) {
-	return bindataRead(
-		_svcClientHttpClientGotemplate,
-		"svc/client/http/client.gotemplate",
-	)
-}
-
-func svcClientHttpClientGotemplate() (*asset, error) {
-	bytes, err := svcClientHttpClientGotemplateBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "svc/client/http/client.gotemplate", size: 105, mode: os.FileMode(0644), modTime: time.Unix(1464111000, 0)}
-	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xa1, 0xf0, 0x36, 0xf9, 0x16, 0xea, 0x9d, 0x4e, 0x73, 0x64, 0xc5, 0xad, 0xb3, 0x1b, 0x4, 0xe, 0xd8, 0xc8, 0x1e, 0xf7, 0x7a, 0x39, 0x40, 0x4c, 0xb2, 0x12, 0x83, 0x35, 0xca, 0x82, 0x6f, 0xd0}}
-	return a, nil
-}
-
-var _svcConfigGotemplate = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x84\xd0\xc1\x4a\x03\x41\x0c\x06\xe0\xf3\xe6\x29\x42\x4f\x7a\xb0\xf3\x0c\x52\xb5\x1e\x4b\xe9\x0b\x4c\x67\xb2\xb3\xa1\x36\x33\x26\x99\x82\x88\xef\x2e\xab\xac\x20\x0a\x0d\xe4\xf2\xf3\x25\x81\xb4\x98\x4e\xb1\x10\xda\x25\x01\xf0\xb9\x55\x75\xbc\x81\x61\x72\x6f\xae\x51\xec\x2b\x58\x15\xf6\xa9\x1f\xd7\xa9\x9e\x43\xa9\x77\x27\xf6\x30\xf7\x0f\x08\x33\x5f\xc1\x2d\x40\x08\xb8\xa9\x32\x72\xc1\x54\xc5\x23\x8b\xa1\x4f\x84\x4a\xaf\x9d\x95\x32\x8e\x4c\x2f\xd9\x70\xac\x8a\xda\x45\x58\x0a\x46\x34\xd2\x0b\x29\xf8\x5b\xa3\x65\xda\x5c\x7b\x72\x7c\x87\xe1\xf9\x70\xd8\xdd\xe7\xac\xf8\xb7\xcc\x95\xa5\xc0\xf0\x40\xc7\x5e\xfe\x37\x0b\xd9\xee\x77\x9b\x2b\x5b\xb6\x24\xa4\x9c\xe6\x7b\x7b\xb2\x56\xc5\xe8\x51\x52\xcd\xa4\xf8\xeb\x1b\xeb\xef\x74\x31\x4f\x5d\x12\x7c\xc0\x67\x00\x00\x00\xff\xff\xc0\x70\xbf\x81\x48\x01\x00\x00")
-
-func svcConfigGotemplateBytes() ([]byte, error) {
-	return bindataRead(
-		_svcConfigGotemplate,
-		"svc/config.gotemplate",
-	)
-}
-
-func svcConfigGotemplate() (*asset, error) {
-	bytes, err := svcConfigGotemplateBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "svc/config.gotemplate", size: 328, mode: os.FileMode(0644), modTime: time.Unix(1464111000, 0)}
-	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xe9, 0x39, 0x2b, 0x91, 0x93, 0x52, 0x93, 0xef, 0x7f, 0x8c, 0x4c, 0x5c, 0x3b, 0x9, 0x2d, 0xf7, 0xf2, 0x5, 0x0, 0x79, 0xcf, 0x24, 0xf6, 0xc7, 0x3d, 0x6f, 0x63, 0xb1, 0x3d, 0x81, 0xb1, 0x3b}}
-	return a, nil
-}
-
-var _svcEndpointsGotemplate = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xec\x57\x5d\x6f\xdb\x38\x16\x7d\x96\x7e\xc5\xad\x90\x45\xec\x42\x61\xde\x53\xe4\x61\xb7\xcd\xee\x06\xd8\x7e\xa0\xc9\xee\x3e\x14\x45\x41\x4b\x57\x16\x11\x8a\x64\x49\xca\x8e\x47\xd0\x7f\x1f\x5c\x52\x92\xe5\x5a\xed\x74\xe6\x71\x30\x0f\x41\x6c\x7e\x1c\x9e\x7b\xce\xbd\x97\xf4\xf5\x35\xbc\xd6\x25\xc2\x16\x15\x5a\xee\xb1\x84\xcd\x01\xbc\x6d\x9d\x63\xf0\xe6\x3d\xbc\x7b\xff\x08\x77\x6f\xee\x1f\x59\x7a\x7d\x0d\x1f\xd1\xb6\x4a\x09\xb5\x8d\x0b\x60\x2f\xa4\x04\xbd\x43\xbb\xb7\xc2\x23\xf8\x5a\x38\xa8\x84\xc4\xb0\xf8\x7f\x68\x9d\xd0\xea\x06\xba\x8e\x0d\x9f\xfb\x7e\x36\x01\x6f\xb8\xc7\xf9\x2c\x7d\xef\xfb\x34\x35\xbc\x78\xe2\x5b\x04\xb7\x2b\x52\x5a\xff\x38\xc2\x42\xa1\x95\xe7\x42\x39\x68\xd0\xd7\xba\x74\xe0\x35\x34\xfc\x09\x41\xa8\x52\xec\x44\xd9\x72\x09\xa8\x4a\xa3\x85\xf2\x0e\x2a\xab\x1b\x70\x68\x77\xa2\x40\x97\x13\x92\xc5\xaf\x2d\x3a\x0f\x5c\x95\x60\xd1\x19\xad\x1c\x82\x3f\x18\x0c\x48\xb4\x94\x82\xd0\x0e\x8f\x28\x39\x70\x07\x7b\x94\x92\xfe\xa3\x2a\x74\x89\xd6\x11\x00\xe1\x95\x38\x7c\xaf\xb4\x1d\x36\x06\xb4\x3c\x0c\x70\x12\xa7\x02\xdd\x5a\x70\xad\x31\xda\x92\xb8\xde\x72\xe5\xe8\x33\x1d\x27\xb8\x14\xbf\x70\x2f\xb4\x22\xb4\x4a\xdb\x86\x7b\xc7\xd2\x54\x34\x61\xc5\x2a\x4d\xb2\xaa\xf1\x59\x9a\x64\x14\x39\x3e\xfb\x2c\x4d\x93\x6c\x2b\x7c\xdd\x6e\x58\xa1\x9b\xeb\xad\xbe\x7a\x12\xfe\x9a\xfe\x46\xc6\xb4\xc4\x6c\x20\xeb\x3a\xf6\xe1\x1f\xf7\x01\xe8\x03\xf7\x35\x5c\xf5\x7d\x96\xae\x83\xa0\x77\x93\x44\x85\x96\x12\x0b\xef\x46\xae\xbe\x9e\x85\x0e\xbe\xe6\x1e\x0a\xdd\x18\x0a\x8c\x2b\xe0\x65\x39\xea\xc9\xe0\xde\x5f\x3a\x02\x6b\x90\x2b\x4f\xf2\x6d\x10\x5a\x87\x25\xe9\xc4\xa1\x46\x69\xd0\x82\xf3\xb6\x2d\x7c\x4e\xd3\xc3\x51\xcb\x27\x09\xe5\x35\x70\x82\x73\x42\x6d\x25\x82\xe1\x96\x37\xe8\xd1\x52\x2a\xd1\xf8\xbd\x02\x1e\x1d\xb2\x39\x08\x7f\xe9\xe8\xb0\xaa\x95\x41\xe9\xaa\x55\x05\xa9\x38\x50\x56\x48\x42\x6b\xd0\x26\x64\x34\x68\xda\x6b\xd0\x5e\x8d\x07\x12\xe0\x86\x3b\xe1\x18\xfc\x53\x5b\xc0\x67\xde\x18\x89\x39\x1c\x74\x0b\x8d\xd8\xd6\x1e\x0c\x77\xe4\xf2\x4c\x2a\x22\x38\x1d\x14\xcf\x31\x56\x97\x6d\x81\x41\x06\xae\xa0\xf6\xde\xb0\x7f\x73\x55\x4a\xe2\xb8\x17\xbe\x06\xe4\x45\x3d\x24\x2b\xac\xc6\xd3\xd7\xb0\x17\x16\x4b\x68\x4d\x04\x75\x06\x0b\x51\x89\x02\x0c\xf7\x35\x83\xd5\x7d\xe0\x27\x1c\xe1\x6f\xf8\x46\x1e\x80\x43\x23\x9c\x8f\x89\x0e\x25\x3a\xb1\x55\xb4\x55\xa8\x9d\x7e\xc2\x20\xe5\x43\xb4\x65\x2a\x8c\x40\x11\x4f\xcd\x8e\x66\x10\xc4\xa8\x24\x5b\xcf\xd5\x2d\xa4\x40\xe5\x4f\xd5\x9d\x19\x77\xac\x31\x79\xa0\x4a\x8c\x70\x58\xfe\xc8\x46\xaa\x86\xa8\x95\x20\x85\x1b\x8c\x69\x75\xe4\x2b\x94\x47\x5b\x71\x4a\xa8\x65\x27\x08\x6c\x3a\x6c\xb9\xce\x5b\x17\x3b\xd2\x50\x58\xd7\xc1\x87\x77\xb8\x7f\x3d\xc4\x53\xe8\x66\x23\x54\xd0\xa9\x19\x28\xce\x8c\xcd\x87\x6e\xe0\x5b\xab\x40\x84\x4c\x26\x82\x05\x97\x12\x6d\x4c\xe6\x81\x2c\x4b\x43\x38\x67\x82\x76\x69\xd7\x59\xae\xb6\x08\x17\x02\x6e\x6e\x81\x8d\xeb\xdf\x46\x33\xfa\x3e\x4d\xba\xee\x42\xb0\x77\xbc\xc1\xbe\x1f\xf7\x03\xc0\x14\x04\x1b\x07\xd3\xae\xbb\xa2\xd1\xbe\x4f\xfb\xd3\x5a\xfd\x89\x43\x28\x3b\x61\x35\x63\xb8\x86\xd9\xb9\xab\xc2\x3f\xc3\xd0\x47\xd8\xeb\xf8\x3f\xa7\x6c\x78\x69\x36\xac\xeb\xfe\xa5\x69\x19\x5c\x08\xf6\x31\x76\xc9\xc7\x83\xc1\x61\xeb\x1a\x56\xe7\x8b\x62\xfb\x9c\xad\xca\x01\xad\xd5\x76\x0d\x5d\x9a\x24\x63\x7b\x0d\x83\x44\x18\xd9\x82\x06\xc4\x89\x38\xac\xd3\x24\x11\x55\x58\xfa\xe2\x16\x94\x90\x01\x23\x19\x5c\x51\x42\x06\x98\x34\x49\xfa\x74\x1a\x1d\x4f\x60\x3f\xc3\x6d\x9d\x13\x4a\x9a\xf4\x69\xd7\x45\x79\x49\xdc\xb7\x54\x52\x73\x85\x43\xd1\x5e\x78\x0c\x0a\x47\xdf\xe6\xa2\x5f\x78\x5c\xd2\x3d\x0a\x4f\x60\x4b\x21\x3a\x08\xf4\xe6\x7b\xe3\x8a\x87\x50\x83\xeb\xf3\x24\x38\x09\x9e\xb0\x97\xad\x1b\x6f\xb3\xa9\x86\x3a\x32\x6a\xba\xd7\x66\xc3\xd1\x84\x99\x3b\x84\xfe\x95\x22\x1a\x30\x96\x34\x3c\x4b\x82\xb0\x6f\x37\x19\xea\xd8\x37\xc9\x15\x18\xc5\x55\x0b\x5e\x2e\xb9\x19\xfd\x9c\x66\x76\x83\x49\x71\x38\xa8\x1f\xbd\x9a\x7b\xf6\x7f\xcb\xcd\xdf\xa5\xbc\x7b\x2e\xd0\x78\xd8\x5b\x6e\x5c\x6c\xb3\x93\x7a\x95\x40\x59\xd2\x1d\x33\xd4\xe7\xb1\x60\x83\xbd\xa1\x3f\x2d\x5c\x9c\xec\xad\x28\x4b\x89\x7b\x6e\xe3\xfb\xe5\xbf\x6e\x7c\xd1\xd0\x5d\x6e\x8c\x3c\x50\x9b\xa1\xd6\xe9\x09\xbc\x99\x56\x87\xbb\x01\x77\x68\x0f\x93\x95\x54\x56\xd4\x45\xc6\xdb\x92\xf0\xde\x1b\xba\x39\xa8\x7b\xe6\xb3\xe6\x55\x70\x45\x37\x27\xdd\x37\x58\xd2\xb6\xcd\x01\x14\x79\x10\x6f\x54\x7c\x2e\x64\x5b\x62\x19\x1f\x33\x1b\x24\x0a\x14\xb3\xc1\x92\x9d\xa9\xb1\x3a\x72\xca\x21\x7b\xf0\xdc\xb7\x2e\xcb\x21\xfb\x20\xd4\x36\x5b\xa7\x63\x7b\x78\x39\xeb\x0f\xdf\xdb\x0f\x0b\xaa\xe4\x47\x36\x8c\x31\xe7\xad\x50\xdb\x90\x4e\x42\x0d\xc3\x37\xb7\xd0\x70\xf3\x29\x4e\x7d\x8e\xf2\x77\x3d\xd9\x4f\x6d\xed\xb7\xda\x57\x92\x64\xb3\x8c\xca\x6e\xa0\xeb\xf3\x61\x6b\xb4\x3f\xe9\xd3\x34\x21\x37\xbe\x10\x95\x90\xbe\x01\x72\xa2\xd5\xc5\x36\xf2\x25\x07\xfd\x44\xd3\x23\xb1\x4f\xf8\xfc\xf9\x15\xbc\xd0\x4f\x31\x15\x0d\x57\xa2\x58\x55\x8d\x67\x0f\xc6\x0a\xe5\xab\x55\x76\x37\x42\x4c\x0e\x5e\xfe\xcd\x5d\x42\xa9\xd1\x81\xd2\x1e\xf0\x59\x38\xff\x0a\x1c\xe2\xdc\xf8\x29\x77\x1c\xdb\xea\x8c\x48\xad\xd7\x43\x93\x2a\x51\xa2\xc7\xd5\xc8\x20\xcc\x1d\x03\x10\xaa\x38\xd2\x9f\xe4\xfb\x79\xa1\x44\x15\x20\x6e\x6f\xe1\x44\xb2\xa1\xd2\x16\x5b\x2d\xdc\xce\x98\xaf\x16\x97\xac\xc7\xd2\x3b\x91\x3c\x96\xdd\x7f\xf8\x06\x25\x96\xc7\x6c\x88\x8f\xff\x2d\xfa\x31\x77\xe7\x2f\xba\x98\xc2\xfb\x1a\xd5\x34\xab\x67\xe9\x3a\x80\xc5\xac\xcb\x63\x95\x0d\x85\xd0\xc6\xc5\x10\x7f\x51\xf0\xf8\xb3\x44\x14\xf4\xb0\xb1\xa2\x88\x2f\xce\x19\x87\x5a\x14\x75\xd8\xea\x50\x2d\x51\x18\x6e\xf3\x61\xf7\xf8\x96\xd1\x76\xb8\xcb\xcf\xa3\x0a\xed\x36\x26\x70\x7e\xde\x99\x17\x9a\x75\xfa\xbd\xb8\xfe\x70\x6f\x3a\x23\x95\x0f\x71\x06\xc5\x2d\x16\x28\x76\xf1\xd5\x17\x42\xfc\xe6\x31\xcd\xe0\x01\x71\x82\x99\xa1\x84\x89\xf1\x31\x3a\xd5\x3d\x11\xa5\x8c\x2c\xd1\x73\x21\xc3\xc3\x71\x2c\xa7\xf0\x9b\x64\x78\xf0\x72\x29\xfc\x81\xfd\xa8\x85\x9c\xc4\x3e\xef\x24\xbf\x5b\xd1\xbf\xfa\xcc\x9f\xa7\xcf\x9c\x6c\xcb\x97\x1f\x81\xdf\x6b\x3b\xbf\x06\x00\x00\xff\xff\x74\xb2\xce\x07\x9a\x10\x00\x00")
-
-func svcEndpointsGotemplateBytes() ([]byte, error) {
-	return bindataRead(
-		_svcEndpointsGotemplate,
-		"svc/endpoints.gotemplate",
-	)
-}
-
-func svcEndpointsGotemplate() (*asset, error) {
-	bytes, err := svcEndpointsGotemplateBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "svc/endpoints.gotemplate", size: 4250, mode: os.FileMode(0644), modTime: time.Unix(1464111000, 0)}
-	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x41, 0x2d, 0x18, 0xaf, 0x17, 0xfe, 0xa0, 0x91, 0xab, 0x63, 0xd2, 0xa4, 0xc4, 0xff, 0xae, 0x35, 0x3b, 0xa8, 0x91, 0xeb, 0x2a, 0xb, 0x54, 0xee, 0x1e, 0xd0, 0x4c, 0x36, 0xb6, 0xce, 0x17, 0x1}}
-	return a, nil
-}
-
-var _svcServerRunGotemplate = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\x56\xcd\x6e\xdb\x38\x10\x3e\x8b\x4f\x31\x15\xba\x0b\x19\x70\xa9\x02\xbb\xdd\x83\xb7\x3e\xb4\x71\x9a\x06\x68\x52\xc3\x71\xbb\xc7\x05\x2d\x8d\x64\xa2\x12\x29\x90\xb4\xdc\x40\xf0\xbb\x2f\x86\xfa\xb1\xdc\x26\x6e\xb2\x39\xc4\xa2\x66\xf8\xcd\xff\x37\x8a\x63\xb8\xd0\x29\x42\x8e\x0a\x8d\x70\x98\xc2\xe6\x1e\x9c\xd9\x59\xcb\x61\xf1\x19\x6e\x3f\xaf\xe1\x72\x71\xbd\xe6\x2c\x8e\x61\x85\x66\xa7\x94\x54\x79\xab\x00\x7b\x59\x14\xa0\x6b\x34\x7b\x23\x1d\x82\xdb\x4a\x0b\x99\x2c\xd0\x2b\x7f\x45\x63\xa5\x56\x33\x68\x1a\xde\x3d\x1f\x0e\x23\x01\x2c\x84\xc3\xb1\x94\xce\x87\x03\x63\x95\x48\xbe\x89\x1c\xc1\xa2\xa9\xd1\x30\x26\xcb\x4a\x1b\x07\x11\x83\xee\x2f\xcc\x0a\x91\x87\xc7\xa3\xb6\xa3\x43\x56\xba\x90\x05\x61\xa1\x73\xfa\x51\xe8\xba\x9f\x78\xeb\x5c\x35\x7e\x8e\xab\xca\xe8\x2c\x64\x2c\x88\x63\xf8\x23\x85\xa5\x30\xee\x9e\x05\x61\xae\x75\x5e\x20\xcf\x75\x21\x54\xce\xb5\xc9\xe3\xdc\x54\x49\xa7\xb7\xa6\x10\xef\xd0\xd4\x32\x41\x16\x54\x1b\x08\x9b\x86\x2f\xdf\x5f\x7b\x17\x97\xc2\x6d\xe1\xd5\xe1\x40\x56\x9a\x86\x9f\xbe\x84\xd8\xd6\xc9\x23\x92\xad\x50\x69\x81\xc6\x86\x6c\xc2\x58\x2d\x0c\x2c\x30\x13\xbb\xc2\x5d\x68\x95\xc9\x1c\x6c\x9d\xf0\xf6\x91\xb1\x6c\xa7\x12\x90\x4a\xba\x68\x02\x0d\x0b\x28\x13\xfc\xce\x19\xa9\xf2\xaf\xc2\x44\xbf\x9f\x5c\xe4\x0b\xdc\xec\xf2\x77\x69\x6a\xa6\x10\xa6\xf4\xcc\x45\x9a\x9a\x70\x0a\xe1\xec\xcd\xeb\xbf\x5e\xd3\x83\x57\x01\xa1\x52\x28\xd1\x19\x99\x58\x28\xa4\x75\xa8\x80\x34\xd1\xda\x70\xf2\x2b\x23\x1f\xd7\xeb\x65\x67\x83\xd2\x3a\x36\xf1\xc6\x9b\x20\x85\x67\xa3\x5e\xad\x96\x17\x1d\x2a\xa5\x7f\x8c\xfa\xa7\x47\xcd\x57\xcb\x0b\x88\x08\x7b\xf2\x33\xb8\xaf\xd5\x17\x8b\x80\xaa\x96\x46\xab\x12\x95\x83\x5a\x18\x29\x36\x05\xda\x29\xc8\x0c\x2c\x3a\x0e\x1f\x0a\x91\x5b\xd8\x8a\x1a\xa1\x32\x52\x1b\xe9\xee\x7d\x3f\xc3\xa5\xaa\x49\xdf\x72\x16\xc8\xcc\x03\xc3\x6c\x0e\xda\xf2\x2b\x74\xa8\xea\x28\x5c\x5c\xbe\xff\x72\xf5\xef\xbb\xc5\x62\x15\x4e\xfe\x6e\x15\x5e\xcc\x21\x0c\xa9\x28\xc1\x23\x55\x80\xb9\x57\x64\xc1\xc1\xa3\xfa\xae\x3e\x45\x5d\x7e\x5e\xad\x09\xcf\x8b\x1e\xc3\xeb\x13\x0e\x73\xc8\x4a\xc7\xef\x2a\x23\x95\xcb\xa2\x70\xf6\x9b\x0d\xa7\xfe\xea\xa4\x37\xf1\x80\xe3\x74\xfb\x69\x7e\x8f\xec\x8c\xdd\x7e\x00\x93\x8a\xf5\x34\xcc\xbe\xac\x23\xcc\x43\xd7\xd4\xb7\xb8\xbf\x54\x69\xa5\xa5\x72\x36\xb2\xed\x88\x41\xb5\xe1\x4d\xc3\xbb\x81\xe3\xb7\xa2\xc4\xc3\xe1\xce\xf3\xc2\xc4\x8f\xc5\x70\x83\x6c\xc5\x31\xbc\xdf\x59\xa9\xd0\x5a\x48\x75\x29\xa4\xe2\x6d\x27\xfc\x63\x44\xd5\x4f\x2d\xec\xa5\xdb\x42\x29\xd3\xb4\xc0\xbd\x30\x68\x39\xdc\x21\x42\x3f\x82\xf1\x58\x92\x6b\x16\xf4\x9e\xcc\x07\x15\x4e\x70\x1d\x5a\xef\x68\xd7\x72\xbd\x3b\x83\xf9\x80\xa6\x39\x62\x41\xd3\x18\xa1\x72\x84\x97\x92\x52\x37\x04\x74\x83\x6e\xab\x53\x4b\x2c\xc0\x82\xa0\x69\xd6\xfa\x93\xde\xa3\x81\x97\xb2\x8b\x75\x00\x9c\xfb\x70\x6f\xc4\x37\x6c\x9a\x9f\xa4\x47\x2f\x82\xa6\x41\x95\x12\x1a\x79\x84\x43\x76\x66\xf3\xd3\x74\x35\x4f\x76\xe9\x27\x63\x33\xa2\xd9\x33\xae\x4e\x47\x4e\x1c\x46\xf9\xb7\x58\x60\x42\xfb\xe5\x58\xb3\x67\x96\xe2\x18\xce\x0f\xc5\x38\xf6\xcd\xa0\x42\xe1\x1b\x74\x3b\xa3\x60\x78\x47\xad\x46\x5b\x6c\xa7\xc0\x3a\x61\x9c\x05\x01\x0a\xf7\x40\xb4\xd5\x6d\x9b\x29\x78\x5e\xe9\x0f\xc4\x8b\x02\x3c\x75\x76\xef\x5a\x9f\xdd\x16\x09\xa9\x12\xd6\x62\x0a\x49\x4b\xd3\xa4\x5c\xe8\x3c\x47\xd3\x36\xf4\x6a\xa7\xa2\x24\x1b\xd3\xb7\xa7\xec\xbe\xa1\x66\xa3\x20\x6e\x71\xdf\x37\xd4\xe4\x87\xb2\x3d\x34\x16\x14\x9c\xcc\x20\xc9\x72\x7e\x45\x6b\x5b\x26\x34\xab\x2b\xb4\x95\x56\x16\x2f\x55\xa2\x53\x34\x30\x9f\x83\x92\x85\x1f\xc2\x5f\x69\x76\xcd\x41\x27\x92\x77\xaa\xbd\xda\x50\xc7\x1b\x4c\xb6\x42\xc9\x44\x14\xc7\x06\x47\x63\x12\xf2\xb3\x14\xdf\x30\x22\x31\xa0\x31\xda\x74\x03\x71\xad\x1c\x1a\xb3\xab\x5c\x1f\x2b\x67\x41\xae\x8f\x81\x0f\xf2\x8f\xed\x9b\x88\xe0\xba\xbb\xed\x6a\x6a\xb9\xbd\xbf\x48\x89\x6d\x37\x5f\x50\xe8\x9c\x2f\x89\xfa\x0a\x15\x85\xce\x08\x65\x89\xfa\xc2\x7e\xd5\xd1\x43\xb7\x34\x28\xfa\x81\x84\x09\x3c\x28\x7d\xf6\x69\x5b\x75\x99\xc7\x9b\xdd\x77\x4a\x7d\x50\xf2\xd6\x93\x28\x8c\x3d\x4c\xfb\x95\x10\x87\xd3\x56\xbd\x73\xf3\x03\xb9\xe1\x25\xfc\x5a\xa5\xf8\x7d\x72\xe6\x6a\x52\xa6\x85\x54\xf8\x38\xc2\x45\xab\x70\x0e\x83\xfe\xc9\xe2\x0c\xc6\xb2\x55\x38\x87\x61\xef\xcb\x8d\x2e\x1e\x87\xb8\xf3\xf2\x73\x08\xce\x88\xe4\x8c\x0f\x6b\x12\x4f\x7c\x7e\x7d\x53\xbc\x7d\xd5\x6a\x7e\xf2\x15\x7c\xa7\x52\x9f\xe8\xe8\xa4\x1a\x53\x28\x69\x59\x45\x5d\xc9\xfd\xa7\xc2\x50\xcb\x67\x94\x9c\x2e\xfe\x50\xf1\x7e\x7d\x51\x40\xdb\x9e\x00\x89\x40\x49\x30\xb4\x5b\x3f\x5a\xd3\x5f\x4c\xd3\xe4\x09\x51\x1d\xbf\x84\xb6\xe3\xa0\x3c\xa3\xfc\x9f\xa0\xe8\x62\x38\x1d\xc7\xd4\xaf\x4f\xf2\xa6\x50\x53\x1a\x35\x8a\x4c\xa1\xeb\xfc\x89\x42\x97\x54\x0f\x28\xcb\xcc\xeb\xbe\x38\x52\xc2\x10\x0d\x1a\x43\xc7\x96\x2c\x59\xe0\x67\x3d\xb0\xa6\x1e\xa7\x8c\xa0\xda\xb5\x3b\x66\xd8\x20\xf0\x0c\xe5\xbf\xcf\xfa\x39\x32\x7e\x8a\xaa\x0d\x5f\x61\x4e\x1e\x99\x47\xd6\x77\x64\xa7\x60\x4d\x7d\xd2\x2d\x96\xb7\xb9\x2c\xd4\x38\x7d\xab\x9d\x7a\xc1\x4e\xb3\x84\xdf\x25\x25\xe8\xed\xab\x96\x2d\x0e\x8c\xfd\x17\x00\x00\xff\xff\x0a\xd8\x0e\xfc\xc6\x0c\x00\x00")
-
-func svcServerRunGotemplateBytes() ([]byte, error) {
-	return bindataRead(
-		_svcServerRunGotemplate,
-		"svc/server/run.gotemplate",
-	)
-}
-
-func svcServerRunGotemplate() (*asset, error) {
-	bytes, err := svcServerRunGotemplateBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "svc/server/run.gotemplate", size: 3270, mode: os.FileMode(0644), modTime: time.Unix(1464111000, 0)}
-	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x9e, 0xe9, 0x4d, 0x80, 0xa0, 0x7b, 0xf5, 0xe3, 0xf4, 0xc4, 0xb4, 0x7a, 0x46, 0x72, 0x63, 0x75, 0x62, 0x5f, 0x37, 0xaf, 0x6e, 0xb1, 0x15, 0x35, 0x41, 0x8b, 0x44, 0x83, 0x27, 0x5f, 0x2f, 0xf7}}
-	return a, nil
-}
-
-var _svcTransport_grpcGotemplate = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x56\x4d\x6f\xdb\x38\x10\x3d\x8b\xbf\x62\xd6\x28\x16\x56\xe1\xd0\x7b\x0e\x90\x4b\x93\x6e\x5b\xec\xe6\x03\x59\xa3\x7b\x28\x8a\x82\x96\xc6\x12\x61\x89\x54\x48\xca\x89\x97\xd0\x7f\x5f\x0c\xf5\x61\x39\x76\x1c\x1f\x0c\x58\xe4\xe3\xcc\x9b\xf7\x86\x23\xcd\xe7\x70\xad\x53\x84\x0c\x15\x1a\xe1\x30\x85\xe5\x16\x9c\xa9\xad\xe5\x70\x73\x0f\x77\xf7\x0b\xf8\x7c\xf3\x6d\xc1\xd9\x7c\x0e\x8f\x68\x6a\xa5\xa4\xca\x5a\x00\x3c\xcb\xa2\x00\xbd\x41\xf3\x6c\xa4\x43\x70\xb9\xb4\xb0\x92\x05\x06\xf0\x77\x34\x56\x6a\x75\x09\xde\xf3\xee\x7f\xd3\x8c\x36\xe0\x46\x38\x1c\xef\xd2\x73\xd3\x30\x56\x89\x64\x2d\x32\x04\xbb\x49\x18\xe1\x17\x7d\x58\xa8\x8c\xde\xc8\x14\x2d\x58\x34\x1b\x34\x17\x56\xa6\x08\x4b\xa9\x52\xa9\x32\x0b\x2b\x6d\xc0\xe5\x08\xd9\xe3\xc3\x35\x38\x23\x94\xad\xb4\x71\x81\xcb\x37\x07\xb5\x93\x85\xfc\x0f\x6d\x80\x0c\xbb\xf3\xcc\x54\x09\xff\x27\x84\xe3\x8c\xc9\x92\x16\x61\xca\xa2\x89\x42\x37\xcf\x9d\xab\x26\x2c\x9a\x24\x5a\x39\x7c\x71\x13\xc6\xa2\x49\xa6\x75\x56\x20\xcf\x74\x21\x54\xc6\xb5\xc9\x42\x88\x79\x89\x4e\xa4\xc2\x09\xc2\xd0\xc2\x90\x01\x26\x99\x74\x79\xbd\xe4\x89\x2e\xe7\x99\xbe\x58\x4b\x37\xa7\xdf\x3e\x05\x3a\xd6\x97\x4a\x6c\x64\x82\x2c\xaa\x96\x30\xf1\x9e\x3f\x7c\xfa\x16\x68\x3d\x08\x97\xc3\x45\xd3\x4c\x58\x1c\x74\xb9\x15\x6b\xfc\xf2\xf8\x70\xdd\xb2\x87\x52\xac\xd1\x82\x00\x8b\x0e\xf4\x0a\x50\xa5\x95\x96\xca\x59\x10\x1b\x21\x0b\xb1\x2c\x10\x04\xed\x07\x79\xbc\xe7\x5d\x1a\x7e\x27\x4a\x6c\x9a\x5e\x82\x55\xad\x92\x57\x91\xa7\xbb\x50\x9f\xfb\x7f\x33\xd0\x95\x93\x5a\x59\xe0\x9c\xef\xd5\xdb\x89\x79\x1f\xb6\x63\xa8\x96\xfc\x8d\x5c\xe0\x59\x64\x47\x58\x0b\x97\x57\xf0\xe3\xe7\xdb\xc1\x3c\x8b\xa2\x63\xbb\x9f\x70\xa5\x0d\x4e\x7b\x07\x16\xfa\xba\xb5\x2b\x9e\xb1\xa8\x79\x9d\xe3\x0a\x44\x55\xa1\x4a\xa7\x7b\xcb\x43\x39\x9c\xf3\x98\x45\x06\x5d\x6d\x14\xfc\x4e\xd9\xda\x1c\x3e\xd8\xe3\x3d\x2c\xf4\xdf\xfa\x19\x0d\xec\x95\x04\x4d\xc3\x22\xef\x8d\x50\x19\xc2\x07\x49\x85\x0c\xfb\xb7\xe8\x72\x9d\x5a\x42\x44\xde\xf7\xc7\x3f\xc8\x4e\x8b\x4b\xd8\x2f\xe9\x0e\x9f\x3b\xd5\x59\x14\x45\x83\xf2\xdc\xfb\xe1\x48\x6f\xc2\x8c\x10\x37\x98\xe8\x34\x98\x35\x42\x3c\xe2\x53\x8d\xb6\x05\x7c\x56\x47\x01\xb6\xd2\xca\x62\x40\xec\x29\xc1\x39\xa7\x45\xd2\xce\xfb\x0b\xea\x22\x62\xde\xb0\x26\xb4\xdc\x4e\x10\x90\x65\x55\x60\x89\xd4\x15\x74\xa3\xbc\xff\xa2\x83\x14\xc7\xbd\x96\xca\xa1\x59\x89\x04\x99\xdb\x56\x38\x8e\x63\x9d\xa9\x13\x07\x9e\xbd\xaf\xdf\x11\xf9\x00\x5e\xe9\xf7\x55\xa8\xb4\x40\xc3\x76\xe4\x5b\xe6\x5d\x98\x30\x24\x46\xd9\x9d\xde\x15\x72\x7e\x0d\xef\x52\x0d\xb7\x68\x6a\xe1\xe3\x2e\x55\xbc\x0b\x3f\xb0\x9f\x26\xee\x05\xba\xe1\xc2\xbb\xae\x9d\x81\xc1\x27\xf8\x18\xee\xcd\x0e\xdf\x39\xba\xd8\x56\x3d\xa9\x18\xa6\x87\xa0\xd6\xd5\x11\x6a\x06\x68\x8c\xa6\xe4\x2c\xfa\x45\xa1\xab\xb0\x42\xb4\xa9\xa7\x0e\xf4\x6c\xaf\x14\x75\x0b\x71\x0b\x5c\x62\x16\xc9\x55\x38\xf4\xdb\x15\x28\x59\x50\xa8\xfe\x86\x28\x59\x84\x78\xe1\xa2\x75\x6b\x06\x2b\x7e\x0e\xb5\x78\x46\xc7\x59\xc3\xbc\x6f\x8d\x22\x9b\x3a\xa9\xdb\xae\x7e\x5f\xe7\xf9\x1c\x4e\x5d\x00\x90\x34\xf0\x5e\x0d\xfb\xf6\x40\x87\xf8\x93\x8c\x72\xb9\x70\x64\xc3\x06\x0d\x8d\xcb\xd0\xe8\xed\x90\x3c\xec\x37\xd3\x45\x76\x1a\x04\xd4\x16\xcd\x45\xaa\x4b\x21\xd5\x29\x30\x87\x07\x23\x4b\x61\x64\xb1\xa5\x23\xab\xba\x00\xa9\xc2\xa4\x1e\xcd\xdc\x53\x75\x4c\x7f\x1d\x76\x09\xd5\xf2\x88\x4f\xbb\xae\xf4\xd4\x12\xa3\xa7\xb1\xf5\xd4\x52\x97\x57\xfd\x99\x63\xf6\x1c\xb4\xd7\xc8\xcf\xa7\x13\x4e\xb5\xe3\xe5\x2c\xa7\x4e\x4e\xa2\xa3\x56\xb5\x27\x7a\xc8\x5b\x5e\xbd\xef\x42\x97\x22\x78\x76\xc2\xd9\xaa\xd8\x9e\x65\xd5\xc9\x42\x8e\x79\x35\x30\x38\xd3\x2c\x5b\x91\x8a\xfd\xa9\xf3\x6e\xd3\xc8\x2f\x5b\x1d\x33\xec\x2b\x16\x15\x1a\xcb\xda\x1a\x0e\xde\x96\xc7\x67\x51\x99\x0e\x48\x7e\x7b\x13\xbf\x06\x10\x5d\x9a\xa8\xeb\x19\x6c\x02\xe5\xd0\x04\x65\x1a\x66\x84\x5c\xc1\x66\x3c\x33\xda\x0f\x1c\x84\x35\x6e\x83\xdb\x69\x4a\x1f\x9b\xda\xe5\x24\x71\x9f\x85\x06\x74\x29\x1c\x4c\xd7\x31\x3c\xe7\x32\xc9\x03\xb4\x28\xa0\x20\xbb\xba\x28\x42\xa5\xe1\xa5\x43\xdf\x67\xfc\x5a\x28\xad\x64\x22\x8a\xaf\x28\x52\x34\x7f\xe1\x96\x3e\x7f\x5c\x97\xc8\xea\xb6\x65\xa4\x83\x44\x28\x58\x62\x1f\x22\x49\xd0\x5a\x4c\x29\x37\x4a\x97\xa3\xe9\x32\xd3\x3e\x49\x71\x35\xd4\xfa\xaf\x74\xf9\x77\x51\xd4\xd8\x8e\x44\xaa\xf5\xc7\x1f\x3f\xe3\x77\x81\x6f\xb0\x9b\xae\xe3\x5d\x84\xf0\x6e\x1d\xac\x4b\xdc\x0b\x6b\xd8\xff\x01\x00\x00\xff\xff\x71\x92\xdd\x9a\x92\x0b\x00\x00")
-
-func svcTransport_grpcGotemplateBytes() ([]byte, error) {
-	return bindataRead(
-		_svcTransport_grpcGotemplate,
-		"svc/transport_grpc.gotemplate",
-	)
-}
-
-func svcTransport_grpcGotemplate() (*asset, error) {
-	bytes, err := svcTransport_grpcGotemplateBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "svc/transport_grpc.gotemplate", size: 2962, mode: os.FileMode(0644), modTime: time.Unix(1464111000, 0)}
-	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xa2, 0xc7, 0xff, 0xf, 0x16, 0xfd, 0xd8, 0x43, 0xfc, 0x25, 0x2b, 0x9c, 0xfe, 0x3c, 0xf, 0x3a, 0x3e, 0xc7, 0x21, 0xdf, 0x32, 0x41, 0x25, 0x39, 0x7d, 0x4b, 0xb6, 0xc2, 0xc, 0x27, 0x81, 0x3c}}
-	return a, nil
-}
-
-var _svcTransport_httpGotemplate = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x2c\xcb\xb1\x0d\xc2\x30\x10\x05\xd0\x3e\x53\x5c\x1d\x09\xdf\x1a\x29\x91\xe2\x05\xac\xf0\x31\x08\x93\xb3\xce\x1f\x1a\xeb\x76\xa7\x61\x80\x37\xa7\xae\xb2\x03\x52\xed\x42\xff\x8c\xa1\x15\x67\xb5\xd7\x93\xfa\x20\x3b\xbd\x9c\xa3\x9b\x53\x89\x77\x6f\x85\x18\xa9\x9a\xdc\xcd\xe5\xb0\x1b\x64\xd5\x88\x65\xce\xa3\xb4\x26\x69\xcb\xf9\xba\xa1\x75\x78\xda\xe1\x5f\x78\xfe\x1b\x49\x11\xcb\x2f\x00\x00\xff\xff\xdd\x3a\x4a\x8f\x6a\x00\x00\x00")
-
-func svcTransport_httpGotemplateBytes() ([]byte, error) {
-	return bindataRead(
-		_svcTransport_httpGotemplate,
-		"svc/transport_http.gotemplate",
-	)
-}
+	"github.com/pkg/errors"
+)
 
-func svcTransport_httpGotemplate() (*asset, error) {
-	bytes, err := svcTransport_httpGotemplateBytes()
-	if err != nil {
-		return nil, err
-	}
+//go:embed NAME-service
+var files embed.FS
 
-	info := bindataFileInfo{name: "svc/transport_http.gotemplate", size: 106, mode: os.FileMode(0644), modTime: time.Unix(1464111000, 0)}
-	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x52, 0x57, 0x56, 0xc6, 0xb4, 0xe5, 0x1f, 0xf4, 0x1d, 0xa5, 0xda, 0x23, 0xea, 0x8f, 0xfb, 0xff, 0xae, 0x4b, 0x12, 0xe4, 0xf6, 0xbf, 0x11, 0xa6, 0x4, 0x83, 0x53, 0xfd, 0xbf, 0xce, 0x4a, 0x47}}
-	return a, nil
-}
+// root is the directory within files holding the templates
+const root = "NAME-service"
 
-// Asset loads and returns the asset for the given name.
-// It returns an error if the asset could not be found or
-// could not be loaded.
+// Asset returns the template named name.
 func Asset(name string) ([]byte, error) {
-	canonicalName := strings.Replace(name, "\\", "/", -1)
-	if f, ok := _bindata[canonicalName]; ok {
-		a, err := f()
-		if err != nil {
-			return nil, fmt.Errorf("Asset %s can't read by error: %v", name, err)
-		}
-		return a.bytes, nil
-	}
-	return nil, fmt.Errorf("Asset %s not found", name)
-}
-
-// AssetString returns the asset contents as a string (instead of a []byte).
-func AssetString(name string) (string, error) {
-	data, err := Asset(name)
-	return string(data), err
-}
-
-// MustAsset is like Asset but panics when Asset would return an error.
-// It simplifies safe initialization of global variables.
-func MustAsset(name string) []byte {
-	a, err := Asset(name)
+	b, err := files.ReadFile(path.Join(root, name))
 	if err != nil {
-		panic("asset: Asset(" + name + "): " + err.Error())
+		return nil, errors.Wrapf(err, "cannot read template %v", name)
 	}
-
-	return a
+	return b, nil
 }
 
-// MustAssetString is like AssetString but panics when Asset would return an
-// error. It simplifies safe initialization of global variables.
-func MustAssetString(name string) string {
-	return string(MustAsset(name))
-}
-
-// AssetInfo loads and returns the asset info for the given name.
-// It returns an error if the asset could not be found or
-// could not be loaded.
+// AssetInfo returns the FileInfo of the template named name, or an error if
+// there is no such template.
 func AssetInfo(name string) (os.FileInfo, error) {
-	canonicalName := strings.Replace(name, "\\", "/", -1)
-	if f, ok := _bindata[canonicalName]; ok {
-		a, err := f()
-		if err != nil {
-			return nil, fmt.Errorf("AssetInfo %s can't read by error: %v", name, err)
-		}
-		return a.info, nil
-	}
-	return nil, fmt.Errorf("AssetInfo %s not found", name)
+	return fs.Stat(files, path.Join(root, name))
 }
 
-// AssetDigest returns the digest of the file with the given name. It returns an
-// error if the asset could not be found or the digest could not be loaded.
-func AssetDigest(name string) ([sha256.Size]byte, error) {
-	canonicalName := strings.Replace(name, "\\", "/", -1)
-	if f, ok := _bindata[canonicalName]; ok {
-		a, err := f()
-		if err != nil {
-			return [sha256.Size]byte{}, fmt.Errorf("AssetDigest %s can't read by error: %v", name, err)
-		}
-		return a.digest, nil
-	}
-	return [sha256.Size]byte{}, fmt.Errorf("AssetDigest %s not found", name)
-}
-
-// Digests returns a map of all known files and their checksums.
-func Digests() (map[string][sha256.Size]byte, error) {
-	mp := make(map[string][sha256.Size]byte, len(_bindata))
-	for name := range _bindata {
-		a, err := _bindata[name]()
-		if err != nil {
-			return nil, err
-		}
-		mp[name] = a.digest
-	}
-	return mp, nil
-}
-
-// AssetNames returns the names of the assets.
+// AssetNames returns the names of every template.
 func AssetNames() []string {
-	names := make([]string, 0, len(_bindata))
-	for name := range _bindata {
-		names = append(names, name)
-	}
-	return names
-}
-
-// _bindata is a table, holding each asset generator, mapped to its name.
-var _bindata = map[string]func() (*asset, error){
-	"cmd/NAME/main.gotemplate":          cmdNameMainGotemplate,
-	"handlers/handlers.gotemplate":      handlersHandlersGotemplate,
-	"handlers/hooks.gotemplate":         handlersHooksGotemplate,
-	"handlers/middlewares.gotemplate":   handlersMiddlewaresGotemplate,
-	"svc/client/grpc/client.gotemplate": svcClientGrpcClientGotemplate,
-	"svc/client/http/client.gotemplate": svcClientHttpClientGotemplate,
-	"svc/config.gotemplate":             svcConfigGotemplate,
-	"svc/endpoints.gotemplate":          svcEndpointsGotemplate,
-	"svc/server/run.gotemplate":         svcServerRunGotemplate,
-	"svc/transport_grpc.gotemplate":     svcTransport_grpcGotemplate,
-	"svc/transport_http.gotemplate":     svcTransport_httpGotemplate,
-}
-
-// AssetDebug is true if the assets were built with the debug flag enabled.
-const AssetDebug = false
-
-// AssetDir returns the file names below a certain
-// directory embedded in the file by go-bindata.
-// For example if you run go-bindata on data/... and data contains the
-// following hierarchy:
-//     data/
-//       foo.txt
-//       img/
-//         a.png
-//         b.png
-// then AssetDir("data") would return []string{"foo.txt", "img"},
-// AssetDir("data/img") would return []string{"a.png", "b.png"},
-// AssetDir("foo.txt") and AssetDir("notexist") would return an error, and
-// AssetDir("") will return []string{"data"}.
-func AssetDir(name string) ([]string, error) {
-	node := _bintree
-	if len(name) != 0 {
-		canonicalName := strings.Replace(name, "\\", "/", -1)
-		pathList := strings.Split(canonicalName, "/")
-		for _, p := range pathList {
-			node = node.Children[p]
-			if node == nil {
-				return nil, fmt.Errorf("Asset %s not found", name)
-			}
-		}
-	}
-	if node.Func != nil {
-		return nil, fmt.Errorf("Asset %s not found", name)
-	}
-	rv := make([]string, 0, len(node.Children))
-	for childName := range node.Children {
-		rv = append(rv, childName)
-	}
-	return rv, nil
-}
-
-type bintree struct {
-	Func     func() (*asset, error)
-	Children map[string]*bintree
-}
-
-var _bintree = &bintree{nil, map[string]*bintree{
-	"cmd": {nil, map[string]*bintree{
-		"NAME": {nil, map[string]*bintree{
-			"main.gotemplate": {cmdNameMainGotemplate, map[string]*bintree{}},
-		}},
-	}},
-	"handlers": {nil, map[string]*bintree{
-		"handlers.gotemplate": {handlersHandlersGotemplate, map[string]*bintree{}},
-		"hooks.gotemplate": {handlersHooksGotemplate, map[string]*bintree{}},
-		"middlewares.gotemplate": {handlersMiddlewaresGotemplate, map[string]*bintree{}},
-	}},
-	"svc": {nil, map[string]*bintree{
-		"client": {nil, map[string]*bintree{
-			"grpc": {nil, map[string]*bintree{
-				"client.gotemplate": {svcClientGrpcClientGotemplate, map[string]*bintree{}},
-			}},
-			"http": {nil, map[string]*bintree{
-				"client.gotemplate": {svcClientHttpClientGotemplate, map[string]*bintree{}},
-			}},
-		}},
-		"config.gotemplate": {svcConfigGotemplate, map[string]*bintree{}},
-		"endpoints.gotemplate": {svcEndpointsGotemplate, map[string]*bintree{}},
-		"server": {nil, map[string]*bintree{
-			"run.gotemplate": {svcServerRunGotemplate, map[string]*bintree{}},
-		}},
-		"transport_grpc.gotemplate": {svcTransport_grpcGotemplate, map[string]*bintree{}},
-		"transport_http.gotemplate": {svcTransport_httpGotemplate, map[string]*bintree{}},
-	}},
-}}
-
-// RestoreAsset restores an asset under the given directory.
-func RestoreAsset(dir, name string) error {
-	data, err := Asset(name)
-	if err != nil {
-		return err
-	}
-	info, err := AssetInfo(name)
-	if err != nil {
-		return err
-	}
-	err = os.MkdirAll(_filePath(dir, filepath.Dir(name)), os.FileMode(0755))
-	if err != nil {
-		return err
-	}
-	err = ioutil.WriteFile(_filePath(dir, name), data, info.Mode())
-	if err != nil {
-		return err
-	}
-	return os.Chtimes(_filePath(dir, name), info.ModTime(), info.ModTime())
-}
-
-// RestoreAssets restores an asset under the given directory recursively.
-func RestoreAssets(dir, name string) error {
-	children, err := AssetDir(name)
-	// File
-	if err != nil {
-		return RestoreAsset(dir, name)
-	}
-	// Dir
-	for _, child := range children {
-		err = RestoreAssets(dir, filepath.Join(name, child))
-		if err != nil {
+	var names []string
+	err := fs.WalkDir(files, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
 			return err
 		}
+		names = append(names, p[len(root)+1:])
+		return nil
+	})
+	if err != nil {
+		// files is compiled in, so it can always be walked
+		panic(err)
 	}
-	return nil
-}
-
-func _filePath(dir, name string) string {
-	canonicalName := strings.Replace(name, "\\", "/", -1)
-	return filepath.Join(append([]string{dir}, strings.Split(canonicalName, "/")...)...)
+	return names
 }
@@ -0,0 +1,302 @@
+// Package genopenapi generates OpenAPI documents of the HTTP APIs of the
+// services of a definition, from the HTTP bindings of their methods.
+package genopenapi
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/metaverse/truss/svcdef"
+)
+
+// Info is the Info Object of a document.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// operation is an HTTP binding of a method, which is an operation of a
+// document.
+type operation struct {
+	method *svcdef.ServiceMethod
+	// id is unique within the document
+	id string
+	// verb is the lowercased HTTP method
+	verb string
+	// path is the path template of the binding, without the patterns its
+	// variables are bound to
+	path string
+	// params holds the parameters in the path and query
+	params []*parameter
+	// body holds the fields of the request in the body
+	body []*svcdef.Field
+	// bodyField is the field of the request which is the body of a request;
+	// nil if the body holds the fields of body as an object
+	bodyField *svcdef.Field
+	// responseBody is the field of the response which is the body of a
+	// response; nil if it is the whole response
+	responseBody *svcdef.Field
+}
+
+type parameter struct {
+	// name is the name of the field, or the dotted path to a field of a
+	// message in the query, e.g. filter.author.name
+	name  string
+	field *svcdef.Field
+	// in is either "path" or "query"
+	in string
+	// dotted is whether the parameter is a field of a message in the query
+	dotted bool
+}
+
+// operations returns an operation for each HTTP binding of the methods of
+// svc. Streaming methods are not served over HTTP, so they have none.
+func operations(svc *svcdef.Service) []*operation {
+	var rv []*operation
+	for _, meth := range svc.Methods {
+		if meth.ClientStreaming || meth.ServerStreaming {
+			continue
+		}
+		for i, b := range meth.Bindings {
+			verb := strings.ToLower(b.Verb)
+			if !httpMethods[verb] {
+				log.Warnf("%s.%s is bound to the HTTP method %s, which OpenAPI does not support; it is left out of the document", svc.Name, meth.Name, b.Verb)
+				continue
+			}
+			op := &operation{
+				method:       meth,
+				id:           svc.Name + "_" + meth.Name,
+				verb:         verb,
+				path:         pathVariable.ReplaceAllString(b.Path, "{$1}"),
+				bodyField:    b.Body,
+				responseBody: b.ResponseBody,
+			}
+			if i > 0 {
+				op.id += fmt.Sprint(i)
+			}
+			for _, p := range b.Params {
+				if p.Location == "query" && p.Field.Type.Oneof == nil && isQueryMessage(p.Field.Type) {
+					op.params = append(op.params, dottedParams(p.Field.PBFieldName, p.Field, make(map[*svcdef.Message]bool))...)
+					continue
+				}
+				for _, f := range flattenOneof(p.Field) {
+					if p.Location == "body" {
+						op.body = append(op.body, f)
+						continue
+					}
+					op.params = append(op.params, &parameter{name: f.PBFieldName, field: f, in: p.Location})
+				}
+			}
+			rv = append(rv, op)
+		}
+	}
+	return rv
+}
+
+// isQueryMessage returns whether a query parameter of type t is a message
+// whose fields the generated service decodes from dotted query parameters;
+// one which is neither repeated nor a well-known type.
+func isQueryMessage(t *svcdef.FieldType) bool {
+	if t.Message == nil || t.ArrayType || t.Map != nil {
+		return false
+	}
+	_, ok := wellKnownTypes[t.Message.ProtoName]
+	return !ok
+}
+
+// dottedParams returns the dotted query parameters of the fields of f, a
+// message in the query named name, down to the fields which are not messages,
+// as populateQueryParams in the generated service decodes them. Oneofs are
+// left out. within holds the messages f is a field of, as a message within
+// itself is given as JSON rather than expanded without end.
+func dottedParams(name string, f *svcdef.Field, within map[*svcdef.Message]bool) []*parameter {
+	m := f.Type.Message
+	if !isQueryMessage(f.Type) || within[m] {
+		return []*parameter{{name: name, field: f, in: "query", dotted: true}}
+	}
+	within[m] = true
+	defer delete(within, m)
+
+	var rv []*parameter
+	for _, field := range m.Fields {
+		if field.Type.Oneof != nil {
+			continue
+		}
+		rv = append(rv, dottedParams(name+"."+field.PBFieldName, field, within)...)
+	}
+	return rv
+}
+
+// pathVariable matches a variable of a path template bound to a pattern,
+// e.g. {name=shelves/*}
+var pathVariable = regexp.MustCompile(`{([^{}=]+)=[^{}]*}`)
+
+// httpMethods holds the HTTP methods OpenAPI has operations for.
+var httpMethods = map[string]bool{
+	"get":     true,
+	"put":     true,
+	"post":    true,
+	"delete":  true,
+	"options": true,
+	"head":    true,
+	"patch":   true,
+}
+
+// findService returns the service of sd named name.
+func findService(sd *svcdef.Svcdef, name string) (*svcdef.Service, error) {
+	for _, svc := range sd.Services {
+		if svc.Name == name {
+			return svc, nil
+		}
+	}
+	return nil, errors.Errorf("no service named %q", name)
+}
+
+// DefaultVersion is the version of an API whose Info has none, as the
+// definition has no version of its API.
+const DefaultVersion = "1.0.0"
+
+// fillInfo fills in the fields of info left empty from svc: it is titled by
+// the name of svc, and described by its comment.
+func fillInfo(info Info, svc *svcdef.Service) Info {
+	if info.Title == "" {
+		info.Title = svc.Name
+	}
+	if info.Description == "" {
+		info.Description = svc.Description
+	}
+	if info.Version == "" {
+		info.Version = DefaultVersion
+	}
+	return info
+}
+
+// bodySchema returns the schema of the body of the request of op; a reference
+// to the request message if every field of it is in the body, and the schema
+// of a single field if the binding binds the body to it.
+func (d *definitions) bodySchema(op *operation) *schema {
+	if op.bodyField != nil {
+		return d.field(op.bodyField)
+	}
+	all := 0
+	for _, f := range op.method.RequestType.Message.Fields {
+		all += len(flattenOneof(f))
+	}
+	if len(op.body) == all {
+		return d.message(op.method.RequestType.Message)
+	}
+	rv := &schema{
+		Type:       "object",
+		Properties: make(map[string]*schema),
+	}
+	for _, f := range op.body {
+		rv.Properties[f.PBFieldName] = d.field(f)
+	}
+	return rv
+}
+
+// responseSchema returns the schema of the body of a successful response of
+// op; a reference to the response message unless the binding has a
+// response_body.
+func (d *definitions) responseSchema(op *operation) *schema {
+	if op.responseBody != nil {
+		return d.field(op.responseBody)
+	}
+	return d.message(op.method.ResponseType.Message)
+}
+
+// paramSchema returns the schema of a path or query parameter of type t as
+// the generated service decodes it, and whether it is decoded as JSON, as
+// messages and maps are. Well-known types whose JSON form is a string or a
+// scalar are decoded from that form, enums from the numbers of their values,
+// and repeated values from either repeated parameters or a JSON array.
+func (d *definitions) paramSchema(t *svcdef.FieldType) (*schema, bool) {
+	if t.Message != nil && !t.ArrayType {
+		if wkt, ok := wellKnownTypes[t.Message.ProtoName]; ok && wkt.Type != "" && wkt.Type != "object" && wkt.Type != "array" {
+			rv := *wkt
+			return &rv, false
+		}
+	}
+	switch {
+	case t.Map != nil, t.Message != nil, isBytes(t):
+		return d.fieldType(t), true
+	case isRepeated(t):
+		item := *t
+		item.ArrayType = false
+		items, _ := d.paramSchema(&item)
+		return &schema{Type: "array", Items: items}, false
+	case t.Enum != nil:
+		rv := &schema{
+			Type:   "integer",
+			Format: "int32",
+		}
+		var values []string
+		for _, v := range t.Enum.Values {
+			rv.Enum = append(rv.Enum, v.Number)
+			values = append(values, fmt.Sprintf("%d: %s", v.Number, v.Name))
+		}
+		rv.Description = strings.Join(values, ", ")
+		return rv, false
+	}
+	switch t.Name {
+	case "int64":
+		return &schema{Type: "integer", Format: "int64"}, false
+	case "uint64":
+		return &schema{Type: "integer", Format: "uint64"}, false
+	}
+	return d.fieldType(t), false
+}
+
+// dottedParamSchema returns the schema of a dotted query parameter of type t,
+// and whether it is decoded as JSON. Those are decoded as paramSchema
+// describes, but for enums, which may also be given by the names of their
+// values, and bytes, which are given base64 encoded rather than as JSON.
+func (d *definitions) dottedParamSchema(t *svcdef.FieldType) (*schema, bool) {
+	switch {
+	case t.Map != nil, t.Message != nil:
+		return d.paramSchema(t)
+	case isRepeated(t):
+		item := *t
+		item.ArrayType = isBytes(t)
+		item.Name = strings.TrimPrefix(t.Name, "[]")
+		items, _ := d.dottedParamSchema(&item)
+		return &schema{Type: "array", Items: items}, false
+	case isBytes(t):
+		return &schema{Type: "string", Format: "byte"}, false
+	case t.Enum != nil:
+		rv := &schema{Type: "string"}
+		var values []string
+		for _, v := range t.Enum.Values {
+			rv.Enum = append(rv.Enum, v.Name)
+			values = append(values, fmt.Sprintf("%d: %s", v.Number, v.Name))
+		}
+		for _, v := range t.Enum.Values {
+			rv.Enum = append(rv.Enum, fmt.Sprint(v.Number))
+		}
+		rv.Description = strings.Join(values, ", ")
+		return rv, false
+	}
+	return d.paramSchema(t)
+}
+
+// parameterSchema returns the schema of p, and whether it is decoded as JSON.
+func (d *definitions) parameterSchema(p *parameter) (*schema, bool) {
+	if p.dotted {
+		return d.dottedParamSchema(p.field.Type)
+	}
+	return d.paramSchema(p.field.Type)
+}
+
+// errorSchema is the schema of the body of an error response, which the
+// generated service encodes as an object with the error message.
+var errorSchema = &schema{
+	Type: "object",
+	Properties: map[string]*schema{
+		"error": {Type: "string"},
+	},
+}
@@ -0,0 +1,236 @@
+package genopenapi
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/metaverse/truss/svcdef"
+)
+
+var gopath []string
+
+func init() {
+	gopath = filepath.SplitList(os.Getenv("GOPATH"))
+}
+
+const definition = `
+	syntax = "proto3";
+
+	package things;
+
+	import "google/protobuf/timestamp.proto";
+	import "github.com/metaverse/truss/deftree/googlethirdparty/annotations.proto";
+
+	// Things keeps things
+	service Things {
+		// Get gets a thing
+		rpc Get(GetRequest) returns (Thing) {
+			option (google.api.http) = {
+				get: "/things/{id}"
+				additional_bindings {
+					get: "/v1/{name=shelves/*}"
+					response_body: "children"
+				}
+			};
+		}
+		rpc Create(Thing) returns (Thing) {
+			option (google.api.http) = {
+				post: "/things"
+				body: "*"
+			};
+		}
+		rpc Filter(GetRequest) returns (Thing) {
+			option (google.api.http) = {
+				patch: "/things/{id}"
+				body: "filter"
+			};
+		}
+		rpc Watch(GetRequest) returns (stream Thing) {
+			option (google.api.http) = {
+				get: "/watch"
+			};
+		}
+	}
+
+	message GetRequest {
+		int64 id = 1;
+		string name = 2;
+		repeated string tags = 3;
+		Thing.Kind kind = 4;
+		Thing filter = 5;
+		google.protobuf.Timestamp since = 6;
+	}
+
+	// Thing is a thing
+	message Thing {
+		// Kind kinds
+		enum Kind {
+			UNKNOWN = 0;
+			BIG = 1;
+		}
+		// id identifies
+		int64 id = 1;
+		Kind kind = 2;
+		map<string, Thing> children = 3;
+		repeated bytes blobs = 4;
+		oneof choice {
+			string label = 5;
+			Thing parent = 6;
+		}
+		google.protobuf.Timestamp created = 7;
+		Author author = 8;
+	}
+
+	message Author {
+		// name names
+		string name = 1;
+		Author mentor = 2;
+	}
+`
+
+// generate returns the document generate creates of the definition, decoded
+// from JSON.
+func generate(t *testing.T, generate func(*svcdef.Svcdef, string, Info) ([]byte, error)) map[string]interface{} {
+	sd, err := svcdef.NewFromString(definition, gopath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := generate(sd, "Things", Info{Title: "Things", Version: "1.0.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+// lookup returns the value within v at path, a list of object keys and array
+// indices.
+func lookup(t *testing.T, v interface{}, path ...interface{}) interface{} {
+	t.Helper()
+	for i, elem := range path {
+		switch elem := elem.(type) {
+		case string:
+			obj, ok := v.(map[string]interface{})
+			if !ok || obj[elem] == nil {
+				t.Fatalf("no %q at %v", elem, path[:i])
+			}
+			v = obj[elem]
+		case int:
+			arr, ok := v.([]interface{})
+			if !ok || elem >= len(arr) {
+				t.Fatalf("no %d at %v", elem, path[:i])
+			}
+			v = arr[elem]
+		}
+	}
+	return v
+}
+
+// jsonValue returns s decoded from JSON.
+func jsonValue(t *testing.T, s string) interface{} {
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestOpenAPI3(t *testing.T) {
+	doc := generate(t, OpenAPI3)
+
+	for _, test := range []struct {
+		path []interface{}
+		want string
+	}{
+		{[]interface{}{"openapi"}, `"3.0.3"`},
+		{[]interface{}{"info"}, `{"title": "Things", "version": "1.0.0", "description": "Things keeps things"}`},
+		{[]interface{}{"paths", "/things/{id}", "get", "operationId"}, `"Things_Get"`},
+		{[]interface{}{"paths", "/things/{id}", "get", "description"}, `"Get gets a thing"`},
+		{[]interface{}{"paths", "/things/{id}", "get", "parameters", 0}, `{"name": "id", "in": "path", "required": true, "schema": {"type": "integer", "format": "int64"}}`},
+		{[]interface{}{"paths", "/things/{id}", "get", "parameters", 2}, `{"name": "tags", "in": "query", "schema": {"type": "array", "items": {"type": "string"}}}`},
+		{[]interface{}{"paths", "/things/{id}", "get", "parameters", 3, "schema", "enum"}, `[0, 1]`},
+		{[]interface{}{"paths", "/things/{id}", "get", "parameters", 4}, `{"name": "filter.id", "in": "query", "description": "id identifies", "schema": {"type": "integer", "format": "int64"}}`},
+		{[]interface{}{"paths", "/things/{id}", "get", "parameters", 5}, `{"name": "filter.kind", "in": "query", "schema": {"type": "string", "description": "0: UNKNOWN, 1: BIG", "enum": ["UNKNOWN", "BIG", "0", "1"]}}`},
+		{[]interface{}{"paths", "/things/{id}", "get", "parameters", 6}, `{"name": "filter.children", "in": "query", "content": {"application/json": {"schema": {"type": "object", "additionalProperties": {"$ref": "#/components/schemas/things.Thing"}}}}}`},
+		{[]interface{}{"paths", "/things/{id}", "get", "parameters", 7}, `{"name": "filter.blobs", "in": "query", "schema": {"type": "array", "items": {"type": "string", "format": "byte"}}}`},
+		{[]interface{}{"paths", "/things/{id}", "get", "parameters", 8}, `{"name": "filter.created", "in": "query", "schema": {"type": "string", "format": "date-time"}}`},
+		{[]interface{}{"paths", "/things/{id}", "get", "parameters", 9}, `{"name": "filter.author.name", "in": "query", "description": "name names", "schema": {"type": "string"}}`},
+		{[]interface{}{"paths", "/things/{id}", "get", "parameters", 10}, `{"name": "filter.author.mentor", "in": "query", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/things.Author"}}}}`},
+		{[]interface{}{"paths", "/things/{id}", "get", "parameters", 11}, `{"name": "since", "in": "query", "schema": {"type": "string", "format": "date-time"}}`},
+		{[]interface{}{"paths", "/things/{id}", "get", "responses", "200", "content", "application/json", "schema"}, `{"$ref": "#/components/schemas/things.Thing"}`},
+		{[]interface{}{"paths", "/v1/{name}", "get", "operationId"}, `"Things_Get1"`},
+		{[]interface{}{"paths", "/v1/{name}", "get", "parameters", 1, "in"}, `"path"`},
+		{[]interface{}{"paths", "/v1/{name}", "get", "responses", "200", "content", "application/json", "schema"}, `{"type": "object", "additionalProperties": {"$ref": "#/components/schemas/things.Thing"}}`},
+		{[]interface{}{"paths", "/things", "post", "requestBody", "content", "application/json", "schema"}, `{"$ref": "#/components/schemas/things.Thing"}`},
+		{[]interface{}{"paths", "/things/{id}", "patch", "requestBody", "content", "application/json", "schema"}, `{"$ref": "#/components/schemas/things.Thing"}`},
+		{[]interface{}{"paths", "/things/{id}", "patch", "parameters", 3, "name"}, `"kind"`},
+		{[]interface{}{"components", "schemas", "things.Thing.Kind"}, `{"type": "string", "description": "Kind kinds", "enum": ["UNKNOWN", "BIG"]}`},
+		{[]interface{}{"components", "schemas", "things.Thing", "description"}, `"Thing is a thing"`},
+		{[]interface{}{"components", "schemas", "things.Thing", "properties"}, `{
+			"id": {"type": "string", "format": "int64", "description": "id identifies"},
+			"kind": {"$ref": "#/components/schemas/things.Thing.Kind"},
+			"children": {"type": "object", "additionalProperties": {"$ref": "#/components/schemas/things.Thing"}},
+			"blobs": {"type": "array", "items": {"type": "string", "format": "byte"}},
+			"label": {"type": "string", "description": "Only one of label, parent may be set."},
+			"parent": {"allOf": [{"$ref": "#/components/schemas/things.Thing"}], "description": "Only one of label, parent may be set."},
+			"created": {"type": "string", "format": "date-time"},
+			"author": {"$ref": "#/components/schemas/things.Author"}
+		}`},
+	} {
+		if got, want := lookup(t, doc, test.path...), jsonValue(t, test.want); !reflect.DeepEqual(got, want) {
+			t.Errorf("%v = %v, want %v", test.path, got, want)
+		}
+	}
+
+	// Streaming methods are not served over HTTP
+	if _, ok := doc["paths"].(map[string]interface{})["/watch"]; ok {
+		t.Error("the streaming method Watch has an operation")
+	}
+	// Only the messages and enums referred to have schemas
+	if got := len(lookup(t, doc, "components", "schemas").(map[string]interface{})); got != 3 {
+		t.Errorf("components have %d schemas, want 3", got)
+	}
+}
+
+func TestSwagger2(t *testing.T) {
+	doc := generate(t, Swagger2)
+
+	for _, test := range []struct {
+		path []interface{}
+		want string
+	}{
+		{[]interface{}{"swagger"}, `"2.0"`},
+		{[]interface{}{"paths", "/things/{id}", "get", "parameters", 0}, `{"name": "id", "in": "path", "required": true, "type": "integer", "format": "int64"}`},
+		{[]interface{}{"paths", "/things/{id}", "get", "parameters", 2}, `{"name": "tags", "in": "query", "type": "array", "items": {"type": "string"}, "collectionFormat": "multi"}`},
+		{[]interface{}{"paths", "/things/{id}", "get", "parameters", 5}, `{"name": "filter.kind", "in": "query", "type": "string", "description": "0: UNKNOWN, 1: BIG", "enum": ["UNKNOWN", "BIG", "0", "1"]}`},
+		{[]interface{}{"paths", "/things/{id}", "get", "parameters", 7}, `{"name": "filter.blobs", "in": "query", "type": "array", "items": {"type": "string", "format": "byte"}, "collectionFormat": "multi"}`},
+		{[]interface{}{"paths", "/things/{id}", "get", "parameters", 9}, `{"name": "filter.author.name", "in": "query", "type": "string", "description": "name names"}`},
+		{[]interface{}{"paths", "/things/{id}", "get", "parameters", 10}, `{"name": "filter.author.mentor", "in": "query", "type": "string", "description": "JSON encoded."}`},
+		{[]interface{}{"paths", "/things/{id}", "get", "parameters", 11}, `{"name": "since", "in": "query", "type": "string", "format": "date-time"}`},
+		{[]interface{}{"paths", "/things", "post", "parameters", 0}, `{"name": "body", "in": "body", "required": true, "schema": {"$ref": "#/definitions/things.Thing"}}`},
+		{[]interface{}{"paths", "/things", "post", "responses", "200", "schema"}, `{"$ref": "#/definitions/things.Thing"}`},
+		{[]interface{}{"paths", "/things/{id}", "patch", "parameters", 5}, `{"name": "body", "in": "body", "required": true, "schema": {"$ref": "#/definitions/things.Thing"}}`},
+		{[]interface{}{"paths", "/v1/{name}", "get", "responses", "200", "schema"}, `{"type": "object", "additionalProperties": {"$ref": "#/definitions/things.Thing"}}`},
+		{[]interface{}{"definitions", "things.Thing", "properties", "kind"}, `{"$ref": "#/definitions/things.Thing.Kind"}`},
+	} {
+		if got, want := lookup(t, doc, test.path...), jsonValue(t, test.want); !reflect.DeepEqual(got, want) {
+			t.Errorf("%v = %v, want %v", test.path, got, want)
+		}
+	}
+}
+
+func TestUnknownService(t *testing.T) {
+	sd, err := svcdef.NewFromString(definition, gopath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenAPI3(sd, "Others", Info{}); err == nil {
+		t.Error("expected an error for an unknown service")
+	}
+}
@@ -0,0 +1,123 @@
+package genopenapi
+
+import (
+	"encoding/json"
+
+	"github.com/metaverse/truss/svcdef"
+)
+
+type openAPI3Document struct {
+	OpenAPI    string                                   `json:"openapi"`
+	Info       Info                                     `json:"info"`
+	Paths      map[string]map[string]*openAPI3Operation `json:"paths"`
+	Components openAPI3Components                       `json:"components"`
+}
+
+type openAPI3Components struct {
+	Schemas map[string]*schema `json:"schemas"`
+}
+
+type openAPI3Operation struct {
+	OperationID string                       `json:"operationId"`
+	Tags        []string                     `json:"tags"`
+	Description string                       `json:"description,omitempty"`
+	Parameters  []*openAPI3Parameter         `json:"parameters,omitempty"`
+	RequestBody *openAPI3RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*openAPI3Response `json:"responses"`
+}
+
+type openAPI3Parameter struct {
+	Name        string                    `json:"name"`
+	In          string                    `json:"in"`
+	Description string                    `json:"description,omitempty"`
+	Required    bool                      `json:"required,omitempty"`
+	Schema      *schema                   `json:"schema,omitempty"`
+	Content     map[string]*openAPI3Media `json:"content,omitempty"`
+}
+
+type openAPI3RequestBody struct {
+	Required bool                      `json:"required"`
+	Content  map[string]*openAPI3Media `json:"content"`
+}
+
+type openAPI3Response struct {
+	Description string                    `json:"description"`
+	Content     map[string]*openAPI3Media `json:"content,omitempty"`
+}
+
+type openAPI3Media struct {
+	Schema *schema `json:"schema"`
+}
+
+// OpenAPI3 returns the OpenAPI 3.0 document of the HTTP API of the service of
+// sd named svcName, as JSON. Each HTTP binding of a method is an operation
+// tagged with the name of the service, while each message and enum it refers
+// to is a schema named by its fully qualified proto name. Comments of the
+// definition become descriptions. The fields of info left empty are filled in
+// from the service.
+func OpenAPI3(sd *svcdef.Svcdef, svcName string, info Info) ([]byte, error) {
+	svc, err := findService(sd, svcName)
+	if err != nil {
+		return nil, err
+	}
+	info = fillInfo(info, svc)
+
+	d := newDefinitions("#/components/schemas/")
+	doc := openAPI3Document{
+		OpenAPI: "3.0.3",
+		Info:    info,
+		Paths:   make(map[string]map[string]*openAPI3Operation),
+	}
+	for _, op := range operations(svc) {
+		o := &openAPI3Operation{
+			OperationID: op.id,
+			Tags:        []string{svc.Name},
+			Description: op.method.Description,
+			Responses: map[string]*openAPI3Response{
+				"200": {
+					Description: "A successful response.",
+					Content:     jsonContent(d.responseSchema(op)),
+				},
+				"default": {
+					Description: "An error response.",
+					Content:     jsonContent(errorSchema),
+				},
+			},
+		}
+		for _, p := range op.params {
+			param := &openAPI3Parameter{
+				Name:        p.name,
+				In:          p.in,
+				Description: p.field.Description,
+				Required:    p.in == "path",
+			}
+			s, isJSON := d.parameterSchema(p)
+			if isJSON {
+				param.Content = jsonContent(s)
+			} else {
+				param.Schema = s
+			}
+			o.Parameters = append(o.Parameters, param)
+		}
+		if len(op.body) > 0 {
+			o.RequestBody = &openAPI3RequestBody{
+				Required: true,
+				Content:  jsonContent(d.bodySchema(op)),
+			}
+		}
+
+		if doc.Paths[op.path] == nil {
+			doc.Paths[op.path] = make(map[string]*openAPI3Operation)
+		}
+		doc.Paths[op.path][op.verb] = o
+	}
+	doc.Components.Schemas = d.defs
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+func jsonContent(s *schema) map[string]*openAPI3Media {
+	return map[string]*openAPI3Media{
+		"application/json": {Schema: s},
+	}
+}
@@ -0,0 +1,216 @@
+package genopenapi
+
+import (
+	"strings"
+
+	"github.com/metaverse/truss/svcdef"
+)
+
+// schema is a Schema Object, of the subset common to OpenAPI 3 and Swagger 2.
+type schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	AllOf                []*schema          `json:"allOf,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Items                *schema            `json:"items,omitempty"`
+	Properties           map[string]*schema `json:"properties,omitempty"`
+	AdditionalProperties *schema            `json:"additionalProperties,omitempty"`
+}
+
+// definitions holds the schemas of the messages and enums a service refers
+// to, as the generated service encodes them as JSON: with the jsonpb package
+// and the original names of fields.
+type definitions struct {
+	// refPrefix prefixes the name of a schema in a reference to it
+	refPrefix string
+	// defs holds the schema of each message and enum referred to, keyed by
+	// its name
+	defs map[string]*schema
+}
+
+func newDefinitions(refPrefix string) *definitions {
+	return &definitions{
+		refPrefix: refPrefix,
+		defs:      make(map[string]*schema),
+	}
+}
+
+// schemaName returns the name of the schema of a message or enum, its proto
+// name, or its Go name for a Svcdef parsed without proto names.
+func schemaName(protoName, name string) string {
+	if protoName != "" {
+		return protoName
+	}
+	return name
+}
+
+// message returns a reference to the schema of m, defining it if need be.
+// Well-known types are encoded as their JSON mapping prescribes, so they have
+// a schema of their own rather than a reference.
+func (d *definitions) message(m *svcdef.Message) *schema {
+	if wkt, ok := wellKnownTypes[m.ProtoName]; ok {
+		rv := *wkt
+		return &rv
+	}
+	name := schemaName(m.ProtoName, m.Name)
+	ref := &schema{Ref: d.refPrefix + name}
+	if _, ok := d.defs[name]; ok {
+		return ref
+	}
+
+	def := &schema{
+		Type:        "object",
+		Description: m.Description,
+		Properties:  make(map[string]*schema),
+	}
+	// Defined before the fields, which may refer to m
+	d.defs[name] = def
+	for _, f := range m.Fields {
+		for _, field := range flattenOneof(f) {
+			def.Properties[field.PBFieldName] = d.field(field)
+		}
+	}
+	return ref
+}
+
+// enum returns a reference to the schema of e, defining it if need be. Enums
+// are encoded by the names of their values.
+func (d *definitions) enum(e *svcdef.Enum) *schema {
+	name := schemaName(e.ProtoName, e.Name)
+	ref := &schema{Ref: d.refPrefix + name}
+	if _, ok := d.defs[name]; ok {
+		return ref
+	}
+
+	def := &schema{
+		Type:        "string",
+		Description: e.Description,
+	}
+	for _, v := range e.Values {
+		def.Enum = append(def.Enum, v.Name)
+	}
+	d.defs[name] = def
+	return ref
+}
+
+// field returns the schema of the value of f, which is not a oneof.
+func (d *definitions) field(f *svcdef.Field) *schema {
+	rv := d.fieldType(f.Type)
+	if f.Description != "" {
+		if rv.Ref != "" {
+			// Siblings of a reference are ignored, so it is wrapped
+			rv = &schema{AllOf: []*schema{rv}}
+		}
+		rv.Description = f.Description
+	}
+	return rv
+}
+
+// fieldType returns the schema of a value of type t.
+func (d *definitions) fieldType(t *svcdef.FieldType) *schema {
+	if t.Map != nil {
+		return &schema{
+			Type:                 "object",
+			AdditionalProperties: d.fieldType(t.Map.ValueType),
+		}
+	}
+	if isRepeated(t) {
+		item := *t
+		item.ArrayType = false
+		item.Name = strings.TrimPrefix(t.Name, "[]")
+		if isBytes(t) {
+			item.ArrayType = true
+		}
+		return &schema{
+			Type:  "array",
+			Items: d.fieldType(&item),
+		}
+	}
+	switch {
+	case t.Message != nil:
+		return d.message(t.Message)
+	case t.Enum != nil:
+		return d.enum(t.Enum)
+	case isBytes(t):
+		return &schema{Type: "string", Format: "byte"}
+	}
+	if scalar, ok := jsonScalars[t.Name]; ok {
+		rv := *scalar
+		return &rv
+	}
+	return &schema{}
+}
+
+// isBytes returns whether t is a bytes field, singular or repeated.
+func isBytes(t *svcdef.FieldType) bool {
+	return t.ArrayType && (t.Name == "byte" || t.Name == "[]byte")
+}
+
+// isRepeated returns whether t is a repeated field; a singular bytes field is
+// a slice as well.
+func isRepeated(t *svcdef.FieldType) bool {
+	return t.ArrayType && t.Name != "byte"
+}
+
+// flattenOneof returns the options of f if it is a oneof, whose options are
+// encoded as fields of the message, or else f. Each option is described as
+// excluding the others.
+func flattenOneof(f *svcdef.Field) []*svcdef.Field {
+	if f.Type.Oneof == nil {
+		return []*svcdef.Field{f}
+	}
+	var names []string
+	for _, option := range f.Type.Oneof {
+		names = append(names, option.PBFieldName)
+	}
+	exclusive := "Only one of " + strings.Join(names, ", ") + " may be set."
+	var rv []*svcdef.Field
+	for _, option := range f.Type.Oneof {
+		o := *option
+		// The type of the option is that of the only field of the struct
+		// wrapping it
+		if wrapper := option.Type.Message; wrapper != nil && len(wrapper.Fields) == 1 {
+			o.Type = wrapper.Fields[0].Type
+		}
+		o.Description = strings.TrimSpace(o.Description + "\n\n" + exclusive)
+		rv = append(rv, &o)
+	}
+	return rv
+}
+
+// jsonScalars holds the schemas of the scalar Go types of fields as jsonpb
+// encodes them; 64 bit integers are encoded as strings.
+var jsonScalars = map[string]*schema{
+	"string":  {Type: "string"},
+	"bool":    {Type: "boolean"},
+	"int32":   {Type: "integer", Format: "int32"},
+	"uint32":  {Type: "integer", Format: "int64"},
+	"int64":   {Type: "string", Format: "int64"},
+	"uint64":  {Type: "string", Format: "uint64"},
+	"float32": {Type: "number", Format: "float"},
+	"float64": {Type: "number", Format: "double"},
+}
+
+// wellKnownTypes holds the schemas of the well-known types, keyed by proto
+// name, following their JSON mapping.
+var wellKnownTypes = map[string]*schema{
+	"google.protobuf.Timestamp":   {Type: "string", Format: "date-time"},
+	"google.protobuf.Duration":    {Type: "string", Description: "A duration in seconds with up to nine fractional digits, ending with \"s\", e.g. \"1.5s\"."},
+	"google.protobuf.FieldMask":   {Type: "string", Description: "A comma separated list of field paths."},
+	"google.protobuf.Empty":       {Type: "object"},
+	"google.protobuf.Struct":      {Type: "object"},
+	"google.protobuf.Value":       {Description: "Any JSON value."},
+	"google.protobuf.ListValue":   {Type: "array", Items: &schema{}},
+	"google.protobuf.Any":         {Type: "object", Description: "A message of the type named by its \"@type\" field, along with the fields of that message."},
+	"google.protobuf.DoubleValue": {Type: "number", Format: "double"},
+	"google.protobuf.FloatValue":  {Type: "number", Format: "float"},
+	"google.protobuf.Int64Value":  {Type: "string", Format: "int64"},
+	"google.protobuf.UInt64Value": {Type: "string", Format: "uint64"},
+	"google.protobuf.Int32Value":  {Type: "integer", Format: "int32"},
+	"google.protobuf.UInt32Value": {Type: "integer", Format: "int64"},
+	"google.protobuf.BoolValue":   {Type: "boolean"},
+	"google.protobuf.StringValue": {Type: "string"},
+	"google.protobuf.BytesValue":  {Type: "string", Format: "byte"},
+}
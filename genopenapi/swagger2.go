@@ -0,0 +1,120 @@
+package genopenapi
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/metaverse/truss/svcdef"
+)
+
+type swagger2Document struct {
+	Swagger     string                                   `json:"swagger"`
+	Info        Info                                     `json:"info"`
+	Consumes    []string                                 `json:"consumes"`
+	Produces    []string                                 `json:"produces"`
+	Paths       map[string]map[string]*swagger2Operation `json:"paths"`
+	Definitions map[string]*schema                       `json:"definitions"`
+}
+
+type swagger2Operation struct {
+	OperationID string                       `json:"operationId"`
+	Tags        []string                     `json:"tags"`
+	Description string                       `json:"description,omitempty"`
+	Parameters  []*swagger2Parameter         `json:"parameters,omitempty"`
+	Responses   map[string]*swagger2Response `json:"responses"`
+}
+
+// swagger2Parameter is a Parameter Object; Schema is set for the body, and
+// the other fields for the path and query.
+type swagger2Parameter struct {
+	Name             string        `json:"name"`
+	In               string        `json:"in"`
+	Description      string        `json:"description,omitempty"`
+	Required         bool          `json:"required,omitempty"`
+	Schema           *schema       `json:"schema,omitempty"`
+	Type             string        `json:"type,omitempty"`
+	Format           string        `json:"format,omitempty"`
+	Items            *schema       `json:"items,omitempty"`
+	Enum             []interface{} `json:"enum,omitempty"`
+	CollectionFormat string        `json:"collectionFormat,omitempty"`
+}
+
+type swagger2Response struct {
+	Description string  `json:"description"`
+	Schema      *schema `json:"schema,omitempty"`
+}
+
+// Swagger2 returns the Swagger 2.0 document of the HTTP API of the service of
+// sd named svcName, as JSON, which describes the same operations and schemas
+// as OpenAPI3. Swagger 2 has no JSON encoded parameters, so a message or map
+// in the path or query is a string parameter.
+func Swagger2(sd *svcdef.Svcdef, svcName string, info Info) ([]byte, error) {
+	svc, err := findService(sd, svcName)
+	if err != nil {
+		return nil, err
+	}
+	info = fillInfo(info, svc)
+
+	d := newDefinitions("#/definitions/")
+	doc := swagger2Document{
+		Swagger:  "2.0",
+		Info:     info,
+		Consumes: []string{"application/json"},
+		Produces: []string{"application/json"},
+		Paths:    make(map[string]map[string]*swagger2Operation),
+	}
+	for _, op := range operations(svc) {
+		o := &swagger2Operation{
+			OperationID: op.id,
+			Tags:        []string{svc.Name},
+			Description: op.method.Description,
+			Responses: map[string]*swagger2Response{
+				"200": {
+					Description: "A successful response.",
+					Schema:      d.responseSchema(op),
+				},
+				"default": {
+					Description: "An error response.",
+					Schema:      errorSchema,
+				},
+			},
+		}
+		for _, p := range op.params {
+			param := &swagger2Parameter{
+				Name:        p.name,
+				In:          p.in,
+				Description: p.field.Description,
+				Required:    p.in == "path",
+			}
+			s, isJSON := d.parameterSchema(p)
+			if isJSON {
+				s = &schema{Type: "string"}
+				param.Description = strings.TrimSpace(param.Description + "\n\nJSON encoded.")
+			}
+			param.Type, param.Format, param.Items, param.Enum = s.Type, s.Format, s.Items, s.Enum
+			if param.Description == "" {
+				param.Description = s.Description
+			}
+			if s.Type == "array" && p.in == "query" {
+				param.CollectionFormat = "multi"
+			}
+			o.Parameters = append(o.Parameters, param)
+		}
+		if len(op.body) > 0 {
+			o.Parameters = append(o.Parameters, &swagger2Parameter{
+				Name:     "body",
+				In:       "body",
+				Required: true,
+				Schema:   d.bodySchema(op),
+			})
+		}
+
+		if doc.Paths[op.path] == nil {
+			doc.Paths[op.path] = make(map[string]*swagger2Operation)
+		}
+		doc.Paths[op.path][op.verb] = o
+	}
+	doc.Definitions = d.defs
+
+	return json.MarshalIndent(doc, "", "  ")
+}
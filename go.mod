@@ -1,22 +1,31 @@
 module github.com/metaverse/truss
 
-go 1.13
+go 1.18
 
 require (
 	github.com/davecgh/go-spew v1.1.1
 	github.com/go-kit/kit v0.10.0
 	github.com/gogo/protobuf v1.2.2-0.20190601103108-21df5aa0e680
+	github.com/golang/protobuf v1.4.2
 	github.com/gorilla/mux v1.8.0
-	github.com/kevinburke/go-bindata v3.22.0+incompatible // indirect
-	github.com/kr/pretty v0.1.0 // indirect
+	github.com/jhump/protoreflect v1.8.2
 	github.com/moul/http2curl v1.0.0
 	github.com/pkg/errors v0.8.1
 	github.com/pmezard/go-difflib v1.0.0
 	github.com/sirupsen/logrus v1.4.2
 	github.com/spf13/pflag v1.0.5
 	github.com/stretchr/testify v1.5.1
-	golang.org/x/sys v0.0.0-20191220142924-d4481acd189f // indirect
-	golang.org/x/tools v0.0.0-20200103221440-774c71fcf114
+	golang.org/x/mod v0.3.0
+	golang.org/x/tools v0.0.0-20200717024301-6ddee64345a6
 	google.golang.org/grpc v1.38.0
+	gopkg.in/yaml.v2 v2.2.2
+)
+
+require (
+	github.com/kr/pretty v0.1.0 // indirect
+	golang.org/x/sys v0.0.0-20200323222414-85ca7c5b95cd // indirect
+	golang.org/x/xerrors v0.0.0-20191204190536-9bdfabe68543 // indirect
+	google.golang.org/genproto v0.0.0-20200526211855-cb27e3aa2013 // indirect
+	google.golang.org/protobuf v1.25.1-0.20200805231151-a709e31e5d12 // indirect
 	gopkg.in/check.v1 v1.0.0-20180628173108-788fd7840127 // indirect
 )
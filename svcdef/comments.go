@@ -0,0 +1,66 @@
+package svcdef
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+)
+
+// The numbers of the fields of descriptors which make up the paths of
+// SourceCodeInfo locations.
+const (
+	fileMessagesPath   = 4
+	fileEnumsPath      = 5
+	fileServicesPath   = 6
+	messageFieldsPath  = 2
+	messageNestedPath  = 3
+	messageEnumsPath   = 4
+	messageOneofsPath  = 8
+	enumValuesPath     = 2
+	serviceMethodsPath = 2
+)
+
+// comments holds the leading comments of the declarations of a file, keyed
+// by the path of each declaration within the descriptor of the file.
+type comments map[string]string
+
+// fileComments returns the comments of f, which are empty if f was parsed
+// without its source code info.
+func fileComments(f *descriptor.FileDescriptorProto) comments {
+	rv := make(comments)
+	for _, loc := range f.GetSourceCodeInfo().GetLocation() {
+		if c := cleanComment(loc.GetLeadingComments()); c != "" {
+			rv[pathKey(loc.Path)] = c
+		}
+	}
+	return rv
+}
+
+// at returns the comment of the declaration at path.
+func (c comments) at(path []int32) string {
+	return c[pathKey(path)]
+}
+
+func pathKey(path []int32) string {
+	var parts []string
+	for _, p := range path {
+		parts = append(parts, strconv.Itoa(int(p)))
+	}
+	return strings.Join(parts, ".")
+}
+
+// subpath returns path followed by elems, without sharing the array of path.
+func subpath(path []int32, elems ...int32) []int32 {
+	return append(path[:len(path):len(path)], elems...)
+}
+
+// cleanComment returns comment without the space following the comment
+// marker of each line, trailing whitespace, or blank lines surrounding it.
+func cleanComment(comment string) string {
+	lines := strings.Split(comment, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(strings.TrimPrefix(line, " "), " \t\r")
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
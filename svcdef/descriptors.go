@@ -0,0 +1,586 @@
+package svcdef
+
+import (
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+	gogen "github.com/gogo/protobuf/protoc-gen-gogo/generator"
+	plugin "github.com/gogo/protobuf/protoc-gen-gogo/plugin"
+	"github.com/pkg/errors"
+
+	google_api "github.com/metaverse/truss/deftree/googlethirdparty"
+	"github.com/metaverse/truss/truss/descutil"
+)
+
+// NewFromRequest creates a Svcdef from the descriptors of a protoc
+// CodeGeneratorRequest. The files named in FileToGenerate make up the
+// definition, while the remaining files are only used to resolve the types
+// they import. HTTP bindings are read from the google.api.http option of each
+// method.
+//
+// As with protoc-gen-gogo, the Go import path of each file is given by an
+// Mfile=importpath entry of the comma separated parameter of req, or else by
+// its go_package option, or else is the directory of the file's name.
+// Messages and enums in files of another import path than that of the files
+// defining the services are given the GoPackage of their import path.
+func NewFromRequest(req *plugin.CodeGeneratorRequest) (*Svcdef, error) {
+	return newFromDescriptors(req.GetProtoFile(), req.GetFileToGenerate(), req.GetParameter())
+}
+
+// NewFromDescriptorSet creates a Svcdef from a FileDescriptorSet, such as the
+// output of protoc run with --descriptor_set_out and --include_imports. The
+// files of set named in files make up the definition, as with
+// NewFromRequest.
+func NewFromDescriptorSet(set *descriptor.FileDescriptorSet, files []string) (*Svcdef, error) {
+	return newFromDescriptors(set.GetFile(), files, "")
+}
+
+// descriptorTypes holds the Go names and svcdef types of every message and
+// enum declared by a group of files, keyed by their fully qualified proto
+// names, such as ".pkg.Outer.Inner".
+type descriptorTypes struct {
+	names    map[string]string
+	messages map[string]*Message
+	enums    map[string]*Enum
+	// mapEntries holds the messages protoc generates for map fields, which
+	// have no Go type of their own
+	mapEntries map[string]*descriptor.DescriptorProto
+	// protos holds the descriptor of each message to fill in its fields
+	protos map[string]*descriptor.DescriptorProto
+	// sources holds the comments of the file declaring each message, along
+	// with the path of its declaration, to describe its fields
+	sources map[string]source
+}
+
+// source is where a message is declared: the comments of its file and the
+// path of its declaration within that file.
+type source struct {
+	comments comments
+	path     []int32
+}
+
+func newFromDescriptors(files []*descriptor.FileDescriptorProto, toGenerate []string, parameter string) (*Svcdef, error) {
+	byName := make(map[string]*descriptor.FileDescriptorProto)
+	for _, f := range files {
+		byName[f.GetName()] = f
+	}
+	var gen []*descriptor.FileDescriptorProto
+	for _, name := range toGenerate {
+		f, ok := byName[name]
+		if !ok {
+			return nil, errors.Errorf("cannot find descriptor of file %q", name)
+		}
+		gen = append(gen, f)
+	}
+	if len(gen) == 0 {
+		return nil, errors.New("no files to create a Svcdef from")
+	}
+
+	rv := Svcdef{
+		PkgName: goPackageName(gen[0]),
+	}
+
+	// The types of files in the Go package of the services are referred to
+	// as "pb", while other packages are imported
+	importPaths := goImportPaths(files, parameter)
+	defImportPath := importPaths[gen[0].GetName()]
+	for _, f := range gen {
+		if len(f.Service) > 0 {
+			defImportPath = importPaths[f.GetName()]
+			break
+		}
+	}
+	packages := make(map[string]*GoPackage)
+	aliases := map[string]bool{
+		(*GoPackage)(nil).Qualifier(): true,
+	}
+	filePackage := func(f *descriptor.FileDescriptorProto) *GoPackage {
+		importPath := importPaths[f.GetName()]
+		if importPath == defImportPath {
+			return nil
+		}
+		if pkg, ok := packages[importPath]; ok {
+			return pkg
+		}
+		pkg := &GoPackage{
+			ImportPath: importPath,
+			Alias:      uniqueAlias(goPackageName(f), aliases),
+		}
+		packages[importPath] = pkg
+		rv.Imports = append(rv.Imports, pkg)
+		return pkg
+	}
+
+	types := &descriptorTypes{
+		names:      make(map[string]string),
+		messages:   make(map[string]*Message),
+		enums:      make(map[string]*Enum),
+		mapEntries: make(map[string]*descriptor.DescriptorProto),
+		protos:     make(map[string]*descriptor.DescriptorProto),
+		sources:    make(map[string]source),
+	}
+	isGen := make(map[string]bool)
+	for _, f := range gen {
+		isGen[f.GetName()] = true
+	}
+	// Types of every file are declared before any fields are created so
+	// that fields may refer to types declared later or in imported files,
+	// though only the types of gen become part of the Svcdef
+	for _, f := range files {
+		prefix := "." + f.GetPackage()
+		if f.GetPackage() == "" {
+			prefix = ""
+		}
+		msgs, enums := types.declare(prefix, nil, source{comments: fileComments(f)}, f.MessageType, f.EnumType)
+		if pkg := filePackage(f); pkg != nil {
+			for _, m := range msgs {
+				m.Package = pkg
+			}
+			for _, e := range enums {
+				e.Package = pkg
+			}
+		}
+		if isGen[f.GetName()] {
+			rv.Messages = append(rv.Messages, msgs...)
+			rv.Enums = append(rv.Enums, enums...)
+		}
+	}
+	for protoName, msg := range types.messages {
+		types.fillMessage(msg, types.protos[protoName], types.sources[protoName])
+	}
+
+	for _, f := range gen {
+		c := fileComments(f)
+		for i, s := range f.Service {
+			svc, err := types.newService(s, source{c, []int32{fileServicesPath, int32(i)}})
+			if err != nil {
+				return nil, errors.Wrapf(err, "cannot create service %q", s.GetName())
+			}
+			rv.Services = append(rv.Services, svc)
+		}
+	}
+	sort.Slice(rv.Services, func(i, j int) bool {
+		return rv.Services[i].Name < rv.Services[j].Name
+	})
+
+	return &rv, nil
+}
+
+// declare records the messages and enums declared within a file or message,
+// along with those nested within them, and returns them in declaration
+// order. prefix is the fully qualified proto name of the enclosing package
+// or message, while outer holds the names of the enclosing messages and src
+// is where the enclosing file or message is declared.
+func (t *descriptorTypes) declare(prefix string, outer []string, src source, msgs []*descriptor.DescriptorProto, enums []*descriptor.EnumDescriptorProto) ([]*Message, []*Enum) {
+	msgsPath, enumsPath := int32(fileMessagesPath), int32(fileEnumsPath)
+	if len(outer) > 0 {
+		msgsPath, enumsPath = messageNestedPath, messageEnumsPath
+	}
+
+	var rvMsgs []*Message
+	var rvEnums []*Enum
+	for i, e := range enums {
+		protoName := prefix + "." + e.GetName()
+		path := subpath(src.path, enumsPath, int32(i))
+		enm := &Enum{
+			Name:        gogen.CamelCaseSlice(append(outer[:len(outer):len(outer)], e.GetName())),
+			ProtoName:   strings.TrimPrefix(protoName, "."),
+			Description: src.comments.at(path),
+		}
+		for j, v := range e.Value {
+			enm.Values = append(enm.Values, &EnumValue{
+				Name:        v.GetName(),
+				Number:      v.GetNumber(),
+				Description: src.comments.at(subpath(path, enumValuesPath, int32(j))),
+			})
+		}
+		t.names[protoName] = enm.Name
+		t.enums[protoName] = enm
+		rvEnums = append(rvEnums, enm)
+	}
+	for i, m := range msgs {
+		protoName := prefix + "." + m.GetName()
+		if m.GetOptions().GetMapEntry() {
+			t.mapEntries[protoName] = m
+			continue
+		}
+		names := append(outer[:len(outer):len(outer)], m.GetName())
+		msgSrc := source{src.comments, subpath(src.path, msgsPath, int32(i))}
+		msg := &Message{
+			Name:        gogen.CamelCaseSlice(names),
+			ProtoName:   strings.TrimPrefix(protoName, "."),
+			Description: src.comments.at(msgSrc.path),
+		}
+		t.names[protoName] = msg.Name
+		t.messages[protoName] = msg
+		t.protos[protoName] = m
+		t.sources[protoName] = msgSrc
+		rvMsgs = append(rvMsgs, msg)
+
+		nestedMsgs, nestedEnums := t.declare(protoName, names, msgSrc, m.NestedType, m.EnumType)
+		rvMsgs = append(rvMsgs, nestedMsgs...)
+		rvEnums = append(rvEnums, nestedEnums...)
+	}
+	return rvMsgs, rvEnums
+}
+
+// fillMessage creates the Fields of msg from its descriptor m, declared at
+// src. The fields of a oneof are gathered into one Field named after the
+// oneof, placed where the first of them is declared, as protoc-gen-gogo does.
+func (t *descriptorTypes) fillMessage(msg *Message, m *descriptor.DescriptorProto, src source) {
+	oneofs := make(map[int32]*Field)
+	for i, f := range m.Field {
+		field := t.newField(f)
+		field.Description = src.comments.at(subpath(src.path, messageFieldsPath, int32(i)))
+		// A proto3 optional field is the only field of a synthetic oneof,
+		// which is not a oneof of the message
+		if f.OneofIndex == nil || descutil.Proto3Optional(f) {
+			msg.Fields = append(msg.Fields, field)
+			continue
+		}
+		idx := f.GetOneofIndex()
+		oneof, ok := oneofs[idx]
+		if !ok {
+			name := gogen.CamelCase(m.OneofDecl[idx].GetName())
+			oneof = &Field{
+				Name:        name,
+				Description: src.comments.at(subpath(src.path, messageOneofsPath, idx)),
+				Type: &FieldType{
+					Name: "is" + msg.Name + "_" + name,
+				},
+			}
+			oneofs[idx] = oneof
+			msg.Fields = append(msg.Fields, oneof)
+		}
+		// Each option of a oneof is wrapped in a struct of its own, whose
+		// only field is the option with its own type
+		wrapped := *field
+		wrapperType := *field.Type
+		wrapperType.Message = &Message{
+			Name:    oneofWrapperName(msg, m, field),
+			Package: msg.Package,
+			Fields:  []*Field{&wrapped},
+		}
+		option := field
+		option.Type = &wrapperType
+		oneof.Type.Oneof = append(oneof.Type.Oneof, option)
+	}
+}
+
+// oneofWrapperName returns the name of the struct wrapping option, an option
+// of a oneof of msg. Like protoc-gen-gogo, underscores are appended until the
+// name differs from those of the types nested within msg.
+func oneofWrapperName(msg *Message, m *descriptor.DescriptorProto, option *Field) string {
+	nested := make(map[string]bool)
+	for _, n := range m.NestedType {
+		nested[gogen.CamelCase(msg.Name+"_"+n.GetName())] = true
+	}
+	for _, e := range m.EnumType {
+		nested[gogen.CamelCase(msg.Name+"_"+e.GetName())] = true
+	}
+	name := msg.Name + "_" + option.Name
+	for nested[name] {
+		name += "_"
+	}
+	return name
+}
+
+// newField returns the Field of the Go struct generated for f.
+func (t *descriptorTypes) newField(f *descriptor.FieldDescriptorProto) *Field {
+	rv := &Field{
+		Name:        gogen.CamelCase(f.GetName()),
+		PBFieldName: f.GetName(),
+	}
+	if entry, ok := t.mapEntries[f.GetTypeName()]; ok {
+		rv.Type = &FieldType{
+			Map: &Map{
+				KeyType:   t.newFieldType(entry.Field[0]),
+				ValueType: t.newFieldType(entry.Field[1]),
+			},
+		}
+		return rv
+	}
+	rv.Type = t.newFieldType(f)
+	if f.GetLabel() == descriptor.FieldDescriptorProto_LABEL_REPEATED {
+		// Repeated bytes are a slice of []byte
+		if rv.Type.ArrayType {
+			rv.Type.Name = "[]" + rv.Type.Name
+		}
+		rv.Type.ArrayType = true
+	}
+	return rv
+}
+
+// newFieldType returns the FieldType of a singular value of the type of f.
+func (t *descriptorTypes) newFieldType(f *descriptor.FieldDescriptorProto) *FieldType {
+	switch f.GetType() {
+	case descriptor.FieldDescriptorProto_TYPE_MESSAGE, descriptor.FieldDescriptorProto_TYPE_GROUP:
+		return &FieldType{
+			Name:     t.names[f.GetTypeName()],
+			Message:  t.messages[f.GetTypeName()],
+			StarExpr: true,
+		}
+	case descriptor.FieldDescriptorProto_TYPE_ENUM:
+		return &FieldType{
+			Name: t.names[f.GetTypeName()],
+			Enum: t.enums[f.GetTypeName()],
+		}
+	case descriptor.FieldDescriptorProto_TYPE_BYTES:
+		return &FieldType{
+			Name:      "byte",
+			ArrayType: true,
+		}
+	}
+	return &FieldType{
+		Name: scalarGoTypes[f.GetType()],
+	}
+}
+
+// scalarGoTypes maps the scalar proto types to the Go types generated for
+// them.
+var scalarGoTypes = map[descriptor.FieldDescriptorProto_Type]string{
+	descriptor.FieldDescriptorProto_TYPE_DOUBLE:   "float64",
+	descriptor.FieldDescriptorProto_TYPE_FLOAT:    "float32",
+	descriptor.FieldDescriptorProto_TYPE_INT64:    "int64",
+	descriptor.FieldDescriptorProto_TYPE_UINT64:   "uint64",
+	descriptor.FieldDescriptorProto_TYPE_INT32:    "int32",
+	descriptor.FieldDescriptorProto_TYPE_FIXED64:  "uint64",
+	descriptor.FieldDescriptorProto_TYPE_FIXED32:  "uint32",
+	descriptor.FieldDescriptorProto_TYPE_BOOL:     "bool",
+	descriptor.FieldDescriptorProto_TYPE_STRING:   "string",
+	descriptor.FieldDescriptorProto_TYPE_UINT32:   "uint32",
+	descriptor.FieldDescriptorProto_TYPE_SFIXED32: "int32",
+	descriptor.FieldDescriptorProto_TYPE_SFIXED64: "int64",
+	descriptor.FieldDescriptorProto_TYPE_SINT32:   "int32",
+	descriptor.FieldDescriptorProto_TYPE_SINT64:   "int64",
+}
+
+// newService returns the Service described by s, declared at src, with the
+// HTTP bindings of each method read from its google.api.http option.
+func (t *descriptorTypes) newService(s *descriptor.ServiceDescriptorProto, src source) (*Service, error) {
+	rv := &Service{
+		Name:        gogen.CamelCase(s.GetName()),
+		Description: src.comments.at(src.path),
+	}
+	for i, m := range s.Method {
+		meth := &ServiceMethod{
+			Name:            gogen.CamelCase(m.GetName()),
+			Description:     src.comments.at(subpath(src.path, serviceMethodsPath, int32(i))),
+			ClientStreaming: m.GetClientStreaming(),
+			ServerStreaming: m.GetServerStreaming(),
+			RequestType: &FieldType{
+				Name:     t.names[m.GetInputType()],
+				Message:  t.messages[m.GetInputType()],
+				StarExpr: true,
+			},
+			ResponseType: &FieldType{
+				Name:     t.names[m.GetOutputType()],
+				Message:  t.messages[m.GetOutputType()],
+				StarExpr: true,
+			},
+		}
+		if meth.RequestType.Message == nil || meth.ResponseType.Message == nil {
+			return nil, errors.Errorf("cannot find the request or response type of method %q", m.GetName())
+		}
+		rv.Methods = append(rv.Methods, meth)
+
+		rules, err := httpRules(m)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot read HTTP options of method %q", m.GetName())
+		}
+		for _, rule := range rules {
+			bind, err := newHTTPBinding(meth, rule)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid HTTP binding of method %q", meth.Name)
+			}
+			meth.Bindings = append(meth.Bindings, bind)
+		}
+	}
+	return rv, nil
+}
+
+// httpRules returns the rules of the google.api.http option of m. Each
+// additional binding is a rule of its own, following the rule it is within.
+func httpRules(m *descriptor.MethodDescriptorProto) ([]*google_api.HttpRule, error) {
+	if m.Options == nil || !proto.HasExtension(m.Options, google_api.E_Http) {
+		return nil, nil
+	}
+	ext, err := proto.GetExtension(m.Options, google_api.E_Http)
+	if err != nil {
+		return nil, err
+	}
+	rule, ok := ext.(*google_api.HttpRule)
+	if !ok {
+		return nil, errors.Errorf("google.api.http option has unexpected type %T", ext)
+	}
+	return flattenRules(rule), nil
+}
+
+func flattenRules(rule *google_api.HttpRule) []*google_api.HttpRule {
+	rv := []*google_api.HttpRule{rule}
+	for _, additional := range rule.AdditionalBindings {
+		rv = append(rv, flattenRules(additional)...)
+	}
+	return rv
+}
+
+// newHTTPBinding returns the binding of meth described by rule, with an
+// HTTPParameter locating each field of the request of meth.
+func newHTTPBinding(meth *ServiceMethod, rule *google_api.HttpRule) (*HTTPBinding, error) {
+	bind := &HTTPBinding{}
+	bind.Verb, bind.Path = ruleVerb(rule)
+	tmpl, err := ParsePathTemplate(bind.Path)
+	if err != nil {
+		return nil, err
+	}
+	bind.Template = tmpl
+	if bind.Body, err = bodyField(meth.RequestType.Message, "body", rule.Body); err != nil {
+		return nil, err
+	}
+	if bind.ResponseBody, err = bodyField(meth.ResponseType.Message, "response_body", rule.ResponseBody); err != nil {
+		return nil, err
+	}
+	for _, field := range meth.RequestType.Message.Fields {
+		bind.Params = append(bind.Params, &HTTPParameter{
+			Field:    field,
+			Location: paramLocation(field, tmpl, rule.Body),
+		})
+	}
+	return bind, nil
+}
+
+// ruleVerb returns the verb and path of the pattern of rule; the verb is
+// lowercase unless given by a custom pattern, and both are empty if rule has
+// no pattern.
+func ruleVerb(rule *google_api.HttpRule) (verb string, path string) {
+	switch p := rule.Pattern.(type) {
+	case *google_api.HttpRule_Get:
+		return "get", p.Get
+	case *google_api.HttpRule_Put:
+		return "put", p.Put
+	case *google_api.HttpRule_Post:
+		return "post", p.Post
+	case *google_api.HttpRule_Delete:
+		return "delete", p.Delete
+	case *google_api.HttpRule_Patch:
+		return "patch", p.Patch
+	case *google_api.HttpRule_Custom:
+		return p.Custom.GetKind(), p.Custom.GetPath()
+	}
+	return "", ""
+}
+
+// bodyField returns the field of msg named by name, the value of the option
+// kind of a rule, either "body" or "response_body"; nil if name is empty or,
+// for a body, it is "*".
+func bodyField(msg *Message, kind, name string) (*Field, error) {
+	if name == "" || name == "*" && kind == "body" {
+		return nil, nil
+	}
+	for _, field := range msg.Fields {
+		if field.PBFieldName == name || field.Name == gogen.CamelCase(name) {
+			return field, nil
+		}
+	}
+	return nil, errors.Errorf("%s %q is not a field of %s", kind, name, msg.Name)
+}
+
+// paramLocation returns where field, a field of the request, is found by a
+// binding of the path template tmpl and the body option body: "path" if a
+// variable of tmpl sets it, else "body" if body is "*" or names it, else
+// "query".
+func paramLocation(field *Field, tmpl *PathTemplate, body string) string {
+	for _, v := range tmpl.Variables() {
+		// Field paths name the fields as in the definition, which may be
+		// lowercase while the Go name is CamelCased
+		if gogen.CamelCase(strings.Split(v.Variable, ".")[0]) == field.Name {
+			return "path"
+		}
+	}
+	if body == "*" || body != "" && gogen.CamelCase(strings.Split(body, ".")[0]) == field.Name {
+		return "body"
+	}
+	return "query"
+}
+
+// goImportPaths returns the Go import path of each of files, keyed by file
+// name, as protoc-gen-gogo determines them given parameter.
+func goImportPaths(files []*descriptor.FileDescriptorProto, parameter string) map[string]string {
+	mapped := make(map[string]string)
+	for _, p := range strings.Split(parameter, ",") {
+		if !strings.HasPrefix(p, "M") {
+			continue
+		}
+		if i := strings.Index(p, "="); i >= 0 {
+			mapped[p[1:i]] = p[i+1:]
+		}
+	}
+
+	rv := make(map[string]string)
+	for _, f := range files {
+		name := f.GetName()
+		opt := f.GetOptions().GetGoPackage()
+		switch {
+		case mapped[name] != "":
+			rv[name] = mapped[name]
+		case strings.Contains(opt, ";"):
+			rv[name] = opt[:strings.Index(opt, ";")]
+		case strings.Contains(opt, "/"):
+			rv[name] = opt
+		default:
+			rv[name] = path.Dir(name)
+		}
+	}
+	return rv
+}
+
+// uniqueAlias returns a name to import the Go package named name as, which
+// is not within taken, and adds it to taken. Aliases end in "pb" so that they
+// do not collide with the other packages imported by generated code.
+func uniqueAlias(name string, taken map[string]bool) string {
+	base := name
+	if !strings.HasSuffix(base, "pb") {
+		base += "pb"
+	}
+	alias := base
+	for i := 2; taken[alias]; i++ {
+		alias = base + strconv.Itoa(i)
+	}
+	taken[alias] = true
+	return alias
+}
+
+// goPackageName returns the name of the Go package protoc-gen-gogo generates
+// for f; the name given by its go_package option, or else derived from its
+// proto package or file name.
+func goPackageName(f *descriptor.FileDescriptorProto) string {
+	name := f.GetOptions().GetGoPackage()
+	if i := strings.Index(name, ";"); i >= 0 {
+		name = name[i+1:]
+	} else if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	if name == "" {
+		name = f.GetPackage()
+	}
+	if name == "" {
+		name = strings.TrimSuffix(f.GetName()[strings.LastIndex(f.GetName(), "/")+1:], ".proto")
+	}
+
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+	if r, _ := utf8.DecodeRuneInString(name); unicode.IsDigit(r) {
+		name = "_" + name
+	}
+	return name
+}
@@ -0,0 +1,569 @@
+package svcdef
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+
+	"github.com/metaverse/truss/truss/parseproto"
+)
+
+func TestNewFromRequestTypes(t *testing.T) {
+	defStr := `
+		syntax = "proto3";
+
+		package general;
+
+		option go_package = "example.com/general/pb;generalpb";
+
+		import "google/protobuf/timestamp.proto";
+
+		message Outer {
+			message Inner {
+				string id = 1;
+			}
+			enum Kind {
+				UNKNOWN = 0;
+				OTHER = 1;
+			}
+			Inner inner = 1;
+			repeated Inner inners = 2;
+			map<string, Inner> named = 3;
+			oneof choice {
+				Kind kind = 4;
+				string label = 5;
+			}
+			google.protobuf.Timestamp created = 6;
+			repeated bytes blobs = 7;
+		}
+
+		service Svc {
+			rpc Get(Outer) returns (Outer.Inner) {}
+		}
+	`
+	sd, err := NewFromString(defStr, gopath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := sd.PkgName, "generalpb"; got != want {
+		t.Errorf("PkgName = %q, want %q", got, want)
+	}
+
+	var names []string
+	for _, m := range sd.Messages {
+		names = append(names, m.Name)
+	}
+	if got, want := names, []string{"Outer", "Outer_Inner"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Messages = %v, want %v; imported messages must not be included", got, want)
+	}
+	if got, want := len(sd.Enums), 1; got != want || sd.Enums[0].Name != "Outer_Kind" {
+		t.Fatalf("Enums = %v, want only Outer_Kind", sd.Enums)
+	}
+
+	outer, inner := sd.Messages[0], sd.Messages[1]
+	fields := make(map[string]*Field)
+	for _, f := range outer.Fields {
+		fields[f.Name] = f
+	}
+
+	if f := fields["Inner"]; f.Type.Message != inner || !f.Type.StarExpr || f.Type.Name != "Outer_Inner" {
+		t.Errorf("Inner field type is not resolved to Outer_Inner: %#v", f.Type)
+	}
+	if f := fields["Inners"]; f.Type.Message != inner || !f.Type.ArrayType {
+		t.Errorf("Inners field is not a slice of Outer_Inner: %#v", f.Type)
+	}
+	if f := fields["Named"]; f.Type.Map == nil || f.Type.Map.KeyType.Name != "string" || f.Type.Map.ValueType.Message != inner {
+		t.Errorf("Named field is not a map of string to Outer_Inner: %#v", f.Type)
+	}
+	if f := fields["Created"]; f.Type.Name != "Timestamp" || !f.Type.StarExpr {
+		t.Errorf("Created field is not a Timestamp: %#v", f.Type)
+	}
+	if f := fields["Blobs"]; f.Type.Name != "[]byte" || !f.Type.ArrayType {
+		t.Errorf("Blobs field is not a [][]byte: %#v", f.Type)
+	}
+
+	choice := fields["Choice"]
+	if choice == nil {
+		t.Fatal("oneof choice is missing")
+	}
+	if got, want := choice.Type.Name, "isOuter_Choice"; got != want {
+		t.Errorf("oneof type = %q, want %q", got, want)
+	}
+	if got, want := len(choice.Type.Oneof), 2; got != want {
+		t.Fatalf("oneof options = %d, want %d", got, want)
+	}
+	// The struct wrapping option kind is disambiguated from enum Outer_Kind
+	kind := choice.Type.Oneof[0]
+	if kind.PBFieldName != "kind" || kind.Type.Enum != sd.Enums[0] || kind.Type.Message.Name != "Outer_Kind_" {
+		t.Errorf("oneof option kind is wrong: %#v", kind.Type)
+	}
+	if label := choice.Type.Oneof[1]; label.Type.Name != "string" || label.Type.Message.Name != "Outer_Label" {
+		t.Errorf("oneof option label is wrong: %#v", label.Type)
+	}
+
+	meth := sd.Services[0].Methods[0]
+	if meth.RequestType.Message != outer || meth.ResponseType.Message != inner {
+		t.Errorf("method types not resolved: %#v, %#v", meth.RequestType, meth.ResponseType)
+	}
+	if got := len(meth.Bindings); got != 0 {
+		t.Errorf("method without google.api.http has %d bindings", got)
+	}
+}
+
+func TestNewFromRequestProto3Optional(t *testing.T) {
+	defStr := `
+		syntax = "proto3";
+
+		package general;
+
+		message Msg {
+			optional string name = 1;
+			oneof choice {
+				string label = 2;
+			}
+			optional int32 count = 3;
+		}
+	`
+	sd, err := NewFromString(defStr, gopath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, f := range sd.Messages[0].Fields {
+		names = append(names, f.Name)
+	}
+	if got, want := names, []string{"Name", "Choice", "Count"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Fields = %v, want %v; optional fields are not oneofs", got, want)
+	}
+	if f := sd.Messages[0].Fields[0]; f.Type.Name != "string" || f.Type.Oneof != nil {
+		t.Errorf("optional field name is not a string: %#v", f.Type)
+	}
+}
+
+func TestNewFromRequestHTTPRules(t *testing.T) {
+	defStr := `
+		syntax = "proto3";
+
+		package general;
+
+		import "github.com/metaverse/truss/deftree/googlethirdparty/annotations.proto";
+
+		message Req {
+			string a = 1;
+			string b = 2;
+		}
+
+		service Svc {
+			rpc Do(Req) returns (Req) {
+				option (google.api.http) = {
+					post: "/do/{a}"
+					body: "*"
+					additional_bindings {
+						get: "/do/{a}/{b}"
+					}
+					additional_bindings {
+						custom {
+							kind: "HEAD"
+							path: "/do"
+						}
+					}
+				};
+			}
+		}
+	`
+	sd, err := NewFromString(defStr, gopath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bindings := sd.Services[0].Methods[0].Bindings
+	if got, want := len(bindings), 3; got != want {
+		t.Fatalf("binding count = %d, want %d", got, want)
+	}
+
+	tests := []struct {
+		verb, path string
+		locations  []string
+	}{
+		{"post", "/do/{a}", []string{"path", "body"}},
+		{"get", "/do/{a}/{b}", []string{"path", "path"}},
+		{"HEAD", "/do", []string{"query", "query"}},
+	}
+	for i, tt := range tests {
+		b := bindings[i]
+		if b.Verb != tt.verb || b.Path != tt.path {
+			t.Errorf("binding %d = %s %s, want %s %s", i, b.Verb, b.Path, tt.verb, tt.path)
+		}
+		for j, p := range b.Params {
+			if p.Location != tt.locations[j] {
+				t.Errorf("binding %d param %q location = %q, want %q", i, p.Field.Name, p.Location, tt.locations[j])
+			}
+		}
+		if b.Body != nil {
+			t.Errorf("binding %d Body = %+v, want nil", i, b.Body)
+		}
+	}
+
+	// A body naming a field binds the body to that field only
+	sd, err = NewFromString(strings.Replace(defStr, `body: "*"`, `body: "b"`, 1), gopath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := sd.Services[0].Methods[0].Bindings[0]
+	if b.Body == nil || b.Body.Name != "B" {
+		t.Errorf("Body = %+v, want B", b.Body)
+	}
+	if got, want := []string{b.Params[0].Location, b.Params[1].Location}, []string{"path", "body"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("param locations = %v, want %v", got, want)
+	}
+
+	if _, err := NewFromString(strings.Replace(defStr, `body: "*"`, `body: "c"`, 1), gopath); err == nil {
+		t.Error("NewFromString succeeded with a body which is not a field of the request")
+	}
+}
+
+func TestNewFromRequestResponseBody(t *testing.T) {
+	defStr := `
+		syntax = "proto3";
+
+		package general;
+
+		import "github.com/metaverse/truss/deftree/googlethirdparty/annotations.proto";
+
+		message Req {
+			string a = 1;
+		}
+
+		message Resp {
+			repeated string items = 1;
+			int32 total_count = 2;
+		}
+
+		service Svc {
+			rpc List(Req) returns (Resp) {
+				option (google.api.http) = {
+					get: "/items"
+					response_body: "items"
+					additional_bindings {
+						get: "/all"
+					}
+				};
+			}
+		}
+	`
+	sd, err := NewFromString(defStr, gopath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bindings := sd.Services[0].Methods[0].Bindings
+	if got, want := len(bindings), 2; got != want {
+		t.Fatalf("binding count = %d, want %d", got, want)
+	}
+	if got := bindings[0].ResponseBody; got == nil || got.Name != "Items" {
+		t.Errorf("ResponseBody of binding 0 = %+v, want Items", got)
+	}
+	if got := bindings[1].ResponseBody; got != nil {
+		t.Errorf("ResponseBody of binding 1 = %+v, want nil", got)
+	}
+
+	invalid := strings.Replace(defStr, `response_body: "items"`, `response_body: "missing"`, 1)
+	if _, err := NewFromString(invalid, gopath); err == nil {
+		t.Error("NewFromString succeeded with a response_body which is not a field of the response")
+	}
+}
+
+func TestNewFromRequestGoPackages(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trusssvcdef")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"svc/svc.proto": `
+			syntax = "proto3";
+			package svc;
+			import "types/types.proto";
+			import "other/other.proto";
+			message Local {
+				other.Other other = 1;
+			}
+			service Svc {
+				rpc Do(types.Req) returns (Local) {}
+			}
+		`,
+		"types/types.proto": `
+			syntax = "proto3";
+			package types;
+			option go_package = "example.com/types;types";
+			message Req {
+				string a = 1;
+			}
+		`,
+		"other/other.proto": `
+			syntax = "proto3";
+			package other;
+			option go_package = "example.com/ignored;other";
+			enum Other {
+				ZERO = 0;
+			}
+		`,
+	}
+	for name, def := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(def), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req, err := parseproto.CodeGeneratorRequest([]string{filepath.Join(dir, "svc", "svc.proto")}, []string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The M option takes precedence over the go_package option
+	req.Parameter = proto.String("plugins=grpc,Msvc/svc.proto=example.com/svc,Mother/other.proto=example.com/other")
+	sd, err := NewFromRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*GoPackage{
+		{ImportPath: "example.com/types", Alias: "typespb"},
+		{ImportPath: "example.com/other", Alias: "otherpb"},
+	}
+	if !reflect.DeepEqual(sd.Imports, want) {
+		t.Fatalf("Imports = %v, want %v", sd.Imports, want)
+	}
+
+	meth := sd.Services[0].Methods[0]
+	if got := meth.RequestType.Package(); got != sd.Imports[0] {
+		t.Errorf("request package = %v, want %v", got, sd.Imports[0])
+	}
+	if got := meth.ResponseType.Package(); got != nil {
+		t.Errorf("response package = %v, want the package of the definition", got)
+	}
+	if got := sd.Messages[0].Fields[0].Type.Package(); got != sd.Imports[1] {
+		t.Errorf("enum field package = %v, want %v", got, sd.Imports[1])
+	}
+}
+
+func TestNewFromRequestComments(t *testing.T) {
+	defStr := `
+		syntax = "proto3";
+
+		package general;
+
+		// Svc serves
+		service Svc {
+			// Get gets
+			//
+			//   indented
+			rpc Get(Outer) returns (Outer.Inner) {}
+		}
+
+		// Outer is outermost
+		message Outer {
+			// Inner is within
+			message Inner {
+				// id identifies
+				string id = 1;
+			}
+			// Kind is a kind
+			enum Kind {
+				// UNKNOWN is the default
+				UNKNOWN = 0;
+				OTHER = 2;
+			}
+			Inner inner = 1;
+			// choice chooses
+			oneof choice {
+				// kind is an option
+				Kind kind = 4;
+			}
+		}
+	`
+	sd, err := NewFromString(defStr, gopath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outer, inner := sd.Messages[0], sd.Messages[1]
+	svc, kind := sd.Services[0], sd.Enums[0]
+	for _, test := range []struct {
+		what, got, want string
+	}{
+		{"service", svc.Description, "Svc serves"},
+		{"method", svc.Methods[0].Description, "Get gets\n\n  indented"},
+		{"message", outer.Description, "Outer is outermost"},
+		{"nested message", inner.Description, "Inner is within"},
+		{"field", inner.Fields[0].Description, "id identifies"},
+		{"field without comment", outer.Fields[0].Description, ""},
+		{"oneof", outer.Fields[1].Description, "choice chooses"},
+		{"oneof option", outer.Fields[1].Type.Oneof[0].Description, "kind is an option"},
+		{"enum", kind.Description, "Kind is a kind"},
+		{"enum value", kind.Values[0].Description, "UNKNOWN is the default"},
+		{"message proto name", inner.ProtoName, "general.Outer.Inner"},
+		{"enum proto name", kind.ProtoName, "general.Outer.Kind"},
+	} {
+		if test.got != test.want {
+			t.Errorf("%s = %q, want %q", test.what, test.got, test.want)
+		}
+	}
+
+	want := []*EnumValue{
+		{Name: "UNKNOWN", Number: 0, Description: "UNKNOWN is the default"},
+		{Name: "OTHER", Number: 2},
+	}
+	if !reflect.DeepEqual(kind.Values, want) {
+		t.Errorf("Values = %+v, want %+v", kind.Values, want)
+	}
+}
+
+func TestNewFromDescriptorSetMissingFile(t *testing.T) {
+	set := &descriptor.FileDescriptorSet{
+		File: []*descriptor.FileDescriptorProto{
+			{Name: proto.String("a.proto")},
+		},
+	}
+	if _, err := NewFromDescriptorSet(set, []string{"b.proto"}); err == nil {
+		t.Error("expected an error for a file missing from the set")
+	}
+}
+
+func TestParamLocation(t *testing.T) {
+	fields := []*Field{
+		{Name: "A", PBFieldName: "a"},
+		{Name: "B", PBFieldName: "b"},
+		{Name: "Parent", PBFieldName: "parent"},
+	}
+	tests := []struct {
+		name       string
+		path, body string
+		want       []string
+	}{
+		{
+			name: "basic",
+			path: "/{a}/{b}",
+			want: []string{"path", "path", "query"},
+		},
+		{
+			name: "variable with path segments",
+			path: "/v1/{parent=shelves/*}/books",
+			want: []string{"query", "query", "path"},
+		},
+		{
+			name: "body of every other field",
+			path: "/v1/{parent.name}",
+			body: "*",
+			want: []string{"body", "body", "path"},
+		},
+		{
+			name: "body of one field",
+			path: "/v1",
+			body: "b",
+			want: []string{"query", "body", "query"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := ParsePathTemplate(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, f := range fields {
+				got = append(got, paramLocation(f, tmpl, tt.body))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("paramLocation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPParams(t *testing.T) {
+	protoCode := `
+		syntax = "proto3";
+		package TEST;
+		import "github.com/metaverse/truss/deftree/googlethirdparty/annotations.proto";
+
+		enum EnumType {
+			A = 0;
+			B = 1;
+			C = 2;
+		}
+
+		message MsgA {
+			int64 A = 1;
+		}
+
+		message Thing {
+			MsgA a = 1;
+			repeated MsgA AA = 17;
+			EnumType C = 18;
+			map<string, MsgA> MapField = 19;
+		}
+
+		service Map {
+			rpc GetThing (Thing) returns (Thing) {
+				option (google.api.http) = {
+					get: "/1"
+					body: "a"
+					response_body: "AA"
+				};
+			}
+		}`
+	sd, err := NewFromString(protoCode, gopath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	meth := sd.Services[0].Methods[0]
+	rq := meth.RequestType
+	bind := meth.Bindings[0]
+	if len(bind.Params) != len(rq.Message.Fields) {
+		t.Fatalf(
+			"Number of http parameters '%v' differs from number of fields on message '%v'",
+			len(bind.Params), len(rq.Message.Fields))
+	}
+
+	if bind.Body != rq.Message.Fields[0] {
+		t.Errorf("Body = %+v, want the field A of the request", bind.Body)
+	}
+	if bind.ResponseBody != meth.ResponseType.Message.Fields[1] {
+		t.Errorf("ResponseBody = %+v, want the field AA of the response", bind.ResponseBody)
+	}
+
+	// Verify that each HTTPParam refers to the field of the RequestType of
+	// its position, and its location
+	var cases = []struct {
+		Name     string
+		Location string
+	}{
+		{"A", "body"},
+		{"AA", "query"},
+		{"C", "query"},
+		{"MapField", "query"},
+	}
+	for i, tcase := range cases {
+		param := bind.Params[i]
+		if param.Field != rq.Message.Fields[i] || param.Field.Name != tcase.Name {
+			t.Fatalf("HTTPParam %d refers to field %q, want %q of the RequestType", i, param.Field.Name, tcase.Name)
+		}
+		if param.Location != tcase.Location {
+			t.Fatalf("The HTTPParameter %q has a location of %q, expected %q", tcase.Name, param.Location, tcase.Location)
+		}
+	}
+}
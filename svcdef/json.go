@@ -0,0 +1,314 @@
+package svcdef
+
+import (
+	"encoding/json"
+)
+
+// MarshalJSON encodes sd as JSON of a stable form, for tools needing the view
+// of a definition truss generates from. Each type and field of svcdef is an
+// object whose keys are the snake_case names of its fields, with these
+// exceptions, which make the encoding free of cycles:
+//
+// Messages and enums are listed once each, within "messages" and "enums",
+// and are referred to by their "id" elsewhere: a FieldType refers to its
+// Message or Enum by id. The id of a message or enum of the package of the
+// definition is its name, while that of another package is its name qualified
+// by the import path of that package, e.g. "example.com/types/pb.Money". Every
+// message or enum referred to is listed, whether or not it is of the
+// definition's package.
+//
+// Since each option of a oneof is wrapped in a struct of its own, the
+// FieldType of an option names that struct as its "oneof_wrapper"; it refers
+// to a message only if the option is of a message type.
+//
+// An HTTPParameter refers to its Field by Name, as a oneof has no
+// PBFieldName.
+func (sd *Svcdef) MarshalJSON() ([]byte, error) {
+	e := jsonEncoder{
+		messages: make(map[*Message]bool),
+		enums:    make(map[*Enum]bool),
+	}
+	for _, m := range sd.Messages {
+		e.message(m)
+	}
+	for _, en := range sd.Enums {
+		e.enum(en)
+	}
+
+	rv := jsonSvcdef{
+		PkgName:  sd.PkgName,
+		Imports:  []jsonGoPackage{},
+		Services: []jsonService{},
+		Messages: []jsonMessage{},
+		Enums:    []jsonEnum{},
+	}
+	for _, p := range sd.Imports {
+		rv.Imports = append(rv.Imports, jsonGoPackage{ImportPath: p.ImportPath, Alias: p.Alias})
+	}
+	for _, svc := range sd.Services {
+		rv.Services = append(rv.Services, e.service(svc))
+	}
+	// Encoding the fields of a message may refer to messages not yet listed,
+	// which are appended to those to encode
+	for i := 0; i < len(e.messageOrder); i++ {
+		m := e.messageOrder[i]
+		jm := jsonMessage{
+			ID:          typeID(m.Name, m.Package),
+			Name:        m.Name,
+			Package:     importPath(m.Package),
+			ProtoName:   m.ProtoName,
+			Description: m.Description,
+			Fields:      []jsonField{},
+		}
+		for _, f := range m.Fields {
+			jm.Fields = append(jm.Fields, e.field(f))
+		}
+		rv.Messages = append(rv.Messages, jm)
+	}
+	for _, en := range e.enumOrder {
+		je := jsonEnum{
+			ID:          typeID(en.Name, en.Package),
+			Name:        en.Name,
+			Package:     importPath(en.Package),
+			ProtoName:   en.ProtoName,
+			Description: en.Description,
+			Values:      []jsonEnumValue{},
+		}
+		for _, v := range en.Values {
+			je.Values = append(je.Values, jsonEnumValue{
+				Name:        v.Name,
+				Number:      v.Number,
+				Description: v.Description,
+			})
+		}
+		rv.Enums = append(rv.Enums, je)
+	}
+
+	return json.Marshal(rv)
+}
+
+type jsonSvcdef struct {
+	PkgName  string          `json:"pkg_name"`
+	Imports  []jsonGoPackage `json:"imports"`
+	Services []jsonService   `json:"services"`
+	Messages []jsonMessage   `json:"messages"`
+	Enums    []jsonEnum      `json:"enums"`
+}
+
+type jsonGoPackage struct {
+	ImportPath string `json:"import_path"`
+	Alias      string `json:"alias"`
+}
+
+type jsonMessage struct {
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Package     string      `json:"package,omitempty"`
+	ProtoName   string      `json:"proto_name,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Fields      []jsonField `json:"fields"`
+}
+
+type jsonEnum struct {
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	Package     string          `json:"package,omitempty"`
+	ProtoName   string          `json:"proto_name,omitempty"`
+	Description string          `json:"description,omitempty"`
+	Values      []jsonEnumValue `json:"values"`
+}
+
+type jsonEnumValue struct {
+	Name        string `json:"name"`
+	Number      int32  `json:"number"`
+	Description string `json:"description,omitempty"`
+}
+
+type jsonService struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description,omitempty"`
+	Methods     []jsonServiceMethod `json:"methods"`
+}
+
+type jsonServiceMethod struct {
+	Name            string            `json:"name"`
+	Description     string            `json:"description,omitempty"`
+	RequestType     *jsonFieldType    `json:"request_type"`
+	ResponseType    *jsonFieldType    `json:"response_type"`
+	ClientStreaming bool              `json:"client_streaming"`
+	ServerStreaming bool              `json:"server_streaming"`
+	Bindings        []jsonHTTPBinding `json:"bindings"`
+}
+
+type jsonField struct {
+	Name        string         `json:"name"`
+	PBFieldName string         `json:"pb_field_name,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Type        *jsonFieldType `json:"type"`
+}
+
+type jsonFieldType struct {
+	Name         string      `json:"name,omitempty"`
+	Enum         string      `json:"enum,omitempty"`
+	Oneof        []jsonField `json:"oneof,omitempty"`
+	OneofWrapper string      `json:"oneof_wrapper,omitempty"`
+	Message      string      `json:"message,omitempty"`
+	Map          *jsonMap    `json:"map,omitempty"`
+	StarExpr     bool        `json:"star_expr,omitempty"`
+	ArrayType    bool        `json:"array_type,omitempty"`
+}
+
+type jsonMap struct {
+	KeyType   *jsonFieldType `json:"key_type"`
+	ValueType *jsonFieldType `json:"value_type"`
+}
+
+type jsonHTTPBinding struct {
+	Verb         string              `json:"verb"`
+	Path         string              `json:"path"`
+	Body         string              `json:"body,omitempty"`
+	ResponseBody string              `json:"response_body,omitempty"`
+	Params       []jsonHTTPParameter `json:"params"`
+}
+
+type jsonHTTPParameter struct {
+	Field    string `json:"field"`
+	Location string `json:"location"`
+}
+
+// jsonEncoder lists each message and enum referred to, in the order they are
+// first referred to.
+type jsonEncoder struct {
+	messages     map[*Message]bool
+	messageOrder []*Message
+	enums        map[*Enum]bool
+	enumOrder    []*Enum
+}
+
+// message lists m, returning its id.
+func (e *jsonEncoder) message(m *Message) string {
+	if !e.messages[m] {
+		e.messages[m] = true
+		e.messageOrder = append(e.messageOrder, m)
+	}
+	return typeID(m.Name, m.Package)
+}
+
+// enum lists en, returning its id.
+func (e *jsonEncoder) enum(en *Enum) string {
+	if !e.enums[en] {
+		e.enums[en] = true
+		e.enumOrder = append(e.enumOrder, en)
+	}
+	return typeID(en.Name, en.Package)
+}
+
+func (e *jsonEncoder) service(svc *Service) jsonService {
+	rv := jsonService{
+		Name:        svc.Name,
+		Description: svc.Description,
+		Methods:     []jsonServiceMethod{},
+	}
+	for _, m := range svc.Methods {
+		jm := jsonServiceMethod{
+			Name:            m.Name,
+			Description:     m.Description,
+			RequestType:     e.fieldType(m.RequestType, false),
+			ResponseType:    e.fieldType(m.ResponseType, false),
+			ClientStreaming: m.ClientStreaming,
+			ServerStreaming: m.ServerStreaming,
+			Bindings:        []jsonHTTPBinding{},
+		}
+		for _, b := range m.Bindings {
+			jb := jsonHTTPBinding{
+				Verb:   b.Verb,
+				Path:   b.Path,
+				Params: []jsonHTTPParameter{},
+			}
+			if b.Body != nil {
+				jb.Body = b.Body.Name
+			}
+			if b.ResponseBody != nil {
+				jb.ResponseBody = b.ResponseBody.Name
+			}
+			for _, p := range b.Params {
+				jb.Params = append(jb.Params, jsonHTTPParameter{
+					Field:    p.Field.Name,
+					Location: p.Location,
+				})
+			}
+			jm.Bindings = append(jm.Bindings, jb)
+		}
+		rv.Methods = append(rv.Methods, jm)
+	}
+	return rv
+}
+
+func (e *jsonEncoder) field(f *Field) jsonField {
+	rv := jsonField{
+		Name:        f.Name,
+		PBFieldName: f.PBFieldName,
+		Description: f.Description,
+		Type:        e.fieldType(f.Type, false),
+	}
+	if f.Type != nil {
+		for _, option := range f.Type.Oneof {
+			rv.Type.Oneof = append(rv.Type.Oneof, jsonField{
+				Name:        option.Name,
+				PBFieldName: option.PBFieldName,
+				Description: option.Description,
+				Type:        e.fieldType(option.Type, true),
+			})
+		}
+	}
+	return rv
+}
+
+// fieldType returns the encoding of t, without any oneof options. If option
+// is true, t is the type of an option of a oneof, whose Message is the
+// struct wrapping the option.
+func (e *jsonEncoder) fieldType(t *FieldType, option bool) *jsonFieldType {
+	if t == nil {
+		return nil
+	}
+	rv := &jsonFieldType{
+		Name:      t.Name,
+		StarExpr:  t.StarExpr,
+		ArrayType: t.ArrayType,
+	}
+	switch {
+	case option && t.Message != nil:
+		rv.OneofWrapper = t.Message.Name
+		// An option of a message type refers to it as well
+		if wrapped := t.Message.Fields; len(wrapped) == 1 && wrapped[0].Type.Message != nil {
+			rv.Message = e.message(wrapped[0].Type.Message)
+		}
+	case t.Message != nil:
+		rv.Message = e.message(t.Message)
+	}
+	if t.Enum != nil {
+		rv.Enum = e.enum(t.Enum)
+	}
+	if t.Map != nil {
+		rv.Map = &jsonMap{
+			KeyType:   e.fieldType(t.Map.KeyType, false),
+			ValueType: e.fieldType(t.Map.ValueType, false),
+		}
+	}
+	return rv
+}
+
+// typeID returns the id a message or enum is referred to by.
+func typeID(name string, pkg *GoPackage) string {
+	if pkg == nil {
+		return name
+	}
+	return pkg.ImportPath + "." + name
+}
+
+func importPath(pkg *GoPackage) string {
+	if pkg == nil {
+		return ""
+	}
+	return pkg.ImportPath
+}
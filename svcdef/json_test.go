@@ -0,0 +1,135 @@
+package svcdef
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	defStr := `
+		syntax = "proto3";
+
+		package general;
+
+		option go_package = "example.com/general/pb;generalpb";
+
+		import "google/protobuf/timestamp.proto";
+		import "github.com/metaverse/truss/deftree/googlethirdparty/annotations.proto";
+
+		message Outer {
+			message Inner {
+				string id = 1;
+			}
+			enum Kind {
+				UNKNOWN = 0;
+				OTHER = 1;
+			}
+			Inner inner = 1;
+			repeated Inner inners = 2;
+			map<string, Inner> named = 3;
+			oneof choice {
+				Kind kind = 4;
+				string label = 5;
+			}
+			google.protobuf.Timestamp created = 6;
+			string name = 7;
+		}
+
+		service Svc {
+			rpc Get(Outer) returns (Outer.Inner) {
+				option (google.api.http) = {
+					get: "/outer/{name}"
+				};
+			}
+		}
+	`
+	sd, err := NewFromString(defStr, gopath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(sd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := json.Marshal(sd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, again) {
+		t.Fatalf("encoding is not stable:\n%s\n%s", b, again)
+	}
+
+	var got jsonSvcdef
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+	for _, m := range got.Messages {
+		ids = append(ids, m.ID)
+	}
+	// The Timestamp message is listed as it is referred to
+	wantIDs := []string{"Outer", "Outer_Inner", "github.com/golang/protobuf/ptypes/timestamp.Timestamp"}
+	if !reflect.DeepEqual(ids, wantIDs) {
+		t.Fatalf("message ids = %v, want %v", ids, wantIDs)
+	}
+
+	fields := make(map[string]jsonField)
+	for _, f := range got.Messages[0].Fields {
+		fields[f.Name] = f
+	}
+	if got, want := fields["Inner"].Type, (&jsonFieldType{Name: "Outer_Inner", Message: "Outer_Inner", StarExpr: true}); !reflect.DeepEqual(got, want) {
+		t.Errorf("type of Inner = %+v, want %+v", got, want)
+	}
+	if got := fields["Inners"].Type; !got.ArrayType || got.Message != "Outer_Inner" {
+		t.Errorf("type of Inners = %+v, want a repeated Outer_Inner", got)
+	}
+	if m := fields["Named"].Type.Map; m == nil || m.KeyType.Name != "string" || m.ValueType.Message != "Outer_Inner" {
+		t.Errorf("type of Named = %+v, want a map of string to Outer_Inner", fields["Named"].Type)
+	}
+	choice := fields["Choice"].Type
+	if len(choice.Oneof) != 2 {
+		t.Fatalf("type of Choice = %+v, want a oneof of 2 options", choice)
+	}
+	if got, want := choice.Oneof[0], (jsonField{
+		Name:        "Kind",
+		PBFieldName: "kind",
+		Type:        &jsonFieldType{Name: "Outer_Kind", Enum: "Outer_Kind", OneofWrapper: "Outer_Kind_"},
+	}); !reflect.DeepEqual(got, want) {
+		t.Errorf("first option of Choice = %+v, want %+v", got, want)
+	}
+	if got, want := fields["Created"].Type.Message, "github.com/golang/protobuf/ptypes/timestamp.Timestamp"; got != want {
+		t.Errorf("type of Created refers to %q, want %q", got, want)
+	}
+
+	if len(got.Enums) != 1 || got.Enums[0].ID != "Outer_Kind" {
+		t.Fatalf("enums = %+v, want Outer_Kind", got.Enums)
+	}
+	if got, want := got.Enums[0].Values, []jsonEnumValue{{Name: "UNKNOWN", Number: 0}, {Name: "OTHER", Number: 1}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("values of Outer_Kind = %+v, want %+v", got, want)
+	}
+	if got, want := got.Enums[0].ProtoName, "general.Outer.Kind"; got != want {
+		t.Errorf("proto name of Outer_Kind = %q, want %q", got, want)
+	}
+
+	if len(got.Services) != 1 || len(got.Services[0].Methods) != 1 {
+		t.Fatalf("services = %+v, want Svc with one method", got.Services)
+	}
+	meth := got.Services[0].Methods[0]
+	if meth.RequestType.Message != "Outer" || meth.ResponseType.Message != "Outer_Inner" {
+		t.Errorf("Get takes %+v and returns %+v, want Outer and Outer_Inner", meth.RequestType, meth.ResponseType)
+	}
+	if len(meth.Bindings) != 1 {
+		t.Fatalf("bindings = %+v, want 1", meth.Bindings)
+	}
+	locations := make(map[string]string)
+	for _, p := range meth.Bindings[0].Params {
+		locations[p.Field] = p.Location
+	}
+	if locations["Name"] != "path" || locations["Inner"] != "query" || locations["Choice"] != "query" {
+		t.Errorf("parameter locations = %v, want Name in the path, Inner and Choice in the query", locations)
+	}
+}
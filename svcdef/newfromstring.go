@@ -1,13 +1,11 @@
 package svcdef
 
 import (
-	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
-	"strings"
 
-	"github.com/metaverse/truss/truss/execprotoc"
+	"github.com/metaverse/truss/truss/parseproto"
 	"github.com/pkg/errors"
 )
 
@@ -15,7 +13,6 @@ import (
 // useful in tests.
 func NewFromString(def string, gopath []string) (*Svcdef, error) {
 	const defFileName = "definition.proto"
-	const goFileName = "definition.pb.go"
 
 	// Write our proto file to a directory
 	protoDir, err := ioutil.TempDir("", "trusssvcdef")
@@ -31,28 +28,14 @@ func NewFromString(def string, gopath []string) (*Svcdef, error) {
 		return nil, errors.Wrap(err, "cannot write proto definition to file")
 	}
 
-	// Create our pb.go file
-	err = execprotoc.GeneratePBDotGo([]string{defPath}, gopath, protoDir)
+	req, err := parseproto.CodeGeneratorRequest([]string{defPath}, parseproto.GoPathImports(gopath))
 	if err != nil {
-		return nil, errors.Wrap(err, "cannot create a pb.go file")
+		return nil, errors.Wrap(err, "cannot parse proto definition")
 	}
-	gPath := filepath.Join(protoDir, goFileName)
 
-	buf, err := ioutil.ReadFile(gPath)
+	sd, err := NewFromRequest(req)
 	if err != nil {
-		return nil, errors.Wrapf(err, "cannot read pb.go file %q", gPath)
-	}
-	pbgo := string(buf)
-
-	pbgoMap := map[string]io.Reader{
-		"/tmp/doesntexist.pb.go": strings.NewReader(pbgo),
-	}
-	pFileMap := map[string]io.Reader{
-		"/tmp/doesntexist.proto": strings.NewReader(def),
-	}
-	sd, err := New(pbgoMap, pFileMap)
-	if err != nil {
-		return nil, errors.Wrap(err, "cannot create new svcdef from pb.go and definition")
+		return nil, errors.Wrap(err, "cannot create new svcdef from definition")
 	}
 
 	return sd, nil
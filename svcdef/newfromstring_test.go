@@ -55,7 +55,8 @@ func TestMessages(t *testing.T) {
 	sd := basicFromString(t)
 	expected := []*Message{
 		&Message{
-			Name: "SumRequest",
+			Name:      "SumRequest",
+			ProtoName: "general.SumRequest",
 			Fields: []*Field{
 				&Field{
 					Name:        "A",
@@ -84,7 +85,8 @@ func TestMessages(t *testing.T) {
 			},
 		},
 		&Message{
-			Name: "SumReply",
+			Name:      "SumReply",
+			ProtoName: "general.SumReply",
 			Fields: []*Field{
 				&Field{
 					Name:        "V",
@@ -112,10 +114,6 @@ func TestMessages(t *testing.T) {
 				},
 			},
 		},
-		&Message{
-			Name:   "UnimplementedSumSvcServer",
-			Fields: nil,
-		},
 	}
 
 	if got, want := sd.Messages, expected; !reflect.DeepEqual(got, want) {
@@ -130,6 +128,12 @@ func TestHTTPBinding(t *testing.T) {
 		&HTTPBinding{
 			Verb: "get",
 			Path: "/sum/{a}",
+			Template: &PathTemplate{
+				Segments: []*PathSegment{
+					{Literal: "sum"},
+					{Variable: "a", Segments: []*PathSegment{{Wildcard: "*"}}},
+				},
+			},
 			Params: []*HTTPParameter{
 				&HTTPParameter{
 					Location: "path",
@@ -154,7 +158,7 @@ func TestHTTPBinding(t *testing.T) {
 			},
 		},
 	}
-	output := sd.Service.Methods[0].Bindings
+	output := sd.Services[0].Methods[0].Bindings
 	if got, want := output, expected; !reflect.DeepEqual(got, want) {
 		diff := gentesthelper.DiffStrings(spew.Sdump(got), spew.Sdump(want))
 		t.Errorf("got != want; methods differ: %v\n", diff)
@@ -189,3 +193,78 @@ func TestNoHTTPBinding(t *testing.T) {
 		t.Fatal("Failed to create svcdef from string:", err)
 	}
 }
+
+func TestMultipleServices(t *testing.T) {
+	defstr := `
+		syntax = "proto3";
+
+		// General package
+		package general;
+
+		import "github.com/metaverse/truss/deftree/googlethirdparty/annotations.proto";
+
+		message SumRequest {
+			int64 a = 1;
+			int64 b = 2;
+		}
+
+		message SumReply {
+			int64 v = 1;
+		}
+
+		service SumSvc {
+			rpc Sum(SumRequest) returns (SumReply) {
+				option (google.api.http) = {
+					get: "/sum"
+				};
+			}
+			rpc Plain(SumRequest) returns (SumReply) {}
+		}
+
+		service AdminSvc {
+			rpc Reset(SumRequest) returns (SumReply) {
+				option (google.api.http) = {
+					post: "/admin/reset"
+					body: "*"
+				};
+			}
+		}
+	`
+	sd, err := NewFromString(defstr, gopath)
+	if err != nil {
+		t.Fatal("Failed to create svcdef from string:", err)
+	}
+
+	if got, want := len(sd.Services), 2; got != want {
+		t.Fatalf("Service count = %d, want %d", got, want)
+	}
+
+	var cases = []struct {
+		svc, method, verb, path string
+	}{
+		{"AdminSvc", "Reset", "post", "/admin/reset"},
+		{"SumSvc", "Sum", "get", "/sum"},
+	}
+	for i, c := range cases {
+		svc := sd.Services[i]
+		if svc.Name != c.svc {
+			t.Fatalf("Service %d name = %q, want %q", i, svc.Name, c.svc)
+		}
+		m := svc.Methods[0]
+		if m.Name != c.method {
+			t.Fatalf("Method name = %q, want %q", m.Name, c.method)
+		}
+		if len(m.Bindings) != 1 {
+			t.Fatalf("Method %q has %d bindings, want 1", m.Name, len(m.Bindings))
+		}
+		if got, want := m.Bindings[0].Verb, c.verb; got != want {
+			t.Errorf("Method %q verb = %q, want %q", m.Name, got, want)
+		}
+		if got, want := m.Bindings[0].Path, c.path; got != want {
+			t.Errorf("Method %q path = %q, want %q", m.Name, got, want)
+		}
+	}
+	if got := len(sd.Services[1].Methods[1].Bindings); got != 0 {
+		t.Errorf("Method Plain has %d bindings, want 0", got)
+	}
+}
@@ -0,0 +1,190 @@
+package svcdef
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// PathTemplate is the path template of an HTTP binding, which follows the
+// grammar of google.api.HttpRule:
+//
+//	Template = "/" Segments [ Verb ] ;
+//	Segments = Segment { "/" Segment } ;
+//	Segment  = "*" | "**" | LITERAL | Variable ;
+//	Variable = "{" FieldPath [ "=" Segments ] "}" ;
+//	FieldPath = IDENT { "." IDENT } ;
+//	Verb     = ":" LITERAL ;
+//
+// A "*" matches a single segment, while a "**" matches zero or more segments
+// and may only be the last segment of the template. A variable matches the
+// segments it is bound to, "*" unless any are given, and sets the field at
+// its field path to what they match.
+type PathTemplate struct {
+	Segments []*PathSegment
+	// Verb is the custom verb ending the template, without its colon, e.g.
+	// "cancel" for "/v1/{name}:cancel"; empty if there is none
+	Verb string
+}
+
+// PathSegment is a segment of a PathTemplate, which is either a literal, a
+// wildcard, or a variable.
+type PathSegment struct {
+	// Literal is the text a literal segment matches
+	Literal string
+	// Wildcard is either "*" or "**" for a wildcard
+	Wildcard string
+	// Variable is the field path of a variable, e.g. "book.name", and
+	// Segments the literals and wildcards it is bound to
+	Variable string
+	Segments []*PathSegment
+}
+
+// Variables returns the variables of t, in the order they appear in.
+func (t *PathTemplate) Variables() []*PathSegment {
+	var rv []*PathSegment
+	for _, s := range t.Segments {
+		if s.Variable != "" {
+			rv = append(rv, s)
+		}
+	}
+	return rv
+}
+
+// MultiSegment returns whether s is a variable which may match more than one
+// segment, such as {name=shelves/*} or {name=**}. Slashes are escaped in the
+// value of a variable of a single segment only.
+func (s *PathSegment) MultiSegment() bool {
+	if s.Variable == "" {
+		return false
+	}
+	return len(s.Segments) > 1 || s.Segments[0].Wildcard == "**"
+}
+
+// fieldPath matches the FieldPath of a variable
+var fieldPath = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+// ParsePathTemplate parses path, the path template of an HTTP binding. A
+// trailing slash, as in "/echo/", is accepted as an empty last segment.
+func ParsePathTemplate(path string) (*PathTemplate, error) {
+	if !strings.HasPrefix(path, "/") {
+		return nil, errors.Errorf("path template %q does not begin with /", path)
+	}
+	rv := &PathTemplate{}
+	segments := path[1:]
+	// The verb follows the last segment, which may be a variable bound to
+	// several segments
+	last := strings.LastIndexAny(segments, "/}") + 1
+	if i := strings.Index(segments[last:], ":"); i >= 0 {
+		rv.Verb = segments[last+i+1:]
+		segments = segments[:last+i]
+		if rv.Verb == "" || strings.ContainsAny(rv.Verb, "{*") {
+			return nil, errors.Errorf("path template %q has an invalid verb %q", path, rv.Verb)
+		}
+	}
+
+	p := pathParser{rest: segments}
+	var err error
+	if rv.Segments, err = p.segments(false); err != nil {
+		return nil, errors.Wrapf(err, "cannot parse path template %q", path)
+	}
+	if p.rest != "" {
+		return nil, errors.Errorf("cannot parse path template %q: unexpected %q", path, p.rest)
+	}
+
+	var all []*PathSegment
+	vars := make(map[string]bool)
+	for i, s := range rv.Segments {
+		if s.Literal == "" && s.Wildcard == "" && s.Variable == "" && i != len(rv.Segments)-1 {
+			return nil, errors.Errorf("path template %q has an empty segment", path)
+		}
+		if s.Variable == "" {
+			all = append(all, s)
+			continue
+		}
+		if vars[s.Variable] {
+			return nil, errors.Errorf("path template %q binds %s more than once", path, s.Variable)
+		}
+		vars[s.Variable] = true
+		all = append(all, s.Segments...)
+	}
+	for i, s := range all {
+		if s.Wildcard == "**" && i != len(all)-1 {
+			return nil, errors.Errorf("path template %q has ** before its last segment", path)
+		}
+	}
+	return rv, nil
+}
+
+// pathParser parses the segments of a path template, consuming rest.
+type pathParser struct {
+	rest string
+}
+
+// segments parses segments separated by slashes; the segments a variable is
+// bound to when inVariable.
+func (p *pathParser) segments(inVariable bool) ([]*PathSegment, error) {
+	var rv []*PathSegment
+	for {
+		s, err := p.segment(inVariable)
+		if err != nil {
+			return nil, err
+		}
+		rv = append(rv, s)
+		if !strings.HasPrefix(p.rest, "/") {
+			return rv, nil
+		}
+		p.rest = p.rest[1:]
+	}
+}
+
+func (p *pathParser) segment(inVariable bool) (*PathSegment, error) {
+	end := strings.IndexAny(p.rest, "/}")
+	if end < 0 {
+		end = len(p.rest)
+	}
+	switch text := p.rest[:end]; {
+	case strings.HasPrefix(text, "{"):
+		if inVariable {
+			return nil, errors.New("variables cannot be nested")
+		}
+		return p.variable()
+	case text == "*", text == "**":
+		p.rest = p.rest[end:]
+		return &PathSegment{Wildcard: text}, nil
+	case strings.ContainsAny(text, "{*"):
+		return nil, errors.Errorf("invalid segment %q", text)
+	case text == "" && inVariable:
+		return nil, errors.New("variable bound to an empty segment")
+	default:
+		p.rest = p.rest[end:]
+		return &PathSegment{Literal: text}, nil
+	}
+}
+
+func (p *pathParser) variable() (*PathSegment, error) {
+	end := strings.IndexAny(p.rest, "=}")
+	if end < 0 {
+		return nil, errors.Errorf("unterminated variable %q", p.rest)
+	}
+	rv := &PathSegment{Variable: p.rest[1:end]}
+	if !fieldPath.MatchString(rv.Variable) {
+		return nil, errors.Errorf("invalid field path %q", rv.Variable)
+	}
+	p.rest = p.rest[end:]
+	if p.rest[0] == '=' {
+		p.rest = p.rest[1:]
+		var err error
+		if rv.Segments, err = p.segments(true); err != nil {
+			return nil, errors.Wrapf(err, "in variable %s", rv.Variable)
+		}
+		if !strings.HasPrefix(p.rest, "}") {
+			return nil, errors.Errorf("unterminated variable %s", rv.Variable)
+		}
+	} else {
+		rv.Segments = []*PathSegment{{Wildcard: "*"}}
+	}
+	p.rest = p.rest[1:]
+	return rv, nil
+}
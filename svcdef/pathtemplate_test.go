@@ -0,0 +1,131 @@
+package svcdef
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+func TestParsePathTemplate(t *testing.T) {
+	star := &PathSegment{Wildcard: "*"}
+	starStar := &PathSegment{Wildcard: "**"}
+	lit := func(s string) *PathSegment { return &PathSegment{Literal: s} }
+	variable := func(name string, segs ...*PathSegment) *PathSegment {
+		if len(segs) == 0 {
+			segs = []*PathSegment{star}
+		}
+		return &PathSegment{Variable: name, Segments: segs}
+	}
+
+	// The examples of google.api.HttpRule, along with each part of its
+	// grammar
+	tests := []struct {
+		path string
+		want *PathTemplate
+	}{
+		{"/v1/{name=messages/*}", &PathTemplate{
+			Segments: []*PathSegment{lit("v1"), variable("name", lit("messages"), star)},
+		}},
+		{"/v1/messages/{message_id}", &PathTemplate{
+			Segments: []*PathSegment{lit("v1"), lit("messages"), variable("message_id")},
+		}},
+		{"/v1/users/{user_id}/messages/{message_id}", &PathTemplate{
+			Segments: []*PathSegment{lit("v1"), lit("users"), variable("user_id"), lit("messages"), variable("message_id")},
+		}},
+		{"/v1/{name=shelves/*/books/*}", &PathTemplate{
+			Segments: []*PathSegment{lit("v1"), variable("name", lit("shelves"), star, lit("books"), star)},
+		}},
+		{"/v1/{book.name=shelves/*/books/*}", &PathTemplate{
+			Segments: []*PathSegment{lit("v1"), variable("book.name", lit("shelves"), star, lit("books"), star)},
+		}},
+		{"/v1/{name=operations/**}", &PathTemplate{
+			Segments: []*PathSegment{lit("v1"), variable("name", lit("operations"), starStar)},
+		}},
+		{"/v1/{name=**}:cancel", &PathTemplate{
+			Segments: []*PathSegment{lit("v1"), variable("name", starStar)},
+			Verb:     "cancel",
+		}},
+		{"/v1/{name}:cancel", &PathTemplate{
+			Segments: []*PathSegment{lit("v1"), variable("name")},
+			Verb:     "cancel",
+		}},
+		{"/v1/{name=shelves/*}:undelete", &PathTemplate{
+			Segments: []*PathSegment{lit("v1"), variable("name", lit("shelves"), star)},
+			Verb:     "undelete",
+		}},
+		{"/v1/operations:list", &PathTemplate{
+			Segments: []*PathSegment{lit("v1"), lit("operations")},
+			Verb:     "list",
+		}},
+		{"/v1/*/books/{book}", &PathTemplate{
+			Segments: []*PathSegment{lit("v1"), star, lit("books"), variable("book")},
+		}},
+		{"/v1/**", &PathTemplate{
+			Segments: []*PathSegment{lit("v1"), starStar},
+		}},
+		{"/v1/a:b/c", &PathTemplate{
+			Segments: []*PathSegment{lit("v1"), lit("a:b"), lit("c")},
+		}},
+		{"/echo/", &PathTemplate{
+			Segments: []*PathSegment{lit("echo"), lit("")},
+		}},
+		{"/", &PathTemplate{
+			Segments: []*PathSegment{lit("")},
+		}},
+	}
+	for _, tt := range tests {
+		got, err := ParsePathTemplate(tt.path)
+		if err != nil {
+			t.Errorf("ParsePathTemplate(%q): %v", tt.path, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParsePathTemplate(%q) = %s, want %s", tt.path, spew.Sdump(got), spew.Sdump(tt.want))
+		}
+	}
+
+	for _, path := range []string{
+		"",
+		"v1/{name}",
+		"/v1//{name}",
+		"/v1/{name",
+		"/v1/{name=shelves/*",
+		"/v1/{name=}",
+		"/v1/{na-me}",
+		"/v1/{name=shelves/{id}}",
+		"/v1/{name}/{name}",
+		"/v1/**/books",
+		"/v1/{name=**}/books",
+		"/v1/{name=**}/{id}",
+		"/v1/a*b",
+		"/v1/{name}:",
+		"/v1/{name}x",
+	} {
+		if _, err := ParsePathTemplate(path); err == nil {
+			t.Errorf("ParsePathTemplate(%q) succeeded, want an error", path)
+		}
+	}
+}
+
+func TestMultiSegment(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/v1/{name}", false},
+		{"/v1/{name=*}", false},
+		{"/v1/{name=shelves}", false},
+		{"/v1/{name=shelves/*}", true},
+		{"/v1/{name=**}", true},
+	}
+	for _, tt := range tests {
+		tmpl, err := ParsePathTemplate(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := tmpl.Variables()[0].MultiSegment(); got != tt.want {
+			t.Errorf("MultiSegment() of the variable of %q = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
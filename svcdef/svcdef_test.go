@@ -1,23 +1,17 @@
 package svcdef
 
 import (
-	"io"
-	"os"
-	"strings"
+	"io/ioutil"
 	"testing"
 )
 
 func TestSvcdef(t *testing.T) {
-	gf, err := os.Open("./test-go.txt")
-	if err != nil {
-		t.Fatal(err)
-	}
-	pf, err := os.Open("./test-proto.txt")
+	def, err := ioutil.ReadFile("./test-proto.txt")
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	sd, err := New(map[string]io.Reader{"./test-go.txt": gf}, map[string]io.Reader{"./test-proto.txt": pf})
+	sd, err := NewFromString(string(def), gopath)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -28,29 +22,41 @@ func TestSvcdef(t *testing.T) {
 
 func TestTypeResolution(t *testing.T) {
 	caseCode := `
-package TEST
-type EnumType int32
+		syntax = "proto3";
 
-type NestedMessageA struct {
-	A *NestedMessageC
-}
-type NestedMessageB struct {
-	A []*NestedMessageC
-}
-type NestedMessageC struct {
-	A int64
-}
+		package TEST;
+
+		enum EnumType {
+			A = 0;
+		}
+
+		message NestedMessageA {
+			NestedMessageC A = 1;
+		}
+		message NestedMessageB {
+			repeated NestedMessageC A = 1;
+		}
+		message NestedMessageC {
+			int64 A = 1;
+		}
 
-type NestedTypeRequest struct {
-	A *NestedMessageA
-	B []*NestedMessageB
-	C EnumType
-}`
-	sd, err := New(map[string]io.Reader{"/tmp/notreal": strings.NewReader(caseCode)}, nil)
+		message NestedTypeRequest {
+			NestedMessageA A = 1;
+			repeated NestedMessageB B = 2;
+			EnumType C = 3;
+		}`
+	sd, err := NewFromString(caseCode, gopath)
 	if err != nil {
 		t.Fatal(err)
 	}
-	tmap := newTypeMap(sd)
+	messages := make(map[string]*Message)
+	for _, m := range sd.Messages {
+		messages[m.Name] = m
+	}
+	enums := make(map[string]*Enum)
+	for _, e := range sd.Enums {
+		enums[e.Name] = e
+	}
 
 	var cases = []struct {
 		name, fieldname, typename string
@@ -62,54 +68,49 @@ type NestedTypeRequest struct {
 		{"NestedTypeRequest", "C", "EnumType"},
 	}
 	for _, c := range cases {
-		box, ok := tmap[c.name]
+		msg, ok := messages[c.name]
 		if !ok {
-			t.Errorf("Could not find %q in map of types", c.name)
-		}
-		msg := box.Message
-		if msg.Name != c.name {
-			t.Errorf("Message in typemap is named %q, wanted %q", msg.Name, c.name)
+			t.Fatalf("Could not find message %q", c.name)
 		}
 		var selectedf *Field
-		foundfield := false
 		for _, f := range msg.Fields {
 			if f.Name == c.fieldname {
-				foundfield = true
 				selectedf = f
 			}
 		}
-		if !foundfield {
+		if selectedf == nil {
 			t.Fatalf("Could't find field %q in message %q", c.fieldname, msg.Name)
 		}
 
-		ftypebox, ok := tmap[selectedf.Type.Name]
-		if !ok {
-			t.Errorf("Field %q has type %q which is not found in the typemap", selectedf.Name, selectedf.Type.Name)
+		if selectedf.Type.Name != c.typename {
+			t.Errorf("Field %q on message %q has type %q, want %q", selectedf.Name, msg.Name, selectedf.Type.Name, c.typename)
 		}
-		if selectedf.Type.Enum != nil {
-			ftype := ftypebox.Enum
-			if selectedf.Type.Enum != ftype {
-				t.Errorf("Field %q on message %q has type which differs from the typemap type of the same name, got %p, want %p", selectedf.Name, msg.Name, selectedf.Type, ftype)
-			}
+		if m, ok := messages[c.typename]; ok && selectedf.Type.Message != m {
+			t.Errorf("Field %q on message %q has a message which differs from the message of the same name, got %p, want %p", selectedf.Name, msg.Name, selectedf.Type.Message, m)
+		}
+		if e, ok := enums[c.typename]; ok && selectedf.Type.Enum != e {
+			t.Errorf("Field %q on message %q has an enum which differs from the enum of the same name, got %p, want %p", selectedf.Name, msg.Name, selectedf.Type.Enum, e)
 		}
 	}
 }
 
-// Test that after calling New(), type resolution of map values functions
-// correctly. So if a message has a map field, and that map field has values
-// that are some other message type, then the type of the key will be correct.
+// Test that type resolution of map values functions correctly. So if a
+// message has a map field, and that map field has values that are some other
+// message type, then the type of the key will be correct.
 func TestNewMapTypeResolution(t *testing.T) {
 	caseCode := `
-package TEST
+		syntax = "proto3";
 
-type NestedMessageC struct {
-	A int64
-}
-type MsgWithMap struct {
-	Beta map[int64]*NestedMessageC
-}
-`
-	sd, err := New(map[string]io.Reader{"/tmp/notreal": strings.NewReader(caseCode)}, nil)
+		package TEST;
+
+		message NestedMessageC {
+			int64 A = 1;
+		}
+		message MsgWithMap {
+			map<int64, NestedMessageC> Beta = 1;
+		}
+	`
+	sd, err := NewFromString(caseCode, gopath)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -135,7 +136,73 @@ type MsgWithMap struct {
 	beta := msg.Fields[0].Type.Map
 
 	if beta.ValueType.Message != expected {
-		t.Fatalf("Expected beta ValueType to be 'NestedMessageC', is %q", beta.ValueType.Message.Name)
+		t.Fatalf("Expected beta ValueType to be 'NestedMessageC', is %+v", beta.ValueType.Message)
+	}
+	if beta.KeyType.Name != "int64" {
+		t.Fatalf("Expected beta KeyType to be 'int64', is %q", beta.KeyType.Name)
+	}
+}
+
+func TestStreamingMethods(t *testing.T) {
+	caseCode := `
+		syntax = "proto3";
+
+		package TEST;
+
+		message StreamRequest {
+			int64 A = 1;
+		}
+		message StreamResponse {
+			int64 B = 1;
+		}
+
+		service Streamer {
+			rpc Unary(StreamRequest) returns (StreamResponse) {}
+			rpc ServerStream(StreamRequest) returns (stream StreamResponse) {}
+			rpc ClientStream(stream StreamRequest) returns (StreamResponse) {}
+			rpc Bidi(stream StreamRequest) returns (stream StreamResponse) {}
+		}
+	`
+	sd, err := NewFromString(caseCode, gopath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(sd.Services), 1; got != want {
+		t.Fatalf("Service count = %d, want %d", got, want)
+	}
+	svc := sd.Services[0]
+	if got, want := svc.Name, "Streamer"; got != want {
+		t.Fatalf("Service name = %q, want %q", got, want)
 	}
 
+	var cases = []struct {
+		name                   string
+		clientStream, svStream bool
+	}{
+		{"Unary", false, false},
+		{"ServerStream", false, true},
+		{"ClientStream", true, false},
+		{"Bidi", true, true},
+	}
+	if got, want := len(svc.Methods), len(cases); got != want {
+		t.Fatalf("Method count = %d, want %d", got, want)
+	}
+	for i, c := range cases {
+		m := svc.Methods[i]
+		if m.Name != c.name {
+			t.Errorf("Method %d name = %q, want %q", i, m.Name, c.name)
+		}
+		if m.ClientStreaming != c.clientStream {
+			t.Errorf("Method %q ClientStreaming = %v, want %v", m.Name, m.ClientStreaming, c.clientStream)
+		}
+		if m.ServerStreaming != c.svStream {
+			t.Errorf("Method %q ServerStreaming = %v, want %v", m.Name, m.ServerStreaming, c.svStream)
+		}
+		if m.RequestType == nil || m.RequestType.Message == nil || m.RequestType.Name != "StreamRequest" {
+			t.Errorf("Method %q RequestType = %+v, want resolved StreamRequest", m.Name, m.RequestType)
+		}
+		if m.ResponseType == nil || m.ResponseType.Message == nil || m.ResponseType.Name != "StreamResponse" {
+			t.Errorf("Method %q ResponseType = %+v, want resolved StreamResponse", m.Name, m.ResponseType)
+		}
+	}
 }
@@ -84,7 +84,11 @@ type Method struct {
 	Description  string
 	RequestType  string
 	ResponseType string
-	HTTPBindings []*HTTPBinding
+	// ClientStreaming and ServerStreaming record if the "stream" keyword
+	// preceded the request or response type, respectively.
+	ClientStreaming bool
+	ServerStreaming bool
+	HTTPBindings    []*HTTPBinding
 }
 
 // HTTPBinding holds information extracted by the parser about each HTTP
@@ -221,6 +225,9 @@ func ParseMethod(lex *SvcLexer) (*Method, error) {
 		if tk == COMMENT {
 			desc = val
 			tk, val = lex.GetTokenIgnoreWhitespace()
+		} else if tk == SYMBOL && val == ";" {
+			// Empty statements may follow the body of a method
+			tk, val = lex.GetTokenIgnoreWhitespace()
 		} else {
 			break
 		}
@@ -264,9 +271,9 @@ func ParseMethod(lex *SvcLexer) (*Method, error) {
 	}
 
 	tk, val = lex.GetTokenIgnoreWhitespace()
-	// Here we ignore the "stream" keyword which may appear in the arguments of
-	// an RPC definition
+	// The "stream" keyword may appear in the arguments of an RPC definition
 	if val == "stream" {
+		toret.ClientStreaming = true
 		tk, val = lex.GetTokenIgnoreWhitespace()
 	}
 	if tk != IDENT {
@@ -315,9 +322,10 @@ func ParseMethod(lex *SvcLexer) (*Method, error) {
 	}
 
 	tk, val = lex.GetTokenIgnoreWhitespace()
-	// Here we ignore the "stream" keyword which may appear in the arguments of
-	// an RPC definition
+	// The "stream" keyword may appear in the return value of an RPC
+	// definition
 	if val == "stream" {
+		toret.ServerStreaming = true
 		tk, val = lex.GetTokenIgnoreWhitespace()
 	}
 	if tk != IDENT {
@@ -350,7 +358,7 @@ func ParseMethod(lex *SvcLexer) (*Method, error) {
 	tk, val = lex.GetTokenIgnoreWhitespace()
 	if val == ";" {
 		// No http options defined
-		return nil, nil
+		return toret, nil
 	} else if tk != OPEN_BRACE {
 		return nil, parserErr{
 			expected: "'{' after declaration of method signature",
@@ -365,7 +373,7 @@ func ParseMethod(lex *SvcLexer) (*Method, error) {
 	}
 	// End of RPC (no httpoptions)
 	if bindings == nil {
-		return nil, nil
+		return toret, nil
 	}
 	toret.HTTPBindings = bindings
 
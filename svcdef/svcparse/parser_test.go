@@ -467,6 +467,12 @@ service FlowCombination {
 	if got, want := methone.ResponseType, "EmptyProto"; got != want {
 		t.Errorf("Response type = %#v, want = %#v\n", got, want)
 	}
+	if got, want := methone.ClientStreaming, false; got != want {
+		t.Errorf("Client streaming = %#v, want = %#v\n", got, want)
+	}
+	if got, want := methone.ServerStreaming, true; got != want {
+		t.Errorf("Server streaming = %#v, want = %#v\n", got, want)
+	}
 	methtwo := svc.Methods[1]
 	if got, want := methtwo.Name, "StreamEmptyRpc"; got != want {
 		t.Errorf("Method name = %#v, want = %#v\n", got, want)
@@ -477,6 +483,12 @@ service FlowCombination {
 	if got, want := methtwo.ResponseType, "EmptyProto"; got != want {
 		t.Errorf("Response type = %#v, want = %#v\n", got, want)
 	}
+	if got, want := methtwo.ClientStreaming, true; got != want {
+		t.Errorf("Client streaming = %#v, want = %#v\n", got, want)
+	}
+	if got, want := methtwo.ServerStreaming, false; got != want {
+		t.Errorf("Server streaming = %#v, want = %#v\n", got, want)
+	}
 	meththree := svc.Methods[2]
 	if got, want := meththree.Name, "StreamEmptyStream"; got != want {
 		t.Errorf("Method name = %#v, want = %#v\n", got, want)
@@ -487,6 +499,12 @@ service FlowCombination {
 	if got, want := meththree.ResponseType, "EmptyProto"; got != want {
 		t.Errorf("Response type = %#v, want = %#v\n", got, want)
 	}
+	if got, want := meththree.ClientStreaming, true; got != want {
+		t.Errorf("Client streaming = %#v, want = %#v\n", got, want)
+	}
+	if got, want := meththree.ServerStreaming, true; got != want {
+		t.Errorf("Server streaming = %#v, want = %#v\n", got, want)
+	}
 
 	bindingsone := []*HTTPBinding{
 		&HTTPBinding{
@@ -727,3 +745,51 @@ func TestCustomHTTPPatternOutputExample(t *testing.T) {
 		t.Errorf("Custom HTTP verb declaration got = %#v, want = %#v\n", got, want)
 	}
 }
+
+func TestMultipleServicesWithoutOptions(t *testing.T) {
+	r := strings.NewReader(`
+service One {
+	rpc Plain(EmptyProto) returns (EmptyProto) {};
+	rpc Semicolon(EmptyProto) returns (EmptyProto);
+	rpc WithOptions(EmptyProto) returns (EmptyProto) {
+		option (google.api.http) = {
+			get: "/one"
+		};
+	}
+}
+
+message EmptyProto {}
+
+service Two {
+	rpc Other(EmptyProto) returns (EmptyProto) {}
+}
+`)
+	lex := NewSvcLexer(r)
+
+	one, err := ParseService(lex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(one.Methods), 3; got != want {
+		t.Fatalf("Method count = %#v, want = %#v\n", got, want)
+	}
+	for i, name := range []string{"Plain", "Semicolon", "WithOptions"} {
+		if got, want := one.Methods[i].Name, name; got != want {
+			t.Errorf("Method name = %#v, want = %#v\n", got, want)
+		}
+	}
+	if got, want := len(one.Methods[2].HTTPBindings), 1; got != want {
+		t.Errorf("Http binding count = %#v, want = %#v\n", got, want)
+	}
+
+	two, err := ParseService(lex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := two.Name, "Two"; got != want {
+		t.Errorf("name = %#v, want = %#v\n", got, want)
+	}
+	if got, want := len(two.Methods), 1; got != want {
+		t.Fatalf("Method count = %#v, want = %#v\n", got, want)
+	}
+}
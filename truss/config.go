@@ -1,11 +1,18 @@
 package truss
 
-import "io"
+import (
+	"io"
+
+	"github.com/metaverse/truss/truss/gomod"
+)
 
 // Config defines the inputs to a truss service generation
 type Config struct {
-	// The first path in $GOPATH
-	GoPath []string
+	// The modules of the definition files, which Go packages are resolved in
+	Modules *gomod.Resolver
+	// The paths imported .proto files are searched for in, see
+	// gomod.Resolver.ProtoImportPaths
+	ImportPaths []string
 
 	// The go package where .pb.go files protoc-gen-go creates will be written
 	PBPackage string
@@ -16,6 +23,16 @@ type Config struct {
 
 	// The paths to each of the .proto files truss is being run against
 	DefPaths []string
+	// The path to the serialized FileDescriptorSet truss is being run
+	// against instead of .proto files, and the names of the files of that
+	// set to generate; if none are named they are chosen by truss
+	DescriptorSetPath  string
+	DescriptorSetFiles []string
+	// The directory of templates overriding or adding to the built-in
+	// templates, may be empty
+	TemplateDir string
 	// The files of a previously generated service, may be nil
 	PrevGen map[string]io.Reader
+	// The options passed to the templates, see gengokit.Config
+	Options map[string]string
 }
@@ -0,0 +1,138 @@
+// Package configfile reads truss.yaml, the file declaring how truss is run
+// against the definition files of a project, so that the flags need not be
+// repeated on each invocation.
+package configfile
+
+import (
+	"go/build"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// Name is the name of the file truss looks for.
+const Name = "truss.yaml"
+
+// The generators a File may enable, besides any other named by the
+// generators package.
+const (
+	// GenService generates a go-kit service tree for each service
+	GenService = "service"
+	// GenCombined also generates one binary serving every service, as the
+	// --combined flag does
+	GenCombined = "combined"
+)
+
+// File holds the settings of a truss.yaml file. Each corresponds to a flag of
+// truss, which overrides it when set.
+type File struct {
+	// Inputs are the .proto files to generate from, or with DescriptorSet,
+	// the names of the files within the set to generate
+	Inputs []string `yaml:"inputs"`
+	// DescriptorSet is a serialized FileDescriptorSet to generate from
+	// instead of .proto files, as with --descriptor_set_in
+	DescriptorSet string `yaml:"descriptor_set_in"`
+	// ProtoPaths are the directories imports are searched for in, as with
+	// -I/--proto_path
+	ProtoPaths []string `yaml:"proto_paths"`
+	// SvcOut is the Go package or directory the services are generated in,
+	// as with --svcout
+	SvcOut string `yaml:"svcout"`
+	// PBOut is the Go package or directory the .pb.go files of the
+	// definition are generated in, as with --pbout
+	PBOut string `yaml:"pbout"`
+	// Generators are the generators to run for each service, as with
+	// --generators; GenService if empty
+	Generators []string `yaml:"generators"`
+	// Options are passed to the templates of the generated service, within
+	// which they are available as {{.Options.name}}
+	Options map[string]string `yaml:"options"`
+	// Templates is a directory of templates overriding or adding to the
+	// built-in templates, as with --templates
+	Templates string `yaml:"templates"`
+}
+
+// Find returns the path of the truss.yaml file within dir, or false if there
+// is none.
+func Find(dir string) (string, bool) {
+	p := filepath.Join(dir, Name)
+	if fi, err := os.Stat(p); err == nil && !fi.IsDir() {
+		return p, true
+	}
+	return "", false
+}
+
+// Read reads the truss.yaml file at path. Relative paths within the file are
+// relative to the directory containing it, and are made absolute; Go package
+// paths are left as they are.
+func Read(path string) (*File, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read %s", path)
+	}
+	var f File
+	if err := yaml.UnmarshalStrict(b, &f); err != nil {
+		return nil, errors.Wrapf(err, "cannot parse %s", path)
+	}
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	// File names within a descriptor set are not paths on disk
+	if f.DescriptorSet == "" {
+		for i, in := range f.Inputs {
+			f.Inputs[i] = abs(dir, in)
+		}
+	} else {
+		f.DescriptorSet = abs(dir, f.DescriptorSet)
+	}
+	for i, ip := range f.ProtoPaths {
+		// As with protoc, PREFIX=DIR maps imports beginning with PREFIX
+		if eq := strings.Index(ip, "="); eq >= 0 {
+			f.ProtoPaths[i] = ip[:eq+1] + abs(dir, ip[eq+1:])
+		} else {
+			f.ProtoPaths[i] = abs(dir, ip)
+		}
+	}
+	f.Templates = abs(dir, f.Templates)
+	f.SvcOut = absPackage(dir, f.SvcOut)
+	f.PBOut = absPackage(dir, f.PBOut)
+
+	return &f, nil
+}
+
+// Combined returns true if the file enables GenCombined.
+func (f *File) Combined() bool {
+	for _, g := range f.Generators {
+		if g == GenCombined {
+			return true
+		}
+	}
+	return false
+}
+
+// abs returns p joined to dir if p is relative.
+func abs(dir, p string) string {
+	if p == "" || filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(dir, filepath.FromSlash(p))
+}
+
+// absPackage returns pkg joined to dir if pkg is a relative path, i.e. it
+// begins with ./ or ../, rather than a Go package path. As with --svcout, a
+// trailing slash is kept.
+func absPackage(dir, pkg string) string {
+	if !build.IsLocalImport(pkg) {
+		return pkg
+	}
+	p := abs(dir, pkg)
+	if strings.HasSuffix(pkg, "/") {
+		p += string(filepath.Separator)
+	}
+	return p
+}
@@ -0,0 +1,103 @@
+package configfile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeFile(t *testing.T, contents string) (dir, path string) {
+	dir, err := ioutil.TempDir("", "truss-configfile-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path = filepath.Join(dir, Name)
+	if err := ioutil.WriteFile(path, []byte(contents), 0666); err != nil {
+		t.Fatal(err)
+	}
+	return dir, path
+}
+
+func TestRead(t *testing.T) {
+	dir, path := writeFile(t, `
+inputs:
+  - svc/svc.proto
+  - types/types.proto
+proto_paths:
+  - .
+  - example.com/vendored=../vendored
+svcout: ./svcs/
+pbout: example.com/echo/pb
+generators: [service, combined]
+options:
+  team: core
+templates: templates
+`)
+	defer os.RemoveAll(dir)
+
+	if got, ok := Find(dir); !ok || got != path {
+		t.Fatalf("Find = %q, %v, want %q", got, ok, path)
+	}
+
+	f, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &File{
+		Inputs: []string{
+			filepath.Join(dir, "svc", "svc.proto"),
+			filepath.Join(dir, "types", "types.proto"),
+		},
+		ProtoPaths: []string{
+			dir,
+			"example.com/vendored=" + filepath.Join(filepath.Dir(dir), "vendored"),
+		},
+		SvcOut:     filepath.Join(dir, "svcs") + string(filepath.Separator),
+		PBOut:      "example.com/echo/pb",
+		Generators: []string{GenService, GenCombined},
+		Options:    map[string]string{"team": "core"},
+		Templates:  filepath.Join(dir, "templates"),
+	}
+	if !reflect.DeepEqual(f, want) {
+		t.Errorf("Read =\n%#v\nwant\n%#v", f, want)
+	}
+	if !f.Combined() {
+		t.Error("Combined = false, want true")
+	}
+}
+
+func TestReadDescriptorSet(t *testing.T) {
+	dir, path := writeFile(t, `
+descriptor_set_in: build/definition.pb
+inputs: [echo/echo.proto]
+`)
+	defer os.RemoveAll(dir)
+
+	f, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "build", "definition.pb"); f.DescriptorSet != want {
+		t.Errorf("DescriptorSet = %q, want %q", f.DescriptorSet, want)
+	}
+	// Inputs name files within the set, so are not paths
+	if want := []string{"echo/echo.proto"}; !reflect.DeepEqual(f.Inputs, want) {
+		t.Errorf("Inputs = %v, want %v", f.Inputs, want)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	for _, contents := range []string{
+		"svc_out: ./svcs",
+		"generators: {docs: true}",
+		"inputs: svc.proto: x",
+	} {
+		dir, path := writeFile(t, contents)
+		if _, err := Read(path); err == nil {
+			t.Errorf("expected an error reading %q", contents)
+		}
+		os.RemoveAll(dir)
+	}
+}
@@ -0,0 +1,46 @@
+// Package descutil reads what the gogo descriptors, which predate some
+// features of protoc, leave among the unrecognized fields of a descriptor.
+package descutil
+
+import (
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+)
+
+// proto3OptionalNumber is the number of the proto3_optional field of a
+// FieldDescriptorProto, which the gogo descriptors predate, so that it is
+// among the unrecognized fields of a descriptor.
+const proto3OptionalNumber = 17
+
+// Proto3Optional returns whether f is a field of a proto3 message marked
+// optional, which protoc places in a synthetic oneof of its own.
+func Proto3Optional(f *descriptor.FieldDescriptorProto) bool {
+	b := proto.NewBuffer(f.XXX_unrecognized)
+	for {
+		key, err := b.DecodeVarint()
+		if err != nil {
+			return false
+		}
+		switch key & 7 {
+		case proto.WireVarint:
+			v, err := b.DecodeVarint()
+			if err != nil {
+				return false
+			}
+			if key>>3 == proto3OptionalNumber {
+				return v != 0
+			}
+		case proto.WireFixed64:
+			_, err = b.DecodeFixed64()
+		case proto.WireBytes:
+			_, err = b.DecodeRawBytes(false)
+		case proto.WireFixed32:
+			_, err = b.DecodeFixed32()
+		default:
+			return false
+		}
+		if err != nil {
+			return false
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package descutil
+
+import (
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+)
+
+func TestProto3Optional(t *testing.T) {
+	// field encodes a varint field of a FieldDescriptorProto
+	field := func(number, value uint64) []byte {
+		return append(proto.EncodeVarint(number<<3|proto.WireVarint), proto.EncodeVarint(value)...)
+	}
+	// json_name = "x", which the gogo descriptors know, precedes it
+	jsonName := append(proto.EncodeVarint(10<<3|proto.WireBytes), append(proto.EncodeVarint(1), 'x')...)
+
+	tests := []struct {
+		name         string
+		unrecognized []byte
+		want         bool
+	}{
+		{"none", nil, false},
+		{"optional", field(proto3OptionalNumber, 1), true},
+		{"not optional", field(proto3OptionalNumber, 0), false},
+		{"after other fields", append(append(jsonName, field(16, 3)...), field(proto3OptionalNumber, 1)...), true},
+		{"other fields only", field(16, 1), false},
+		{"truncated", proto.EncodeVarint(proto3OptionalNumber<<3 | proto.WireVarint), false},
+	}
+	for _, tt := range tests {
+		f := &descriptor.FieldDescriptorProto{XXX_unrecognized: tt.unrecognized}
+		if got := Proto3Optional(f); got != tt.want {
+			t.Errorf("%s: Proto3Optional() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
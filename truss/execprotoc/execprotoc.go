@@ -1,112 +1,84 @@
 // Package execprotoc provides an interface for interacting with proto
-// requiring only paths to files on disk
+// requiring only paths to files on disk. It is only used to generate .pb.go
+// files when truss is asked to run protoc; see package parseproto for parsing
+// .proto files, and package pbgo for generating .pb.go files, without protoc.
 package execprotoc
 
 import (
+	"bytes"
+	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 
-	"github.com/gogo/protobuf/proto"
-	plugin "github.com/gogo/protobuf/protoc-gen-gogo/plugin"
 	"github.com/pkg/errors"
 )
 
-// GeneratePBDotGo creates .pb.go files from the passed protoPaths and writes
-// them to outDir.
-func GeneratePBDotGo(protoPaths, gopath []string, outDir string) error {
-
-	genGoCode := "--gogofaster_out=" +
-		"Mgoogle/protobuf/any.proto=github.com/gogo/protobuf/types," +
-		"Mgoogle/protobuf/duration.proto=github.com/gogo/protobuf/types," +
-		"Mgoogle/protobuf/struct.proto=github.com/gogo/protobuf/types," +
-		"Mgoogle/protobuf/timestamp.proto=github.com/gogo/protobuf/types," +
-		"Mgoogle/protobuf/wrappers.proto=github.com/gogo/protobuf/types," +
-		"paths=source_relative,plugins=grpc:" + outDir
-
+// GeneratePBDotGo returns the .pb.go file of each of the passed protoPaths,
+// keyed by the slash separated path of the file relative to root. protoPaths must be within the directory
+// root. The files are named relative to root, which is searched for imports
+// before importPaths, each of which is passed to protoc as a --proto_path.
+// parameter is the parameter of protoc-gen-gogofaster, see pbgo.Parameter.
+func GeneratePBDotGo(protoPaths, importPaths []string, root, parameter string) (map[string]io.Reader, error) {
 	_, err := exec.LookPath("protoc-gen-gogo")
 	if err != nil {
-		return errors.Wrap(err, "cannot find protoc-gen-gogo in PATH")
+		return nil, errors.Wrap(err, "cannot find protoc-gen-gogo in PATH")
 	}
 
-	err = protoc(protoPaths, gopath, genGoCode)
+	// protoc writes each file at its path relative to root, so the files
+	// are generated in a temporary directory and read from there
+	tmpDir, err := ioutil.TempDir("", "truss-pbgo-")
 	if err != nil {
-		return errors.Wrap(err, "cannot exec protoc with protoc-gen-gogo")
+		return nil, errors.Wrap(err, "cannot create temporary directory for .pb.go files")
 	}
+	defer os.RemoveAll(tmpDir)
 
-	return nil
-}
+	genGoCode := "--gogofaster_out=" + parameter + ":" + tmpDir
 
-// CodeGeneratorRequest returns a protoc CodeGeneratorRequest from running
-// protoc on protoPaths
-// TODO: replace getProtocOutput with some other way of getting the protoc ast.
-// i.e. the binary data that will allow proto.Unmarshal to Unmashal the
-// .proto file into a *plugin.CodeGeneratorRequest
-func CodeGeneratorRequest(protoPaths, gopath []string) (*plugin.CodeGeneratorRequest, error) {
-	protocOut, err := getProtocOutput(protoPaths, gopath)
+	err = protoc(protoPaths, append([]string{root}, importPaths...), genGoCode)
 	if err != nil {
-		return nil, errors.Wrap(err, "cannot get output from protoc")
+		return nil, errors.Wrap(err, "cannot exec protoc with protoc-gen-gogo")
 	}
 
-	req := new(plugin.CodeGeneratorRequest)
-	if err = proto.Unmarshal(protocOut, req); err != nil {
-		return nil, errors.Wrap(err, "cannot marshal protoc ouput to code generator request")
-	}
-
-	return req, nil
+	return readGenerated(tmpDir)
 }
 
-// TODO: getProtocOutput is broken because golang protoc plugins no longer can
-// have UTF-8 in the output. This caused protoc-gen-truss-protocast to fail to
-// output its the protoc AST.
-func getProtocOutput(protoPaths, gopath []string) ([]byte, error) {
-	_, err := exec.LookPath("protoc-gen-truss-protocast")
-	if err != nil {
-		return nil, errors.Wrap(err, "protoc-gen-truss-protocast does not exist in $PATH")
-	}
-
-	protocOutDir, err := ioutil.TempDir("", "truss-")
-	if err != nil {
-		return nil, errors.Wrap(err, "cannot create temp directory")
-	}
-	defer os.RemoveAll(protocOutDir)
-
-	pluginCall := "--truss-protocast_out=" + protocOutDir
-
-	err = protoc(protoPaths, gopath, pluginCall)
-	if err != nil {
-		return nil, errors.Wrap(err, "protoc failed")
-	}
-
-	fileInfo, err := ioutil.ReadDir(protocOutDir)
-	if err != nil {
-		return nil, errors.Wrapf(err, "cannot read directory: %v", protocOutDir)
-	}
-
-	for _, f := range fileInfo {
-		if f.IsDir() {
-			continue
+// readGenerated returns each .pb.go file within the tree of dir, keyed by its
+// slash separated path relative to dir.
+func readGenerated(dir string) (map[string]io.Reader, error) {
+	files := make(map[string]io.Reader)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
 		}
-		fPath := filepath.Join(protocOutDir, f.Name())
-		protocOut, err := ioutil.ReadFile(fPath)
+		if info.IsDir() || !strings.HasSuffix(path, ".pb.go") {
+			return nil
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return errors.Wrapf(err, "cannot read generated file %v", path)
+		}
+		name, err := filepath.Rel(dir, path)
 		if err != nil {
-			return nil, errors.Wrapf(err, "cannot read file: %v", fPath)
+			return err
 		}
-		return protocOut, nil
+		files[filepath.ToSlash(name)] = bytes.NewReader(b)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-
-	return nil, errors.Errorf("no protoc output file found in: %v", protocOutDir)
+	return files, nil
 }
 
 // protoc executes protoc on protoPaths
-func protoc(protoPaths, gopath []string, plugin string) error {
+func protoc(protoPaths, importPaths []string, plugin string) error {
 	var cmdArgs []string
 
-	cmdArgs = append(cmdArgs, "--proto_path="+filepath.Dir(protoPaths[0]))
-
-	for _, gp := range gopath {
-		cmdArgs = append(cmdArgs, "-I"+filepath.Join(gp, "src"))
+	for _, ip := range importPaths {
+		cmdArgs = append(cmdArgs, "--proto_path="+ip)
 	}
 
 	cmdArgs = append(cmdArgs, plugin)
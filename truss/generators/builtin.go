@@ -0,0 +1,99 @@
+package generators
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/metaverse/truss/deftree"
+	"github.com/metaverse/truss/gendoc"
+	ggk "github.com/metaverse/truss/gengokit"
+	gengokit "github.com/metaverse/truss/gengokit/generator"
+	"github.com/metaverse/truss/genopenapi"
+)
+
+// The names of the built-in generators.
+const (
+	// Service generates the go-kit service
+	Service = "service"
+	// Docs generates docs/docs.md and docs/docs.html, markdown and HTML
+	// documentation of the definition, along with svc/docs.go, which serves
+	// the HTML at /debug/docs of the service's debug listener
+	Docs = "docs"
+	// OpenAPI generates docs/openapi.json, the OpenAPI 3 document of the
+	// service's HTTP API
+	OpenAPI = "openapi"
+	// Swagger generates docs/swagger.json, the Swagger 2 document of the
+	// service's HTTP API
+	Swagger = "swagger"
+)
+
+func init() {
+	Register(Service, Func(func(in *Input) (map[string]io.Reader, error) {
+		files, err := gengokit.GenerateGokit(in.Svcdef, in.Config)
+		return files, errors.Wrap(err, "cannot generate gokit service")
+	}))
+	Register(Docs, Func(generateDocs))
+	Register(OpenAPI, Func(func(in *Input) (map[string]io.Reader, error) {
+		doc, err := genopenapi.OpenAPI3(in.Svcdef, in.Config.Service, genopenapi.Info{})
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot generate OpenAPI document")
+		}
+		return map[string]io.Reader{"docs/openapi.json": bytes.NewReader(doc)}, nil
+	}))
+	Register(Swagger, Func(func(in *Input) (map[string]io.Reader, error) {
+		doc, err := genopenapi.Swagger2(in.Svcdef, in.Config.Service, genopenapi.Info{})
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot generate Swagger document")
+		}
+		return map[string]io.Reader{"docs/swagger.json": bytes.NewReader(doc)}, nil
+	}))
+}
+
+// generateDocs documents the service of in with gendoc, along with the
+// messages and enums it refers to.
+func generateDocs(in *Input) (map[string]io.Reader, error) {
+	dt, err := deftree.NewForService(in.Request, in.Svcdef, in.Config.Service)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create deftree")
+	}
+	files, err := gendoc.GenerateDocs(dt)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot generate documentation")
+	}
+
+	html, err := ioutil.ReadAll(files["docs/docs.html"])
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read docs.html")
+	}
+	files["docs/docs.html"] = bytes.NewReader(html)
+	files["svc/docs.go"] = strings.NewReader(fmt.Sprintf(docsHandler, ggk.GoString(string(html))))
+
+	return files, nil
+}
+
+// docsHandler is svc/docs.go, formatted with the literal of the HTML
+// documentation.
+const docsHandler = `// Code generated by truss. DO NOT EDIT.
+// Rerunning truss will overwrite this file.
+
+package svc
+
+import (
+	"net/http"
+)
+
+func init() {
+	DebugHandlers["/debug/docs"] = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(docsHTML))
+	})
+}
+
+// docsHTML documents the service, see docs/docs.html
+const docsHTML = %s
+`
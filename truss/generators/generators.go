@@ -0,0 +1,160 @@
+// Package generators holds the registry of the generators truss runs for each
+// service of a definition, such as the go-kit service itself or its
+// documentation. A generator is either a Go package which Registers it, or an
+// executable implementing the protoc plugin protocol.
+package generators
+
+import (
+	"bytes"
+	"io"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gogo/protobuf/proto"
+	plugin "github.com/gogo/protobuf/protoc-gen-gogo/plugin"
+	"github.com/pkg/errors"
+
+	"github.com/metaverse/truss/gengokit"
+	"github.com/metaverse/truss/svcdef"
+)
+
+// Input is what a generator generates the files of a service from.
+type Input struct {
+	// Svcdef is the parsed definition
+	Svcdef *svcdef.Svcdef
+	// Request holds the descriptors of the definition, including the
+	// comments of each file declared within it
+	Request *plugin.CodeGeneratorRequest
+	// Config is that of the service being generated, naming it along with
+	// its Go packages and options
+	Config gengokit.Config
+}
+
+// Generator generates files of the service of an Input.
+type Generator interface {
+	// Generate returns the files generated from in, keyed by their slash
+	// separated paths relative to the root of the service's tree
+	Generate(in *Input) (map[string]io.Reader, error)
+}
+
+// Func is a function implementing Generator.
+type Func func(in *Input) (map[string]io.Reader, error)
+
+// Generate calls f(in).
+func (f Func) Generate(in *Input) (map[string]io.Reader, error) {
+	return f(in)
+}
+
+var registry = make(map[string]Generator)
+
+// Register makes g available by name. It panics if a generator is already
+// registered by name, or if name contains a colon.
+func Register(name string, g Generator) {
+	if strings.Contains(name, ":") {
+		panic("generators: invalid name " + name)
+	}
+	if _, ok := registry[name]; ok {
+		panic("generators: Register called twice for " + name)
+	}
+	registry[name] = g
+}
+
+// Names returns the sorted names of the registered generators.
+func Names() []string {
+	var names []string
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns the generator named by spec, which is NAME or
+// NAME:PARAMETER. NAME is that of a registered generator or, failing that, of
+// an executable protoc-gen-NAME within PATH, which is run as a protoc plugin
+// with PARAMETER as the parameter of its request. Registered generators take
+// no parameter.
+func Lookup(spec string) (Generator, error) {
+	name, param := spec, ""
+	if i := strings.Index(spec, ":"); i >= 0 {
+		name, param = spec[:i], spec[i+1:]
+	}
+	if g, ok := registry[name]; ok {
+		if param != "" {
+			return nil, errors.Errorf("generator %q takes no parameter", name)
+		}
+		return g, nil
+	}
+
+	path, err := exec.LookPath("protoc-gen-" + name)
+	if err != nil {
+		return nil, errors.Errorf("unknown generator %q; expected one of %s, or an executable protoc-gen-%s in PATH",
+			name, strings.Join(Names(), ", "), name)
+	}
+	return &Plugin{Path: path, Parameter: param}, nil
+}
+
+// Plugin is a Generator running an executable protoc plugin with the request
+// of the definition.
+type Plugin struct {
+	// Path is the path of the executable
+	Path string
+	// Parameter is set as the parameter of the request
+	Parameter string
+}
+
+// Generate runs the plugin with the request of in, returning the files of its
+// response.
+func (p *Plugin) Generate(in *Input) (map[string]io.Reader, error) {
+	req := proto.Clone(in.Request).(*plugin.CodeGeneratorRequest)
+	req.Parameter = proto.String(p.Parameter)
+	reqBytes, err := proto.Marshal(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot marshal CodeGeneratorRequest")
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(p.Path)
+	cmd.Stdin = bytes.NewReader(reqBytes)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrapf(err, "cannot run %v: %s", p.Path, strings.TrimSpace(stderr.String()))
+	}
+
+	var resp plugin.CodeGeneratorResponse
+	if err := proto.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return nil, errors.Wrapf(err, "cannot unmarshal CodeGeneratorResponse of %v", p.Path)
+	}
+	if resp.Error != nil {
+		return nil, errors.Errorf("%v: %s", p.Path, resp.GetError())
+	}
+
+	files := make(map[string]io.Reader)
+	for _, f := range resp.File {
+		if f.GetInsertionPoint() != "" {
+			return nil, errors.Errorf("%v: insertion points are not supported, in %v", p.Path, f.GetName())
+		}
+		name, ok := treePath(f.GetName())
+		if !ok {
+			return nil, errors.Errorf("%v: file %q is not within the tree of the service", p.Path, f.GetName())
+		}
+		files[name] = strings.NewReader(f.GetContent())
+	}
+	return files, nil
+}
+
+// treePath returns name cleaned, and false if it is not a relative path
+// within the tree it is relative to.
+func treePath(name string) (string, bool) {
+	clean := filepath.Clean(filepath.FromSlash(name))
+	if name == "" || filepath.IsAbs(clean) || filepath.VolumeName(clean) != "" || path.IsAbs(name) {
+		return "", false
+	}
+	if clean == "." || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(clean), true
+}
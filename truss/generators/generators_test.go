@@ -0,0 +1,259 @@
+package generators
+
+import (
+	"encoding/json"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	plugin "github.com/gogo/protobuf/protoc-gen-gogo/plugin"
+
+	"github.com/metaverse/truss/gengokit"
+	"github.com/metaverse/truss/svcdef"
+	"github.com/metaverse/truss/truss/parseproto"
+)
+
+// With pluginEnv set, the test binary acts as a protoc plugin generating
+// plugin.txt, which holds the parameter and files to generate of its request.
+// A parameter of name=NAME names the file NAME instead.
+const pluginEnv = "TRUSS_GENERATORS_TEST_PLUGIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(pluginEnv) != "" {
+		runPlugin()
+		return
+	}
+	os.Exit(m.Run())
+}
+
+func runPlugin() {
+	in, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		panic(err)
+	}
+	var req plugin.CodeGeneratorRequest
+	if err := proto.Unmarshal(in, &req); err != nil {
+		panic(err)
+	}
+	content := req.GetParameter() + "\n" + strings.Join(req.FileToGenerate, "\n") + "\n"
+	name := "plugin.txt"
+	if strings.HasPrefix(req.GetParameter(), "name=") {
+		name = strings.TrimPrefix(req.GetParameter(), "name=")
+	}
+	out, err := proto.Marshal(&plugin.CodeGeneratorResponse{
+		File: []*plugin.CodeGeneratorResponse_File{{
+			Name:    proto.String(name),
+			Content: proto.String(content),
+		}},
+	})
+	if err != nil {
+		panic(err)
+	}
+	os.Stdout.Write(out)
+}
+
+const definition = `
+syntax = "proto3";
+
+package echo;
+
+import "github.com/metaverse/truss/deftree/googlethirdparty/annotations.proto";
+
+// Echo says things back
+service Echo {
+  // Say says one thing
+  rpc Say (SayRequest) returns (SayResponse) {
+    option (google.api.http) = {
+      get: "/say/{text}"
+    };
+  }
+}
+
+message SayRequest {
+  string text = 1;
+}
+
+message SayResponse {
+  string text = 1;
+}
+`
+
+func input(t *testing.T) *Input {
+	dir, err := ioutil.TempDir("", "truss-generators-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "echo.proto")
+	if err := ioutil.WriteFile(path, []byte(definition), 0666); err != nil {
+		t.Fatal(err)
+	}
+	gopath := filepath.SplitList(os.Getenv("GOPATH"))
+	req, err := parseproto.CodeGeneratorRequest([]string{path}, append([]string{dir}, parseproto.GoPathImports(gopath)...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sd, err := svcdef.NewFromRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Input{
+		Svcdef:  sd,
+		Request: req,
+		Config:  gengokit.Config{Service: "Echo"},
+	}
+}
+
+func TestLookup(t *testing.T) {
+	for _, name := range []string{Service, Docs, OpenAPI, Swagger} {
+		if _, err := Lookup(name); err != nil {
+			t.Errorf("Lookup(%q): %v", name, err)
+		}
+	}
+	if _, err := Lookup(Docs + ":x=1"); err == nil {
+		t.Error("expected an error passing a parameter to a registered generator")
+	}
+	if _, err := Lookup("truss-no-such-generator"); err == nil {
+		t.Error("expected an error looking up an unknown generator")
+	}
+}
+
+func TestPlugin(t *testing.T) {
+	bin, err := ioutil.TempDir("", "truss-generators-bin-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(bin)
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(exe, filepath.Join(bin, "protoc-gen-trusstest")); err != nil {
+		t.Skip("cannot link test binary:", err)
+	}
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", bin)
+	defer os.Unsetenv(pluginEnv)
+	os.Setenv(pluginEnv, "1")
+
+	g, err := Lookup("trusstest:a=1,b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := g.Generate(input(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files["plugin.txt"] == nil {
+		t.Fatalf("Generate = %v, want plugin.txt", files)
+	}
+	b, err := ioutil.ReadAll(files["plugin.txt"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(b), "a=1,b\necho.proto\n"; got != want {
+		t.Errorf("plugin.txt = %q, want %q", got, want)
+	}
+
+	for _, name := range []string{"../escaped.txt", "docs/../../escaped.txt", "/abs.txt"} {
+		g, err := Lookup("trusstest:name=" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if files, err := g.Generate(input(t)); err == nil {
+			t.Errorf("Generate of a file named %q = %v, want an error", name, files)
+		}
+	}
+	g, err = Lookup("trusstest:name=docs/./plugin.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files, err = g.Generate(input(t)); err != nil || files["docs/plugin.txt"] == nil {
+		t.Errorf("Generate of a file named docs/./plugin.txt = %v, %v; want docs/plugin.txt", files, err)
+	}
+}
+
+func TestDocs(t *testing.T) {
+	g, err := Lookup(Docs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := g.Generate(input(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents := make(map[string]string)
+	for _, path := range []string{"docs/docs.md", "docs/docs.html", "svc/docs.go"} {
+		f, ok := files[path]
+		if !ok {
+			t.Fatalf("Generate = %v, want %v", files, path)
+		}
+		b, err := ioutil.ReadAll(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents[path] = string(b)
+	}
+
+	for _, want := range []string{"Echo", "Say says one thing", "/say/{text}"} {
+		if !strings.Contains(contents["docs/docs.md"], want) {
+			t.Errorf("docs.md does not contain %q:\n%s", want, contents["docs/docs.md"])
+		}
+	}
+	for _, want := range []string{
+		`<a href="#method-Echo-Say">Say</a>`,
+		`<h3 id="method-Echo-Say">Say</h3>`,
+		`<p>Say says one thing</p>`,
+		`Say(<a href="#message-SayRequest">SayRequest</a>) returns (<a href="#message-SayResponse">SayResponse</a>)`,
+		`<h4>GET <code>/say/{text}</code></h4>`,
+		`<tr><td>text</td><td>path</td><td>string</td></tr>`,
+		`<h3 id="message-SayRequest">SayRequest</h3>`,
+	} {
+		if !strings.Contains(contents["docs/docs.html"], want) {
+			t.Errorf("docs.html does not contain %q:\n%s", want, contents["docs/docs.html"])
+		}
+	}
+
+	f, err := parser.ParseFile(token.NewFileSet(), "docs.go", contents["svc/docs.go"], 0)
+	if err != nil {
+		t.Fatalf("cannot parse svc/docs.go: %v\n%s", err, contents["svc/docs.go"])
+	}
+	if f.Name.Name != "svc" {
+		t.Errorf("svc/docs.go is of package %v, want svc", f.Name.Name)
+	}
+}
+
+func TestOpenAPI(t *testing.T) {
+	for name, path := range map[string]string{
+		OpenAPI: "docs/openapi.json",
+		Swagger: "docs/swagger.json",
+	} {
+		g, err := Lookup(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files, err := g.Generate(input(t))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		doc, ok := files[path]
+		if !ok {
+			t.Fatalf("%s: Generate = %v, want %v", name, files, path)
+		}
+		var v struct {
+			Info  map[string]string
+			Paths map[string]interface{}
+		}
+		if err := json.NewDecoder(doc).Decode(&v); err != nil {
+			t.Fatalf("%s: cannot decode %v: %v", name, path, err)
+		}
+		if v.Info["title"] != "Echo" || v.Info["description"] != "Echo says things back" || v.Paths["/say/{text}"] == nil {
+			t.Errorf("%s: %v does not describe Echo: %+v", name, path, v)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+// Package gomod resolves Go import paths to directories, and directories to
+// Go import paths, from go.mod and go.work files and the module cache. A
+// GOPATH layout is only relied upon when the go command is not in module mode.
+package gomod
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// TrussModule is the module providing the annotations imported by service
+// definitions, such as deftree/googlethirdparty/annotations.proto.
+const TrussModule = "github.com/metaverse/truss"
+
+// Module is a module of the build list, as listed by `go list -m`.
+type Module struct {
+	Path string
+	// Dir is the directory holding the files of the module; it is empty if
+	// the module has not been downloaded
+	Dir string
+	// Main is true for the main module, and for each module of a workspace
+	Main bool
+}
+
+// Resolver resolves paths within the modules of the main module or
+// workspace of a directory.
+type Resolver struct {
+	// Modules is the build list, beginning with the main modules
+	Modules []Module
+	// GoPath is set only outside of module mode
+	GoPath   []string
+	modCache string
+}
+
+// goEnv holds the variables of `go env -json` a Resolver depends on.
+type goEnv struct {
+	GOPATH     string
+	GOMODCACHE string
+	GOMOD      string
+	GOWORK     string
+}
+
+// NewResolver returns the Resolver of the modules which the go command uses
+// when run within dir.
+func NewResolver(dir string) (*Resolver, error) {
+	var env goEnv
+	out, err := goCmd(dir, "env", "-json", "GOPATH", "GOMODCACHE", "GOMOD", "GOWORK")
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(out, &env); err != nil {
+		return nil, errors.Wrap(err, "cannot decode go env output")
+	}
+
+	r := &Resolver{
+		modCache: env.GOMODCACHE,
+	}
+	if r.modCache == "" {
+		if gp := filepath.SplitList(env.GOPATH); len(gp) > 0 {
+			r.modCache = filepath.Join(gp[0], "pkg", "mod")
+		}
+	}
+
+	if (env.GOMOD == "" || env.GOMOD == os.DevNull) && (env.GOWORK == "" || env.GOWORK == "off") {
+		r.GoPath = filepath.SplitList(env.GOPATH)
+		return r, nil
+	}
+
+	// -e reports modules which cannot be loaded, such as those not yet
+	// downloaded, rather than failing
+	out, err = goCmd(dir, "list", "-m", "-e", "-mod=readonly", "-json", "all")
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for {
+		var m struct {
+			Module
+			Replace *Module
+		}
+		if err := dec.Decode(&m); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, errors.Wrap(err, "cannot decode go list output")
+		}
+		if m.Replace != nil && m.Dir == "" {
+			m.Dir = m.Replace.Dir
+		}
+		r.Modules = append(r.Modules, m.Module)
+	}
+
+	return r, nil
+}
+
+// goCmd runs the go command with args in dir and returns its output.
+func goCmd(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "go %s failed: %s", strings.Join(args, " "), stderr.String())
+	}
+	return out, nil
+}
+
+// ImportPath returns the Go import path of the package in dir, which need
+// not exist yet. The path is that of the module of the closest go.mod file
+// above dir, or outside of module mode, the path of dir within GOPATH.
+func (r *Resolver) ImportPath(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for d := dir; ; d = filepath.Dir(d) {
+		b, err := ioutil.ReadFile(filepath.Join(d, "go.mod"))
+		if err == nil {
+			modPath := modfile.ModulePath(b)
+			if modPath == "" {
+				return "", errors.Errorf("no module path in %s", filepath.Join(d, "go.mod"))
+			}
+			rel, err := filepath.Rel(d, dir)
+			if err != nil {
+				return "", err
+			}
+			return path.Join(modPath, filepath.ToSlash(rel)), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", errors.Wrap(err, "cannot read go.mod")
+		}
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+
+	for _, gp := range r.GoPath {
+		src := filepath.Join(gp, "src")
+		if rel, err := filepath.Rel(src, dir); err == nil && !strings.HasPrefix(rel, "..") && rel != "." {
+			return filepath.ToSlash(rel), nil
+		}
+	}
+
+	return "", errors.Errorf("%s is not within a Go module; run `go mod init` to create one", dir)
+}
+
+// Dir returns the directory of the package with importPath, which need not
+// exist yet. The package must be within a main module or, outside of module
+// mode, the first GOPATH entry.
+func (r *Resolver) Dir(importPath string) (string, error) {
+	var best *Module
+	for i, m := range r.Modules {
+		if !m.Main || m.Dir == "" || !within(importPath, m.Path) {
+			continue
+		}
+		if best == nil || len(m.Path) > len(best.Path) {
+			best = &r.Modules[i]
+		}
+	}
+	if best != nil {
+		rel := strings.TrimPrefix(strings.TrimPrefix(importPath, best.Path), "/")
+		return filepath.Join(best.Dir, filepath.FromSlash(rel)), nil
+	}
+
+	if len(r.GoPath) > 0 {
+		return filepath.Join(r.GoPath[0], "src", filepath.FromSlash(importPath)), nil
+	}
+
+	var mains []string
+	for _, m := range r.Modules {
+		if m.Main {
+			mains = append(mains, m.Path)
+		}
+	}
+	return "", errors.Errorf("package %s is not within the main module(s) %s", importPath, strings.Join(mains, ", "))
+}
+
+// ProtoImportPaths returns the paths .proto files are imported from, in the
+// form taken by the --proto_path flag of protoc. The root of each module is
+// mapped to the module path, so that a file is imported by the Go import path
+// of its directory. The truss module is found in the module cache even when
+// the build list does not include it. Any GOPATH src directories follow.
+func (r *Resolver) ProtoImportPaths() []string {
+	var paths []string
+	hasTruss := false
+	for _, m := range r.Modules {
+		if m.Dir == "" {
+			continue
+		}
+		paths = append(paths, m.Path+"="+m.Dir)
+		if m.Path == TrussModule {
+			hasTruss = true
+		}
+	}
+	if !hasTruss {
+		if dir := r.cachedModule(TrussModule); dir != "" {
+			paths = append(paths, TrussModule+"="+dir)
+		}
+	}
+
+	gopath := r.GoPath
+	if len(gopath) == 0 {
+		gopath = filepath.SplitList(os.Getenv("GOPATH"))
+	}
+	for _, gp := range gopath {
+		src := filepath.Join(gp, "src")
+		if fi, err := os.Stat(src); err == nil && fi.IsDir() {
+			paths = append(paths, src)
+		}
+	}
+
+	return paths
+}
+
+// cachedModule returns the directory of the highest version of modPath
+// within the module cache, or "" if there is none.
+func (r *Resolver) cachedModule(modPath string) string {
+	if r.modCache == "" {
+		return ""
+	}
+	escaped, err := module.EscapePath(modPath)
+	if err != nil {
+		return ""
+	}
+	prefix := filepath.Join(r.modCache, filepath.FromSlash(escaped)) + "@"
+	dirs, err := filepath.Glob(prefix + "*")
+	if err != nil || len(dirs) == 0 {
+		return ""
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		vi, _ := module.UnescapeVersion(strings.TrimPrefix(dirs[i], prefix))
+		vj, _ := module.UnescapeVersion(strings.TrimPrefix(dirs[j], prefix))
+		return semver.Compare(vi, vj) > 0
+	})
+	return dirs[0]
+}
+
+// within returns true if importPath is modPath or a package within it.
+func within(importPath, modPath string) bool {
+	return importPath == modPath || strings.HasPrefix(importPath, modPath+"/")
+}
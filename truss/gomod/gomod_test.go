@@ -0,0 +1,70 @@
+package gomod
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// tempModule creates a module with the module path modPath in a temporary
+// directory, returning that directory.
+func tempModule(t *testing.T, modPath string) string {
+	dir, err := ioutil.TempDir("", "truss-gomod-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The go command resolves symlinks, such as those of TMPDIR on macOS
+	dir, err = filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mod := "module " + modPath + "\n\ngo 1.13\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte(mod), 0666); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestResolver(t *testing.T) {
+	dir := tempModule(t, "example.com/echo")
+	defer os.RemoveAll(dir)
+
+	pbDir := filepath.Join(dir, "pb")
+	if err := os.MkdirAll(pbDir, 0777); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewResolver(pbDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Modules) == 0 || !r.Modules[0].Main || r.Modules[0].Path != "example.com/echo" {
+		t.Fatalf("Modules = %v, want the main module example.com/echo first", r.Modules)
+	}
+
+	got, err := r.ImportPath(filepath.Join(pbDir, "echo-service"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "example.com/echo/pb/echo-service"; got != want {
+		t.Errorf("ImportPath = %q, want %q", got, want)
+	}
+
+	got, err = r.Dir("example.com/echo/svcs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "svcs"); got != want {
+		t.Errorf("Dir = %q, want %q", got, want)
+	}
+
+	if _, err := r.Dir("example.com/echoes"); err == nil {
+		t.Error("expected an error resolving a package outside of the main module")
+	}
+
+	paths := r.ProtoImportPaths()
+	if want := "example.com/echo=" + dir; len(paths) == 0 || paths[0] != want {
+		t.Errorf("ProtoImportPaths = %v, want %q first", paths, want)
+	}
+}
@@ -0,0 +1,130 @@
+// Package manifest records the files truss generates within a service tree,
+// along with a hash of the content each was generated with, so that files
+// truss no longer generates can be told apart from files truss never owned.
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// FileName is the name of the manifest within the root of a service tree.
+const FileName = "truss-manifest.json"
+
+// Manifest lists the files generated within a service tree.
+type Manifest struct {
+	// PBPackage is the Go package of the definition the tree was generated
+	// from
+	PBPackage string `json:"pb_package"`
+	// Service is the name of the service of the tree; it is empty for the
+	// tree of a binary serving several services
+	Service string `json:"service,omitempty"`
+	// Inputs are the sorted names of the definition files the tree was
+	// generated from
+	Inputs []string `json:"inputs,omitempty"`
+	// Files maps the slash separated path of each generated file, relative
+	// to the root of the tree, to the Hash of its generated content
+	Files map[string]string `json:"files"`
+}
+
+// New returns an empty Manifest of the tree of the named service, generated
+// from the definition files named by inputs.
+func New(pbPackage, service string, inputs []string) *Manifest {
+	inputs = append([]string(nil), inputs...)
+	sort.Strings(inputs)
+	return &Manifest{
+		PBPackage: pbPackage,
+		Service:   service,
+		Inputs:    inputs,
+		Files:     make(map[string]string),
+	}
+}
+
+// SameInputs returns true if the trees of m and next were generated from the
+// same definition files of the same Go package. A manifest which records no
+// inputs is never the same.
+func (m *Manifest) SameInputs(next *Manifest) bool {
+	if m.PBPackage != next.PBPackage || len(m.Inputs) == 0 || len(m.Inputs) != len(next.Inputs) {
+		return false
+	}
+	for i := range m.Inputs {
+		if m.Inputs[i] != next.Inputs[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Hash returns the hash of content recorded in a Manifest.
+func Hash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// Add records the file at path, relative to the root of the tree, as
+// generated with content.
+func (m *Manifest) Add(path string, content []byte) {
+	m.Files[filepath.ToSlash(path)] = Hash(content)
+}
+
+// Orphans returns the sorted paths of the files of m which next does not
+// list, i.e. those which are no longer generated.
+func (m *Manifest) Orphans(next *Manifest) []string {
+	var rv []string
+	for path := range m.Files {
+		if _, ok := next.Files[path]; !ok {
+			rv = append(rv, path)
+		}
+	}
+	sort.Strings(rv)
+	return rv
+}
+
+// Modified returns true if the file of m at path within the tree rooted at
+// dir no longer has the content it was generated with. It is an error if the
+// file cannot be read.
+func (m *Manifest) Modified(dir, path string) (bool, error) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(path)))
+	if err != nil {
+		return false, err
+	}
+	return Hash(b) != m.Files[path], nil
+}
+
+// Marshal returns the encoding of m written to FileName.
+func (m *Manifest) Marshal() []byte {
+	// Maps are encoded sorted by key, so the encoding is stable
+	b, err := json.MarshalIndent(m, "", "\t")
+	if err != nil {
+		panic(err)
+	}
+	return append(b, '\n')
+}
+
+// Read returns the Manifest of the tree rooted at dir, or nil if there is
+// none.
+func Read(dir string) (*Manifest, error) {
+	path := filepath.Join(dir, FileName)
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read %v", path)
+	}
+	var m Manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, errors.Wrapf(err, "cannot parse %v", path)
+	}
+	if m.Files == nil {
+		m.Files = make(map[string]string)
+	}
+	return &m, nil
+}
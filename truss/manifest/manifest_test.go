@@ -0,0 +1,75 @@
+package manifest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "truss-manifest-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if m, err := Read(dir); err != nil || m != nil {
+		t.Fatalf("Read of a tree without a manifest = %v, %v; want nil", m, err)
+	}
+
+	prev := New("example.com/echo/pb", "Echo", []string{"echo.proto", "admin.proto"})
+	prev.Add("handlers/handlers.go", []byte("package handlers\n"))
+	prev.Add(filepath.Join("svc", "server", "cli", "cli.go"), []byte("package cli\n"))
+	prev.Add("svc/endpoints.go", []byte("package svc\n"))
+	if err := ioutil.WriteFile(filepath.Join(dir, FileName), prev.Marshal(), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Read(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, prev) {
+		t.Fatalf("Read = %#v, want %#v", got, prev)
+	}
+
+	next := New("example.com/echo/pb", "Echo", []string{"echo.proto", "admin.proto"})
+	next.Add("svc/endpoints.go", []byte("package svc\n\n// changed\n"))
+	if got, want := prev.Orphans(next), []string{"handlers/handlers.go", "svc/server/cli/cli.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Orphans = %v, want %v", got, want)
+	}
+
+	if !prev.SameInputs(next) {
+		t.Errorf("SameInputs(%v, %v) = false, want true", prev.Inputs, next.Inputs)
+	}
+	for _, other := range []*Manifest{
+		New("example.com/echo/pb", "Admin", []string{"admin.proto"}),
+		New("example.com/other/pb", "Echo", []string{"admin.proto", "echo.proto"}),
+	} {
+		if prev.SameInputs(other) {
+			t.Errorf("SameInputs(%v, %v of %s) = true, want false", prev.Inputs, other.Inputs, other.PBPackage)
+		}
+	}
+	if old := New("example.com/echo/pb", "Echo", nil); old.SameInputs(old) {
+		t.Error("SameInputs of a manifest without inputs = true, want false")
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, "handlers"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	handlers := filepath.Join(dir, "handlers", "handlers.go")
+	if err := ioutil.WriteFile(handlers, []byte("package handlers\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if modified, err := prev.Modified(dir, "handlers/handlers.go"); err != nil || modified {
+		t.Errorf("Modified of an unchanged file = %v, %v; want false", modified, err)
+	}
+	if err := ioutil.WriteFile(handlers, []byte("package handlers\n\n// mine\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if modified, err := prev.Modified(dir, "handlers/handlers.go"); err != nil || !modified {
+		t.Errorf("Modified of an edited file = %v, %v; want true", modified, err)
+	}
+}
@@ -0,0 +1,195 @@
+// Package output writes generated files to disk or, so that a regeneration
+// can be reviewed before any file is touched, reports the changes writing
+// them would make.
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// Mode is what a Writer does with the files it is given.
+type Mode int
+
+const (
+	// Write writes files to disk
+	Write Mode = iota
+	// DryRun lists the files which would be created, updated or deleted
+	DryRun
+	// Diff prints a unified diff of each file which would be created,
+	// updated or deleted
+	Diff
+	// Verify prints the path of each file which differs from what would be
+	// generated, i.e. which is stale
+	Verify
+)
+
+// Writer writes and removes generated files according to its Mode. Files
+// which are unchanged are neither written nor reported.
+type Writer struct {
+	Mode Mode
+	// Out is where changes are reported to
+	Out io.Writer
+	// Base is the directory paths are reported relative to; they are
+	// reported as given if empty
+	Base string
+	// Changed holds the path of each file which was, or in a mode other
+	// than Write would have been, created, updated or deleted
+	Changed []string
+}
+
+// WriteFile writes file at path, creating any directories it is within.
+func (w *Writer) WriteFile(path string, file io.Reader) error {
+	content, err := ioutil.ReadAll(file)
+	if err != nil {
+		return errors.Wrapf(err, "cannot read generated file %v", path)
+	}
+
+	prev, err := ioutil.ReadFile(path)
+	exists := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "cannot read %v", path)
+	}
+	if exists && bytes.Equal(prev, content) {
+		return nil
+	}
+	w.Changed = append(w.Changed, path)
+
+	switch w.Mode {
+	case Verify:
+		_, err = fmt.Fprintln(w.Out, w.rel(path))
+		return err
+	case DryRun:
+		action := "create"
+		if exists {
+			action = "update"
+		}
+		_, err = fmt.Fprintf(w.Out, "%s %s\n", action, w.rel(path))
+		return err
+	case Diff:
+		return w.diff(path, prev, content, exists, true)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path, content, 0666); err != nil {
+		return errors.Wrapf(err, "cannot write to %v", path)
+	}
+	return nil
+}
+
+// RemoveAll removes path and, if it is a directory, every file within it. It
+// is not an error if path does not exist.
+func (w *Writer) RemoveAll(path string) error {
+	if w.Mode == Write {
+		if _, err := os.Stat(path); err == nil {
+			w.Changed = append(w.Changed, path)
+		}
+		return os.RemoveAll(path)
+	}
+
+	return filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil || info.IsDir() {
+			return err
+		}
+		w.Changed = append(w.Changed, p)
+		switch w.Mode {
+		case Verify:
+			_, err = fmt.Fprintln(w.Out, w.rel(p))
+			return err
+		case DryRun:
+			_, err = fmt.Fprintf(w.Out, "delete %s\n", w.rel(p))
+			return err
+		}
+		prev, err := ioutil.ReadFile(p)
+		if err != nil {
+			return errors.Wrapf(err, "cannot read %v", p)
+		}
+		return w.diff(p, prev, nil, true, false)
+	})
+}
+
+// RemoveFile removes the file at path, along with each directory above it,
+// up to but excluding root, which is left empty.
+func (w *Writer) RemoveFile(root, path string) error {
+	if err := w.RemoveAll(path); err != nil || w.Mode != Write {
+		return err
+	}
+	for dir := filepath.Dir(path); dir != root && strings.HasPrefix(dir, root); dir = filepath.Dir(dir) {
+		// Only an empty directory can be removed
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+// diff prints the unified diff from the content of path, prev, to content.
+// Either side which does not exist is diffed as /dev/null.
+func (w *Writer) diff(path string, prev, content []byte, exists, created bool) error {
+	rel := filepath.ToSlash(w.rel(path))
+	from, to := "a/"+rel, "b/"+rel
+	if !exists {
+		from = os.DevNull
+	}
+	if !created {
+		to = os.DevNull
+	}
+
+	d := difflib.UnifiedDiff{
+		A:        lines(prev),
+		B:        lines(content),
+		FromFile: from,
+		ToFile:   to,
+		Context:  3,
+	}
+	text, err := difflib.GetUnifiedDiffString(d)
+	if err != nil {
+		return errors.Wrapf(err, "cannot diff %v", path)
+	}
+	_, err = io.WriteString(w.Out, text)
+	return err
+}
+
+// noNewline marks the last line of a unified diff lacking a newline.
+const noNewline = "\n\\ No newline at end of file\n"
+
+// lines splits b into lines for diffing; an empty file has none. A final line
+// without a newline ends with noNewline, so that it differs from the same
+// line with one, and is marked as such in the diff.
+func lines(b []byte) []string {
+	if len(b) == 0 {
+		return nil
+	}
+	rv := strings.SplitAfter(string(b), "\n")
+	if last := rv[len(rv)-1]; last == "" {
+		rv = rv[:len(rv)-1]
+	} else {
+		rv[len(rv)-1] = last + noNewline
+	}
+	return rv
+}
+
+// rel returns path relative to Base if it is within Base.
+func (w *Writer) rel(path string) string {
+	if w.Base == "" {
+		return path
+	}
+	rel, err := filepath.Rel(w.Base, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return rel
+}
@@ -0,0 +1,218 @@
+package output
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// tempTree creates a directory holding the files of tree, keyed by their
+// slash separated paths.
+func tempTree(t *testing.T, tree map[string]string) string {
+	dir, err := ioutil.TempDir("", "truss-output-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range tree {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// apply writes and removes files of the tree at dir with w.
+func apply(t *testing.T, w *Writer, dir string) {
+	for name, content := range map[string]string{
+		"same.go":        "package same\n",
+		"changed.go":     "package changed\n\nvar x = 2\n",
+		"new/created.go": "package created\n",
+	} {
+		if err := w.WriteFile(filepath.Join(dir, filepath.FromSlash(name)), strings.NewReader(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.RemoveAll(filepath.Join(dir, "stale")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.RemoveAll(filepath.Join(dir, "missing")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+var tree = map[string]string{
+	"same.go":        "package same\n",
+	"changed.go":     "package changed\n\nvar x = 1\n",
+	"stale/stale.go": "package stale\n",
+}
+
+func TestWrite(t *testing.T) {
+	dir := tempTree(t, tree)
+	defer os.RemoveAll(dir)
+
+	var out bytes.Buffer
+	apply(t, &Writer{Mode: Write, Out: &out, Base: dir}, dir)
+
+	if out.Len() != 0 {
+		t.Errorf("Write mode reported changes:\n%s", out.String())
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, "changed.go"))
+	if err != nil || !strings.Contains(string(b), "x = 2") {
+		t.Errorf("changed.go was not written: %q, %v", b, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "new", "created.go")); err != nil {
+		t.Errorf("new/created.go was not written: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "stale")); !os.IsNotExist(err) {
+		t.Errorf("stale was not removed: %v", err)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	dir := tempTree(t, tree)
+	defer os.RemoveAll(dir)
+
+	var out bytes.Buffer
+	apply(t, &Writer{Mode: DryRun, Out: &out, Base: dir}, dir)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	want := map[string]bool{
+		"update changed.go":                            true,
+		"create " + filepath.Join("new", "created.go"): true,
+		"delete " + filepath.Join("stale", "stale.go"): true,
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("DryRun reported:\n%s\nwant %d lines", out.String(), len(want))
+	}
+	for _, l := range lines {
+		if !want[l] {
+			t.Errorf("unexpected line %q", l)
+		}
+	}
+
+	// Nothing is touched
+	if got := tempTreeFile(t, dir, "changed.go"); got != tree["changed.go"] {
+		t.Errorf("changed.go was written: %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "new")); !os.IsNotExist(err) {
+		t.Errorf("new was created: %v", err)
+	}
+	if got := tempTreeFile(t, dir, "stale/stale.go"); got != tree["stale/stale.go"] {
+		t.Errorf("stale/stale.go was removed: %q", got)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	dir := tempTree(t, tree)
+	defer os.RemoveAll(dir)
+
+	var out bytes.Buffer
+	apply(t, &Writer{Mode: Diff, Out: &out, Base: dir}, dir)
+	diff := out.String()
+
+	for _, want := range []string{
+		"--- a/changed.go\n+++ b/changed.go\n",
+		"-var x = 1\n+var x = 2\n",
+		"--- " + os.DevNull + "\n+++ b/new/created.go\n",
+		"+package created\n",
+		"--- a/stale/stale.go\n+++ " + os.DevNull + "\n",
+		"-package stale\n",
+	} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("diff does not contain %q:\n%s", want, diff)
+		}
+	}
+	if strings.Contains(diff, "same.go") {
+		t.Errorf("diff contains an unchanged file:\n%s", diff)
+	}
+	if got := tempTreeFile(t, dir, "changed.go"); got != tree["changed.go"] {
+		t.Errorf("changed.go was written: %q", got)
+	}
+}
+
+func TestDiffNoNewline(t *testing.T) {
+	dir := tempTree(t, map[string]string{
+		"changed.go": "package changed\n\nvar x = 1",
+	})
+	defer os.RemoveAll(dir)
+
+	var out bytes.Buffer
+	w := &Writer{Mode: Diff, Out: &out, Base: dir}
+	if err := w.WriteFile(filepath.Join(dir, "changed.go"), strings.NewReader("package changed\n\nvar x = 1\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteFile(filepath.Join(dir, "created.go"), strings.NewReader("package created")); err != nil {
+		t.Fatal(err)
+	}
+	diff := out.String()
+
+	for _, want := range []string{
+		"-var x = 1\n\\ No newline at end of file\n+var x = 1\n",
+		"+package created\n\\ No newline at end of file\n",
+	} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("diff does not contain %q:\n%s", want, diff)
+		}
+	}
+	if strings.Contains(diff, "+\n") {
+		t.Errorf("diff adds an empty line at the end of a file:\n%s", diff)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	dir := tempTree(t, tree)
+	defer os.RemoveAll(dir)
+
+	var out bytes.Buffer
+	w := &Writer{Mode: Verify, Out: &out, Base: dir}
+	apply(t, w, dir)
+
+	want := []string{"changed.go", filepath.Join("new", "created.go"), filepath.Join("stale", "stale.go")}
+	got := strings.Split(strings.TrimSpace(out.String()), "\n")
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Verify reported %v, want %v", got, want)
+	}
+	if len(w.Changed) != len(want) {
+		t.Errorf("Changed = %v, want %d paths", w.Changed, len(want))
+	}
+	if got := tempTreeFile(t, dir, "changed.go"); got != tree["changed.go"] {
+		t.Errorf("changed.go was written: %q", got)
+	}
+}
+
+func TestRemoveFile(t *testing.T) {
+	dir := tempTree(t, map[string]string{
+		"svc/server/cli/cli.go": "package cli\n",
+		"svc/server/run.go":     "package server\n",
+	})
+	defer os.RemoveAll(dir)
+
+	w := &Writer{Mode: Write}
+	if err := w.RemoveFile(dir, filepath.Join(dir, "svc", "server", "cli", "cli.go")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "svc", "server", "cli")); !os.IsNotExist(err) {
+		t.Errorf("empty directory svc/server/cli was not removed: %v", err)
+	}
+	if got := tempTreeFile(t, dir, "svc/server/run.go"); got != "package server\n" {
+		t.Errorf("svc/server/run.go was changed: %q", got)
+	}
+}
+
+func tempTreeFile(t *testing.T, dir, name string) string {
+	b, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
@@ -0,0 +1,90 @@
+package parseproto
+
+import (
+	"io/ioutil"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+	plugin "github.com/gogo/protobuf/protoc-gen-gogo/plugin"
+	"github.com/pkg/errors"
+)
+
+// ReadDescriptorSet reads the serialized FileDescriptorSet at setPath, such as
+// the output of `buf build -o` or `protoc --descriptor_set_out
+// --include_imports`, and returns the CodeGeneratorRequest protoc would send
+// to a plugin generating the files named in files. The set must contain every
+// file imported by those files.
+//
+// If files is empty, the files of the set which no other file imports are
+// generated, along with every other file of their packages.
+func ReadDescriptorSet(setPath string, files []string) (*plugin.CodeGeneratorRequest, error) {
+	b, err := ioutil.ReadFile(setPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read descriptor set %q", setPath)
+	}
+	set := new(descriptor.FileDescriptorSet)
+	if err := proto.Unmarshal(b, set); err != nil {
+		return nil, errors.Wrapf(err, "cannot unmarshal descriptor set %q", setPath)
+	}
+
+	return DescriptorSetRequest(set, files)
+}
+
+// DescriptorSetRequest returns the CodeGeneratorRequest generating the files
+// of set named in files, as ReadDescriptorSet does.
+func DescriptorSetRequest(set *descriptor.FileDescriptorSet, files []string) (*plugin.CodeGeneratorRequest, error) {
+	byName := make(map[string]*descriptor.FileDescriptorProto)
+	for _, f := range set.File {
+		byName[f.GetName()] = f
+	}
+	for _, f := range set.File {
+		for _, dep := range f.Dependency {
+			if _, ok := byName[dep]; !ok {
+				return nil, errors.Errorf("file %q imports %q which is missing from the descriptor set; "+
+					"was it built including imports?", f.GetName(), dep)
+			}
+		}
+	}
+
+	if len(files) == 0 {
+		files = rootPackageFiles(set)
+	}
+	if len(files) == 0 {
+		return nil, errors.New("descriptor set contains no files")
+	}
+	for _, name := range files {
+		if _, ok := byName[name]; !ok {
+			return nil, errors.Errorf("file %q is not within the descriptor set", name)
+		}
+	}
+
+	return &plugin.CodeGeneratorRequest{
+		FileToGenerate: files,
+		ProtoFile:      set.File,
+	}, nil
+}
+
+// rootPackageFiles returns the names of the files of set which share a
+// package with a file no other file imports.
+func rootPackageFiles(set *descriptor.FileDescriptorSet) []string {
+	imported := make(map[string]bool)
+	for _, f := range set.File {
+		for _, dep := range f.Dependency {
+			imported[dep] = true
+		}
+	}
+	roots := make(map[string]bool)
+	for _, f := range set.File {
+		if !imported[f.GetName()] {
+			roots[f.GetPackage()] = true
+		}
+	}
+
+	var rv []string
+	for _, f := range set.File {
+		if roots[f.GetPackage()] {
+			rv = append(rv, f.GetName())
+		}
+	}
+	return rv
+}
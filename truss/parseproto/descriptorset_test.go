@@ -0,0 +1,61 @@
+package parseproto
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+)
+
+func file(name, pkg string, deps ...string) *descriptor.FileDescriptorProto {
+	return &descriptor.FileDescriptorProto{
+		Name:       proto.String(name),
+		Package:    proto.String(pkg),
+		Dependency: deps,
+	}
+}
+
+func TestDescriptorSetRequest(t *testing.T) {
+	set := &descriptor.FileDescriptorSet{
+		File: []*descriptor.FileDescriptorProto{
+			file("google/protobuf/timestamp.proto", "google.protobuf"),
+			file("types.proto", "svc"),
+			file("svc.proto", "svc", "types.proto", "google/protobuf/timestamp.proto"),
+		},
+	}
+
+	req, err := DescriptorSetRequest(set, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := req.FileToGenerate, []string{"types.proto", "svc.proto"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FileToGenerate = %v, want = %v", got, want)
+	}
+	if got, want := len(req.ProtoFile), 3; got != want {
+		t.Errorf("len(ProtoFile) = %d, want = %d", got, want)
+	}
+
+	req, err = DescriptorSetRequest(set, []string{"types.proto"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := req.FileToGenerate, []string{"types.proto"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FileToGenerate = %v, want = %v", got, want)
+	}
+
+	if _, err := DescriptorSetRequest(set, []string{"other.proto"}); err == nil {
+		t.Error("expected an error generating a file missing from the set")
+	}
+}
+
+func TestDescriptorSetRequestMissingImport(t *testing.T) {
+	set := &descriptor.FileDescriptorSet{
+		File: []*descriptor.FileDescriptorProto{
+			file("svc.proto", "svc", "google/protobuf/timestamp.proto"),
+		},
+	}
+	if _, err := DescriptorSetRequest(set, nil); err == nil {
+		t.Error("expected an error for a set built without its imports")
+	}
+}
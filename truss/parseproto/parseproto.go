@@ -0,0 +1,205 @@
+// Package parseproto parses and links .proto files in process, producing the
+// same descriptors protoc would hand to a plugin without requiring protoc or
+// any plugin binary to be installed.
+package parseproto
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+	plugin "github.com/gogo/protobuf/protoc-gen-gogo/plugin"
+	gproto "github.com/golang/protobuf/proto"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/pkg/errors"
+)
+
+// Files parses the .proto files at protoPaths and returns the descriptors of
+// those files and every file they import. Descriptors are ordered such that
+// each file follows all of its dependencies, as in the ProtoFile field of a
+// protoc CodeGeneratorRequest.
+//
+// As with protoc, imports are resolved relative to each of importPaths in
+// order, and each file of protoPaths is named relative to the first of
+// importPaths containing it, see Name. Files within none of importPaths are
+// named relative to their own directory, which is searched before
+// importPaths. As with the --proto_path flag of protoc, an import path of the
+// form PREFIX=DIR resolves the imports beginning with PREFIX relative to DIR.
+// Imports of the well-known google/protobuf/*.proto files are resolved even
+// if their sources cannot be found.
+func Files(protoPaths, importPaths []string) ([]*descriptor.FileDescriptorProto, error) {
+	if len(protoPaths) == 0 {
+		return nil, errors.New("no .proto files to parse")
+	}
+
+	names, importPaths := fileNames(protoPaths, importPaths)
+
+	parser := protoparse.Parser{
+		Accessor:              importAccessor(importPaths),
+		IncludeSourceCodeInfo: true,
+	}
+	fds, err := parser.ParseFiles(names...)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot parse .proto files")
+	}
+
+	var files []*descriptor.FileDescriptorProto
+	seen := make(map[string]bool)
+	var add func(fd *desc.FileDescriptor) error
+	add = func(fd *desc.FileDescriptor) error {
+		if seen[fd.GetName()] {
+			return nil
+		}
+		seen[fd.GetName()] = true
+		for _, dep := range fd.GetDependencies() {
+			if err := add(dep); err != nil {
+				return err
+			}
+		}
+		file, err := toGogo(fd)
+		if err != nil {
+			return errors.Wrapf(err, "cannot convert descriptor of %q", fd.GetName())
+		}
+		files = append(files, file)
+		return nil
+	}
+	for _, fd := range fds {
+		if err := add(fd); err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+// CodeGeneratorRequest returns the CodeGeneratorRequest protoc would send to
+// a plugin when run on protoPaths with importPaths.
+func CodeGeneratorRequest(protoPaths, importPaths []string) (*plugin.CodeGeneratorRequest, error) {
+	files, err := Files(protoPaths, importPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	names, _ := fileNames(protoPaths, importPaths)
+
+	return &plugin.CodeGeneratorRequest{
+		FileToGenerate: names,
+		ProtoFile:      files,
+	}, nil
+}
+
+// fileNames returns the names of the files at protoPaths, as Files names
+// them, and the import paths Files searches.
+func fileNames(protoPaths, importPaths []string) ([]string, []string) {
+	var names, dirs []string
+	seen := make(map[string]bool)
+	for _, p := range protoPaths {
+		name, _, ok := Name(p, importPaths)
+		if !ok {
+			dir := filepath.Dir(p)
+			if !seen[dir] {
+				seen[dir] = true
+				dirs = append(dirs, dir)
+			}
+			name = filepath.ToSlash(filepath.Base(p))
+		}
+		names = append(names, name)
+	}
+	return names, append(dirs, importPaths...)
+}
+
+// Name returns the name protoc gives to the .proto file at protoPath given
+// importPaths: its path relative to the first directory of importPaths
+// containing it, which is returned as root. Import paths of the form
+// PREFIX=DIR are skipped. ok is false if no directory contains protoPath.
+func Name(protoPath string, importPaths []string) (name, root string, ok bool) {
+	for _, ip := range importPaths {
+		if strings.Contains(ip, "=") {
+			continue
+		}
+		rel, err := filepath.Rel(ip, protoPath)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		return filepath.ToSlash(rel), ip, true
+	}
+	return "", "", false
+}
+
+// importAccessor returns a FileAccessor opening the files of importPaths,
+// which are searched in order.
+func importAccessor(importPaths []string) protoparse.FileAccessor {
+	return func(name string) (io.ReadCloser, error) {
+		var firstErr error
+		for _, p := range candidates(name, importPaths) {
+			f, err := os.Open(p)
+			if err == nil {
+				return f, nil
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+		if firstErr == nil {
+			firstErr = &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+		}
+		return nil, firstErr
+	}
+}
+
+// Locate returns the path of the file imported as name, searching
+// importPaths as Files does. ok is false if the file cannot be found.
+func Locate(name string, importPaths []string) (path string, ok bool) {
+	for _, p := range candidates(name, importPaths) {
+		if fi, err := os.Stat(p); err == nil && !fi.IsDir() {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// candidates returns the paths the file imported as name may be found at
+// within each of importPaths, in order.
+func candidates(name string, importPaths []string) []string {
+	var rv []string
+	for _, ip := range importPaths {
+		if i := strings.Index(ip, "="); i >= 0 {
+			prefix, dir := ip[:i], ip[i+1:]
+			if name != prefix && !strings.HasPrefix(name, prefix+"/") {
+				continue
+			}
+			rv = append(rv, filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(name, prefix))))
+			continue
+		}
+		rv = append(rv, filepath.Join(ip, filepath.FromSlash(name)))
+	}
+	return rv
+}
+
+// GoPathImports returns the directories .proto files are imported from
+// within each GOPATH entry of gopath.
+func GoPathImports(gopath []string) []string {
+	var paths []string
+	for _, gp := range gopath {
+		paths = append(paths, filepath.Join(gp, "src"))
+	}
+	return paths
+}
+
+// toGogo converts the descriptor produced by the parser into the gogo
+// descriptor type used throughout truss.
+func toGogo(fd *desc.FileDescriptor) (*descriptor.FileDescriptorProto, error) {
+	b, err := gproto.Marshal(fd.AsFileDescriptorProto())
+	if err != nil {
+		return nil, err
+	}
+	file := new(descriptor.FileDescriptorProto)
+	if err := proto.Unmarshal(b, file); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
@@ -0,0 +1,155 @@
+package parseproto
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var gopath []string
+
+func init() {
+	gopath = filepath.SplitList(os.Getenv("GOPATH"))
+}
+
+func TestCodeGeneratorRequest(t *testing.T) {
+	const def = `
+		syntax = "proto3";
+
+		package general;
+
+		import "google/protobuf/timestamp.proto";
+		import "github.com/metaverse/truss/deftree/googlethirdparty/annotations.proto";
+
+		// Request has a timestamp
+		message Request {
+			google.protobuf.Timestamp time = 1;
+		}
+
+		service Svc {
+			rpc Get (Request) returns (Request) {
+				option (google.api.http) = {
+					get: "/get"
+				};
+			}
+		}
+	`
+	protoDir, err := ioutil.TempDir("", "truss-parseproto-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(protoDir)
+
+	defPath := filepath.Join(protoDir, "definition.proto")
+	if err := ioutil.WriteFile(defPath, []byte(def), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := CodeGeneratorRequest([]string{defPath}, GoPathImports(gopath))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := req.FileToGenerate, []string{"definition.proto"}; len(got) != 1 || got[0] != want[0] {
+		t.Fatalf("FileToGenerate = %v, want = %v", got, want)
+	}
+
+	// Every file must follow the files it imports
+	index := make(map[string]int)
+	for i, f := range req.ProtoFile {
+		index[f.GetName()] = i
+	}
+	for _, f := range req.ProtoFile {
+		for _, dep := range f.Dependency {
+			di, ok := index[dep]
+			if !ok {
+				t.Fatalf("dependency %q of %q not in ProtoFile", dep, f.GetName())
+			}
+			if di > index[f.GetName()] {
+				t.Errorf("dependency %q follows %q", dep, f.GetName())
+			}
+		}
+	}
+
+	last := req.ProtoFile[len(req.ProtoFile)-1]
+	if got, want := last.GetName(), "definition.proto"; got != want {
+		t.Fatalf("last ProtoFile = %q, want = %q", got, want)
+	}
+	if got, want := last.GetPackage(), "general"; got != want {
+		t.Errorf("package = %q, want = %q", got, want)
+	}
+	if got, want := last.MessageType[0].Field[0].GetTypeName(), ".google.protobuf.Timestamp"; got != want {
+		t.Errorf("field type = %q, want = %q", got, want)
+	}
+	if last.SourceCodeInfo == nil {
+		t.Error("SourceCodeInfo is missing; comments cannot be associated")
+	}
+}
+
+func TestFilesError(t *testing.T) {
+	protoDir, err := ioutil.TempDir("", "truss-parseproto-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(protoDir)
+
+	defPath := filepath.Join(protoDir, "definition.proto")
+	if err := ioutil.WriteFile(defPath, []byte(`syntax = "proto3"; message {}`), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Files([]string{defPath}, nil); err == nil {
+		t.Error("expected an error parsing an invalid definition")
+	}
+}
+
+func TestFilesMappedImportPath(t *testing.T) {
+	const def = `
+		syntax = "proto3";
+
+		package general;
+
+		import "example.com/other/types/types.proto";
+
+		message Request {
+			other.Type t = 1;
+		}
+	`
+	const types = `
+		syntax = "proto3";
+
+		package other;
+
+		message Type {}
+	`
+	dir, err := ioutil.TempDir("", "truss-parseproto-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	otherDir := filepath.Join(dir, "other")
+	if err := os.MkdirAll(filepath.Join(otherDir, "types"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(otherDir, "types", "types.proto"), []byte(types), 0666); err != nil {
+		t.Fatal(err)
+	}
+	defPath := filepath.Join(dir, "definition.proto")
+	if err := ioutil.WriteFile(defPath, []byte(def), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := Files([]string{defPath}, []string{"example.com/other=" + otherDir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := files[0].GetName(), "example.com/other/types/types.proto"; got != want {
+		t.Errorf("imported file name = %q, want %q", got, want)
+	}
+
+	if _, err := Files([]string{defPath}, []string{"example.com/otherwise=" + otherDir}); err == nil {
+		t.Error("expected an error importing a file outside of the mapped prefix")
+	}
+}
@@ -7,67 +7,31 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
-	"path/filepath"
-	"strings"
 
 	"github.com/metaverse/truss/svcdef"
-	"github.com/metaverse/truss/truss/execprotoc"
+	"github.com/metaverse/truss/truss/parseproto"
 	"github.com/pkg/errors"
 )
 
 // FromPaths accepts the paths of protobuf definition files and returns the
-// name of the service in that protobuf definition file.
+// name of the service in that protobuf definition file. If the definition
+// contains several services, the first by name is returned.
 func FromPaths(gopath []string, protoDefPaths []string) (string, error) {
-	td, err := ioutil.TempDir("", "parsesvcname")
-	defer os.RemoveAll(td)
+	req, err := parseproto.CodeGeneratorRequest(protoDefPaths, parseproto.GoPathImports(gopath))
 	if err != nil {
-		return "", errors.Wrap(err, "failed to create temporary directory for .pb.go files")
-	}
-	err = execprotoc.GeneratePBDotGo(protoDefPaths, gopath, td)
-	if err != nil {
-		return "", errors.Wrap(err, "failed to generate .pb.go files from proto definition files")
-	}
-
-	// Get path names of .pb.go files
-	pbgoPaths := []string{}
-	for _, p := range protoDefPaths {
-		base := filepath.Base(p)
-		barename := strings.TrimSuffix(base, filepath.Ext(p))
-		pbgp := filepath.Join(td, barename+".pb.go")
-		pbgoPaths = append(pbgoPaths, pbgp)
-	}
-
-	// Open all .pb.go files and store in map to be passed to svcdef.New()
-	openFiles := func(paths []string) (map[string]io.Reader, error) {
-		rv := map[string]io.Reader{}
-		for _, p := range paths {
-			reader, err := os.Open(p)
-			if err != nil {
-				return nil, errors.Wrapf(err, "cannot open file %q", p)
-			}
-			rv[p] = reader
-		}
-		return rv, nil
-	}
-	pbgoFiles, err := openFiles(pbgoPaths)
-	if err != nil {
-		return "", errors.Wrap(err, "cannot open all .pb.go files")
-	}
-	pbFiles, err := openFiles(protoDefPaths)
-	if err != nil {
-		return "", errors.Wrap(err, "cannot open all .proto files")
+		return "", errors.Wrap(err, "failed to parse proto definition files")
 	}
 
-	sd, err := svcdef.New(pbgoFiles, pbFiles)
+	sd, err := svcdef.NewFromRequest(req)
 	if err != nil {
 		return "", errors.Wrapf(err, "failed to create service definition; did you pass ALL the protobuf files to truss?")
 	}
 
-	if sd.Service == nil {
+	if len(sd.Services) == 0 {
 		return "", errors.New("no service defined")
 	}
 
-	return sd.Service.Name, nil
+	return sd.Services[0].Name, nil
 }
 
 func FromReaders(gopath []string, protoDefReaders []io.Reader) (string, error) {
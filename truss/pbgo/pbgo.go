@@ -0,0 +1,139 @@
+// Package pbgo generates .pb.go files from proto descriptors in process,
+// exactly as protoc-gen-gogofaster does, so that neither protoc nor any
+// plugin binary has to be installed.
+package pbgo
+
+import (
+	"go/format"
+	"io"
+	"sort"
+	"strings"
+
+	// The plugins protoc-gen-gogofaster runs, registered with the generator
+	_ "github.com/gogo/protobuf/plugin/compare"
+	_ "github.com/gogo/protobuf/plugin/defaultcheck"
+	_ "github.com/gogo/protobuf/plugin/description"
+	_ "github.com/gogo/protobuf/plugin/embedcheck"
+	_ "github.com/gogo/protobuf/plugin/enumstringer"
+	_ "github.com/gogo/protobuf/plugin/equal"
+	_ "github.com/gogo/protobuf/plugin/face"
+	_ "github.com/gogo/protobuf/plugin/gostring"
+	_ "github.com/gogo/protobuf/plugin/marshalto"
+	_ "github.com/gogo/protobuf/plugin/oneofcheck"
+	_ "github.com/gogo/protobuf/plugin/populate"
+	_ "github.com/gogo/protobuf/plugin/size"
+	_ "github.com/gogo/protobuf/plugin/stringer"
+	_ "github.com/gogo/protobuf/plugin/union"
+	_ "github.com/gogo/protobuf/plugin/unmarshal"
+	_ "github.com/gogo/protobuf/protoc-gen-gogo/grpc"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+	"github.com/gogo/protobuf/protoc-gen-gogo/generator"
+	plugin "github.com/gogo/protobuf/protoc-gen-gogo/plugin"
+	"github.com/gogo/protobuf/vanity"
+	"github.com/pkg/errors"
+
+	"github.com/metaverse/truss/truss/descutil"
+)
+
+// Parameter is the parameter truss passes to protoc-gen-gogofaster. It maps
+// the well-known types onto the gogo types package and generates gRPC
+// services, naming each .pb.go file after its .proto file.
+const Parameter = "Mgoogle/protobuf/any.proto=github.com/gogo/protobuf/types," +
+	"Mgoogle/protobuf/duration.proto=github.com/gogo/protobuf/types," +
+	"Mgoogle/protobuf/field_mask.proto=github.com/gogo/protobuf/types," +
+	"Mgoogle/protobuf/struct.proto=github.com/gogo/protobuf/types," +
+	"Mgoogle/protobuf/timestamp.proto=github.com/gogo/protobuf/types," +
+	"Mgoogle/protobuf/wrappers.proto=github.com/gogo/protobuf/types," +
+	"paths=source_relative,plugins=grpc"
+
+// MappedParameter returns Parameter along with an M option for each .proto
+// file named in goPackages, setting the Go import path of the file to that it
+// maps to.
+func MappedParameter(goPackages map[string]string) string {
+	var names []string
+	for name := range goPackages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	param := Parameter
+	for _, name := range names {
+		param += ",M" + name + "=" + goPackages[name]
+	}
+	return param
+}
+
+// Generate returns the .pb.go files of the files req names to generate,
+// keyed by the name of each file, which is that of its .proto file with the
+// .proto extension replaced. The parameter of req is that of
+// protoc-gen-gogofaster, such as one returned by MappedParameter; Parameter
+// if it is empty. req itself is not modified.
+func Generate(req *plugin.CodeGeneratorRequest) (map[string]io.Reader, error) {
+	req = proto.Clone(req).(*plugin.CodeGeneratorRequest)
+	if req.GetParameter() == "" {
+		req.Parameter = proto.String(Parameter)
+	}
+
+	// These are the options protoc-gen-gogofaster turns on
+	files := vanity.FilterFiles(req.GetProtoFile(), vanity.NotGoogleProtobufDescriptorProto)
+	vanity.ForEachFile(files, vanity.TurnOnMarshalerAll)
+	vanity.ForEachFile(files, vanity.TurnOnSizerAll)
+	vanity.ForEachFile(files, vanity.TurnOnUnmarshalerAll)
+	vanity.ForEachFieldInFilesExcludingExtensions(vanity.OnlyProto2(files), vanity.TurnOffNullableForNativeTypesWithoutDefaultsOnly)
+	vanity.ForEachFile(files, vanity.TurnOffGoUnrecognizedAll)
+	vanity.ForEachFile(files, vanity.TurnOffGoUnkeyedAll)
+	vanity.ForEachFile(files, vanity.TurnOffGoSizecacheAll)
+	for _, f := range files {
+		flattenProto3Optional(f.MessageType)
+	}
+
+	// This is command.Generate without its pass of the testgen plugin,
+	// which protoc-gen-gogofaster never enables and which leaves testgen as
+	// the only plugin of the generator for any later generation in process
+	g := generator.New()
+	g.Request = req
+	g.CommandLineParameters(req.GetParameter())
+	g.WrapTypes()
+	g.SetPackageNames()
+	g.BuildTypeNameMap()
+	g.GenerateAllFiles()
+	if g.Response.Error != nil {
+		return nil, errors.Errorf("cannot generate .pb.go files: %s", g.Response.GetError())
+	}
+
+	rv := make(map[string]io.Reader)
+	for _, f := range g.Response.File {
+		code, err := format.Source([]byte(f.GetContent()))
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot format generated %s", f.GetName())
+		}
+		rv[f.GetName()] = strings.NewReader(string(code))
+	}
+	return rv, nil
+}
+
+// flattenProto3Optional removes the synthetic oneof of each proto3 optional
+// field of msgs and their nested messages. The gogo generator predates proto3
+// optional fields and would generate those oneofs as real ones, where svcdef
+// takes the fields to be plain fields.
+func flattenProto3Optional(msgs []*descriptor.DescriptorProto) {
+	for _, m := range msgs {
+		flattenProto3Optional(m.NestedType)
+
+		// protoc places synthetic oneofs after all the real oneofs
+		synthetic := len(m.OneofDecl)
+		for _, f := range m.Field {
+			if !descutil.Proto3Optional(f) {
+				continue
+			}
+			if i := int(f.GetOneofIndex()); f.OneofIndex != nil && i < synthetic {
+				synthetic = i
+			}
+			f.OneofIndex = nil
+			f.XXX_unrecognized = nil
+		}
+		m.OneofDecl = m.OneofDecl[:synthetic]
+	}
+}
@@ -0,0 +1,183 @@
+package pbgo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/metaverse/truss/truss/parseproto"
+)
+
+func TestGenerateProto3Optional(t *testing.T) {
+	dir, err := ioutil.TempDir("", "truss-pbgo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	def := `
+		syntax = "proto3";
+
+		package general;
+
+		option go_package = "example.com/general/pb";
+
+		message Msg {
+			optional string name = 1;
+			oneof choice {
+				string label = 2;
+			}
+		}
+	`
+	if err := os.Mkdir(filepath.Join(dir, "general"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	protoPath := filepath.Join(dir, "general", "general.proto")
+	if err := ioutil.WriteFile(protoPath, []byte(def), 0644); err != nil {
+		t.Fatal(err)
+	}
+	req, err := parseproto.CodeGeneratorRequest([]string{protoPath}, []string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := Generate(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, ok := files["general/general.pb.go"]
+	if !ok {
+		t.Fatalf("general/general.pb.go not generated: %v", files)
+	}
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := string(b)
+
+	if strings.Contains(code, "isMsg_XName") || strings.Contains(code, "Msg_Name") {
+		t.Error("optional field name generated as a oneof")
+	}
+	if !strings.Contains(code, "isMsg_Choice") {
+		t.Error("oneof choice not generated as a oneof")
+	}
+}
+
+func TestGenerateMappedParameter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "truss-pbgo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"types/types.proto": `
+			syntax = "proto3";
+			package types;
+			message Item {
+				string name = 1;
+			}
+		`,
+		"svc/svc.proto": `
+			syntax = "proto3";
+			package svc;
+			import "types/types.proto";
+			message Req {
+				types.Item item = 1;
+			}
+		`,
+	}
+	for name, def := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(def), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req, err := parseproto.CodeGeneratorRequest([]string{filepath.Join(dir, "svc", "svc.proto")}, []string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	param := MappedParameter(map[string]string{
+		"svc/svc.proto":     "example.com/svc",
+		"types/types.proto": "example.com/shared/types",
+	})
+	req.Parameter = &param
+
+	gen, err := Generate(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, ok := gen["svc/svc.pb.go"]
+	if !ok {
+		t.Fatalf("svc/svc.pb.go not generated: %v", gen)
+	}
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"example.com/shared/types"`) {
+		t.Errorf("svc/svc.pb.go does not import types from the mapped import path:\n%s", b)
+	}
+	if req.GetParameter() != param {
+		t.Errorf("Generate modified the parameter of req to %q", req.GetParameter())
+	}
+}
+
+// Test that generating more than once in a process generates the same files,
+// such as when generating the files of each directory of a definition.
+func TestGenerateRepeatedly(t *testing.T) {
+	dir, err := ioutil.TempDir("", "truss-pbgo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	def := `
+		syntax = "proto3";
+		package repeat;
+		message Msg {
+			string name = 1;
+		}
+	`
+	protoPath := filepath.Join(dir, "repeat.proto")
+	if err := ioutil.WriteFile(protoPath, []byte(def), 0644); err != nil {
+		t.Fatal(err)
+	}
+	req, err := parseproto.CodeGeneratorRequest([]string{protoPath}, []string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var first string
+	for i := 0; i < 2; i++ {
+		files, err := Generate(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, ok := files["repeat.pb.go"]
+		if !ok {
+			t.Fatalf("repeat.pb.go not generated: %v", files)
+		}
+		b, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		code := string(b)
+
+		for _, method := range []string{") Marshal(", ") Unmarshal(", ") Size("} {
+			if !strings.Contains(code, method) {
+				t.Errorf("generation %d: method %q not generated", i+1, strings.Trim(method, ") ("))
+			}
+		}
+		if i == 0 {
+			first = code
+		} else if code != first {
+			t.Error("second generation differs from the first")
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package truss
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// ReadPreviousGeneration returns a map[string]io.Reader representing the files in serviceDir
+func ReadPreviousGeneration(serviceDir string) (map[string]io.Reader, error) {
+	if !fileExists(serviceDir) {
+		return nil, nil
+	}
+
+	const handlersDirName = "handlers"
+	files := make(map[string]io.Reader)
+
+	addFileToFiles := func(path string, info os.FileInfo, err error) error {
+		if info.IsDir() {
+			switch info.Name() {
+			// Only files within the handlers dir are used to
+			// support regeneration.
+			// See `gengokit/generator/gen.go:generateResponseFile`
+			case filepath.Base(serviceDir), handlersDirName:
+				return nil
+			default:
+				return filepath.SkipDir
+			}
+		}
+
+		file, ioErr := os.Open(path)
+		if ioErr != nil {
+			return errors.Wrapf(ioErr, "cannot read file: %v", path)
+		}
+
+		// trim the prefix of the path to the proto files from the full path to the file
+		relPath, err := filepath.Rel(serviceDir, path)
+		if err != nil {
+			return err
+		}
+
+		// ensure relPath is unix-style, so it matches what we look for later
+		relPath = filepath.ToSlash(relPath)
+
+		files[relPath] = file
+
+		return nil
+	}
+
+	err := filepath.Walk(serviceDir, addFileToFiles)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot fully walk directory %v", serviceDir)
+	}
+
+	return files, nil
+}
+
+// fileExists checks if a file at the given path exists. Returns true if the
+// file exists, and false if the file does not exist.
+func fileExists(path string) bool {
+	if _, err := os.Stat(path); err == nil {
+		return true
+	}
+	return false
+}
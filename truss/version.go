@@ -0,0 +1,34 @@
+package truss
+
+import "runtime/debug"
+
+// Version returns the version of truss and the date of its commit, as
+// recorded within the running binary by the go command. The version is that
+// of the module for a binary built with go install ...@version, and otherwise
+// the revision of the repository it was built within. Either is empty if it
+// is not known.
+func Version() (version, date string) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "", ""
+	}
+	return buildVersion(info)
+}
+
+// buildVersion returns the version and date Version reads from info.
+func buildVersion(info *debug.BuildInfo) (version, date string) {
+	if info.Main.Version != "(devel)" {
+		version = info.Main.Version
+	}
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			if version == "" {
+				version = s.Value
+			}
+		case "vcs.time":
+			date = s.Value
+		}
+	}
+	return version, date
+}
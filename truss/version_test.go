@@ -0,0 +1,41 @@
+package truss
+
+import (
+	"runtime/debug"
+	"testing"
+)
+
+func TestBuildVersion(t *testing.T) {
+	vcs := []debug.BuildSetting{
+		{Key: "-compiler", Value: "gc"},
+		{Key: "vcs.revision", Value: "0123abcd"},
+		{Key: "vcs.time", Value: "2022-01-02T03:04:05Z"},
+	}
+	for _, tc := range []struct {
+		name          string
+		info          debug.BuildInfo
+		version, date string
+	}{
+		{
+			name:    "installed at a version",
+			info:    debug.BuildInfo{Main: debug.Module{Version: "v0.4.0"}, Settings: vcs},
+			version: "v0.4.0",
+			date:    "2022-01-02T03:04:05Z",
+		},
+		{
+			name:    "built within the repository",
+			info:    debug.BuildInfo{Main: debug.Module{Version: "(devel)"}, Settings: vcs},
+			version: "0123abcd",
+			date:    "2022-01-02T03:04:05Z",
+		},
+		{
+			name: "built without version control",
+			info: debug.BuildInfo{Main: debug.Module{Version: "(devel)"}},
+		},
+	} {
+		version, date := buildVersion(&tc.info)
+		if version != tc.version || date != tc.date {
+			t.Errorf("%s: buildVersion = %q, %q; want %q, %q", tc.name, version, date, tc.version, tc.date)
+		}
+	}
+}
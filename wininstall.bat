@@ -21,18 +21,10 @@ FOR /F "tokens=* USEBACKQ" %%F IN (`%DATE_FMT_CMD%`) DO (
 )
 
 @ECHO ON
-go get github.com/pauln/go-datefmt
+go install github.com/pauln/go-datefmt@latest
 
-:: go get github.com/gogo/protobuf/protoc-gen-gogo
-go get -u github.com/gogo/protobuf/protoc-gen-gogo@21df5aa0e680850681b8643f0024f92d3b09930c
-go get -u github.com/gogo/protobuf/protoc-gen-gogofaster@21df5aa0e680850681b8643f0024f92d3b09930c
+go install github.com/gogo/protobuf/protoc-gen-gogo@21df5aa0e680850681b8643f0024f92d3b09930c
+go install github.com/gogo/protobuf/protoc-gen-gogofaster@21df5aa0e680850681b8643f0024f92d3b09930c
 
-:: go get github.com/gogo/protobuf/proto
-go get -u github.com/gogo/protobuf/proto@21df5aa0e680850681b8643f0024f92d3b09930c
-
-:: go get github.com/jteeuwen/go-bindata/...
-go get -u github.com/kevinburke/go-bindata/go-bindata
-
-go generate github.com/metaverse/truss/gengokit/template
 go install -ldflags "-X 'main.version=%SHA%' -X 'main.date=%HEAD_DATE%'" github.com/metaverse/truss/cmd/truss
 @ECHO OFF